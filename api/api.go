@@ -4,21 +4,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/credentials"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/diagnostics"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/dossier"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/episode"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/notification"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/onboarding"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/organizations"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/patients"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/reports"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer/receiver"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer/sender"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/trust"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
 
 	"github.com/lestrrat-go/jwx/jwt"
 	nutsClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
-	nutsAuth "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
 
 	"github.com/labstack/echo/v4"
@@ -51,6 +60,23 @@ type Wrapper struct {
 	EpisodeService          episode.Service
 	NotificationHandler     notification.Handler
 	TenantInitializer       func(tenant int) error
+	UserRepository          users.Repository
+	// SigningMeans contains the means of the Nuts node users can sign the login contract with, by name.
+	SigningMeans map[string]SigningMeans
+	SignSessions *SignSessionTracker
+	// Onboarding sets customers up on the Nuts network, nil if it isn't configured.
+	Onboarding onboarding.Service
+	// Diagnostics checks the registration of customers on the Nuts network.
+	Diagnostics diagnostics.Service
+	// OrganizationSearch searches other care organizations, ranking the favourite partners and closest ones first.
+	OrganizationSearch  organizations.SearchService
+	FavouriteRepository organizations.FavouriteRepository
+	// Trust manages which issuers of credentials the Nuts node trusts.
+	Trust trust.Service
+	// Credentials gives an overview of the authorization credentials customers issued.
+	Credentials credentials.Service
+	// Caches contains the caches of the EHR by name, of which admins can see the usage statistics.
+	Caches map[string]*cache.Cache
 }
 
 func (w Wrapper) CheckSession(ctx echo.Context) error {
@@ -58,12 +84,29 @@ func (w Wrapper) CheckSession(ctx echo.Context) error {
 	return ctx.NoContent(http.StatusNoContent)
 }
 
+func (w Wrapper) Logout(ctx echo.Context) error {
+	sessionID, ok := ctx.Get(SessionID).(string)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "could not get sessionID from token")
+	}
+	if err := w.APIAuth.Logout(ctx.Request().Context(), sessionID); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
 func (w Wrapper) SetCustomer(ctx echo.Context) error {
 	customer := types.Customer{}
 	if err := ctx.Bind(&customer); err != nil {
 		return err
 	}
 
+	if existing, err := w.CustomerRepository.FindByID(ctx.Request().Context(), customer.Id); err != nil {
+		return err
+	} else if existing == nil || !existing.Active {
+		return echo.NewHTTPError(http.StatusNotFound, "customer unknown")
+	}
+
 	token, err := w.APIAuth.CreateCustomerJWT(customer.Id)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
@@ -82,12 +125,12 @@ func (w Wrapper) AuthenticateWithPassword(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse{err})
 	}
 
-	sessionId, err := w.APIAuth.AuthenticatePassword(req.CustomerID, req.Password)
+	sessionId, err := w.APIAuth.AuthenticatePassword(ctx.Request().Context(), req.CustomerID, req.Username, req.Password)
 	if err != nil {
 		return ctx.JSON(http.StatusForbidden, errorResponse{err})
 	}
 
-	customer, err := w.CustomerRepository.FindByID(req.CustomerID)
+	customer, err := w.CustomerRepository.FindByID(ctx.Request().Context(), req.CustomerID)
 	if err != nil {
 		return err
 	}
@@ -100,129 +143,105 @@ func (w Wrapper) AuthenticateWithPassword(ctx echo.Context) error {
 	return ctx.JSON(200, types.SessionToken{Token: string(token)})
 }
 
-func (w Wrapper) AuthenticateWithIRMA(ctx echo.Context) error {
-	customerID, err := w.APIAuth.GetCustomerIDFromHeader(ctx)
-	if err != nil {
-		return err
-	}
-	customer, err := w.CustomerRepository.FindByID(customerID)
-	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errorResponse{err})
-	}
-
-	// forward to node
-	bytes, err := w.NutsAuth.CreateIrmaSession(*customer)
-	if err != nil {
-		return err
-	}
+type HandleOIDCCallbackParams = types.HandleOIDCCallbackParams
+type StartOIDCLoginParams = types.StartOIDCLoginParams
 
-	// convert to map so echo rendering doesn't escape double quotes
-	j := map[string]interface{}{}
-	json.Unmarshal(bytes, &j)
-	return ctx.JSON(http.StatusOK, j)
+func (w Wrapper) GetOIDCStatus(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, types.OIDCStatus{Enabled: w.APIAuth.OIDCEnabled()})
 }
 
-func (w Wrapper) GetIRMAAuthenticationResult(ctx echo.Context, sessionToken string) error {
-	customerID, err := w.APIAuth.GetCustomerIDFromHeader(ctx)
-	if err != nil {
-		return err
+func (w Wrapper) StartOIDCLogin(ctx echo.Context, params StartOIDCLoginParams) error {
+	if !w.APIAuth.OIDCEnabled() {
+		return echo.NewHTTPError(http.StatusNotFound, "OpenID Connect login is not enabled")
 	}
-
-	// forward to node
-	sessionStatus, err := w.NutsAuth.GetIrmaSessionResult(sessionToken)
+	redirectPath := ""
+	if params.Redirect != nil {
+		redirectPath = *params.Redirect
+	}
+	authorizationURL, nonce, err := w.APIAuth.OIDCAuthorizationURL(ctx.Request().Context(), redirectPath)
 	if err != nil {
 		return err
 	}
+	setOIDCCookie(ctx, OIDCNonceCookie, nonce, MaxOIDCLoginDuration)
+	return ctx.Redirect(http.StatusFound, authorizationURL)
+}
 
-	if sessionStatus.Status != "DONE" {
-		return echo.NewHTTPError(http.StatusNotFound, "signing session not completed")
+// HandleOIDCCallback finishes the login at the OpenID Connect provider. Since the user's browser is redirected here,
+// the result is passed to the application by redirecting to its OIDC login page, which retrieves the session token
+// from the OIDCSessionCookie with ExchangeOIDCSession.
+func (w Wrapper) HandleOIDCCallback(ctx echo.Context, params HandleOIDCCallbackParams) error {
+	redirectWithError := func(message string) error {
+		return ctx.Redirect(http.StatusFound, "/#/auth/oidc?"+url.Values{"error": []string{message}}.Encode())
 	}
-
-	sessionID := w.APIAuth.StoreVP(customerID, *sessionStatus.VerifiablePresentation)
-
-	customer, err := w.CustomerRepository.FindByID(customerID)
-	if err != nil {
-		return err
+	// the nonce is only used once
+	browserNonce := ""
+	if cookie, err := ctx.Cookie(OIDCNonceCookie); err == nil {
+		browserNonce = cookie.Value
 	}
-
-	newToken, err := w.APIAuth.CreateSessionJWT(customer.Name, customerID, sessionID, true)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	setOIDCCookie(ctx, OIDCNonceCookie, "", 0)
+	if params.Error != nil {
+		return redirectWithError(*params.Error)
 	}
-
-	return ctx.JSON(200, types.SessionToken{Token: string(newToken)})
-}
-
-func (w Wrapper) AuthenticateWithDummy(ctx echo.Context) error {
-	customerID, err := w.APIAuth.GetCustomerIDFromHeader(ctx)
-	if err != nil {
-		return err
+	if params.Code == nil || params.State == nil {
+		return redirectWithError("missing code or state")
 	}
 
-	customer, err := w.CustomerRepository.FindByID(customerID)
+	login, err := w.APIAuth.AuthenticateOIDC(ctx.Request().Context(), *params.Code, *params.State, browserNonce)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errorResponse{err})
+		ctx.Logger().Warnf("OpenID Connect login failed: %s", err)
+		return redirectWithError("authentication failed")
 	}
 
-	bytes, err := w.NutsAuth.CreateDummySession(*customer)
+	customer, err := w.CustomerRepository.FindByID(ctx.Request().Context(), login.CustomerID)
 	if err != nil {
 		return err
 	}
 
-	// convert to map so echo rendering doesn't escape double quotes
-	j := map[string]interface{}{}
-
-	if err := json.Unmarshal(bytes, &j); err != nil {
-		return err
-	}
-
-	return ctx.JSON(http.StatusOK, j)
-}
-
-func (w Wrapper) GetDummyAuthenticationResult(ctx echo.Context, sessionToken string) error {
-	customerID, err := w.APIAuth.GetCustomerIDFromHeader(ctx)
+	token, err := w.APIAuth.CreateSessionJWT(customer.Name, login.CustomerID, login.SessionID, false)
 	if err != nil {
-		return err
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
-	var sessionResult *nutsAuth.SignSessionStatusResponse
-
-	// for dummy, it takes a few request to get to status completed.
-	for i := 0; i < 4; i++ {
-		// forward to node
-		sessionResult, err = w.NutsAuth.GetDummySessionResult(sessionToken)
-		if err != nil {
-			return err
-		}
-
-		if sessionResult.Status == "completed" {
-			break
-		}
+	setOIDCCookie(ctx, OIDCSessionCookie, string(token), time.Minute)
+	query := url.Values{}
+	// only redirect within the application
+	if strings.HasPrefix(login.RedirectPath, "/") && !strings.HasPrefix(login.RedirectPath, "//") {
+		query.Set("redirect", login.RedirectPath)
 	}
+	return ctx.Redirect(http.StatusFound, "/#/auth/oidc?"+query.Encode())
+}
 
-	if sessionResult.Status != "completed" {
-		return echo.NewHTTPError(http.StatusNotFound, "signing session not completed")
+func (w Wrapper) ExchangeOIDCSession(ctx echo.Context) error {
+	cookie, err := ctx.Cookie(OIDCSessionCookie)
+	if err != nil || cookie.Value == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no OpenID Connect login finished in this browser")
 	}
+	setOIDCCookie(ctx, OIDCSessionCookie, "", 0)
+	return ctx.JSON(http.StatusOK, types.SessionToken{Token: cookie.Value})
+}
 
-	sessionID := w.APIAuth.StoreVP(customerID, *sessionResult.VerifiablePresentation)
-
-	customer, err := w.CustomerRepository.FindByID(customerID)
-	if err != nil {
-		return err
+// setOIDCCookie sets a cookie that's only sent to the OpenID Connect endpoints, it's removed if maxAge is 0.
+func setOIDCCookie(ctx echo.Context, name, value string, maxAge time.Duration) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/web/auth/oidc",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   ctx.IsTLS(),
+		// the browser is redirected back from the provider, which is a cross-site top-level navigation
+		SameSite: http.SameSiteLaxMode,
 	}
-
-	newToken, err := w.APIAuth.CreateSessionJWT(customer.Name, customerID, sessionID, true)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	if maxAge == 0 {
+		cookie.MaxAge = -1
 	}
-
-	return ctx.JSON(200, types.SessionToken{Token: string(newToken)})
+	ctx.SetCookie(cookie)
 }
 
 func (w Wrapper) GetCustomer(ctx echo.Context) error {
 	customerID := ctx.Get(CustomerID)
 
-	customer, err := w.CustomerRepository.FindByID(customerID.(int))
+	customer, err := w.CustomerRepository.FindByID(ctx.Request().Context(), customerID.(int))
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{err})
 	}
@@ -230,11 +249,18 @@ func (w Wrapper) GetCustomer(ctx echo.Context) error {
 }
 
 func (w Wrapper) ListCustomers(ctx echo.Context) error {
-	customers, err := w.CustomerRepository.All()
+	customers, err := w.CustomerRepository.All(ctx.Request().Context())
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{err})
 	}
-	return ctx.JSON(http.StatusOK, customers)
+	// deactivated customers can't be selected to log in
+	active := []types.Customer{}
+	for _, customer := range customers {
+		if customer.Active {
+			active = append(active, customer)
+		}
+	}
+	return ctx.JSON(http.StatusOK, active)
 }
 
 // customerIDFromToken gets the customerID from the jwt
@@ -244,6 +270,11 @@ func customerIDFromToken(token jwt.Token) (int, bool) {
 	if !ok {
 		return 0, false
 	}
+	return parseCustomerID(rawCustomerID)
+}
+
+// parseCustomerID parses a customerID claim, which can be a number or a string
+func parseCustomerID(rawCustomerID interface{}) (int, bool) {
 	switch customerID := rawCustomerID.(type) {
 	case float64:
 		return int(customerID), true
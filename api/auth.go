@@ -1,15 +1,11 @@
 package api
 
 import (
-	"crypto/ecdsa"
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
-	"reflect"
 	"strings"
-	"sync"
 	"time"
 
 	jwt2 "github.com/golang-jwt/jwt"
@@ -18,6 +14,9 @@ import (
 	"github.com/lestrrat-go/jwx/jwt"
 	"github.com/lestrrat-go/jwx/jwt/openid"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
+	"github.com/nuts-foundation/nuts-demo-ehr/http/proxy"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
 )
 
@@ -26,14 +25,31 @@ const CustomerID = "cid"
 const SessionID = "sid"
 const Elevated = "elv"
 
+// sessionKey is the key under which the JWTHandler stores the caller's Session in the echo context
+const sessionKey = "session"
+
+// MaxLaunchTokenAge is the maximum age of a launch token, local apps have to request a new one when it expires.
+const MaxLaunchTokenAge = 15 * time.Minute
+
+// LaunchTokenAudience is the audience of launch tokens, which may only be used on the FHIR proxy.
+const LaunchTokenAudience = "fhir-proxy"
+
+// LaunchScope is the (SMART on FHIR) scope of launch tokens: reading all resources of a single patient.
+const LaunchScope = "launch/patient patient/*.read"
+const Patient = "patient"
+const Scope = "scope"
+
+// DefaultRoles are the roles of users that log in without an account of the EHR, e.g. only with IRMA.
+var DefaultRoles = []types.UserRole{types.UserRoleNurse, types.UserRolePlanner}
+
 type Auth struct {
-	// sessions maps session identifiers to VPs
-	sessions map[string]Session
-	// mux is used to secure access to internal state of this struct to prevent racy behaviour
-	mux        sync.RWMutex
-	customers  customers.Repository
-	password   string
-	sessionKey *ecdsa.PrivateKey
+	// sessions stores the VPs of the sessions by session identifier
+	sessions  SessionStore
+	customers customers.Repository
+	users     users.Repository
+	keys      *KeyRing
+	// oidc is the OpenID Connect provider users can log in with, nil if not enabled
+	oidc *oidcProvider
 }
 
 type Session struct {
@@ -41,6 +57,14 @@ type Session struct {
 	CustomerID  int
 	StartTime   time.Time
 	UserContext bool
+	// Username is the user that logged in, empty if the user didn't log in with an account of the EHR (e.g. IRMA only).
+	Username string
+	Roles    []types.UserRole
+}
+
+// HasRole returns true if the user of the session has any of the given roles.
+func (s Session) HasRole(roles ...types.UserRole) bool {
+	return users.HasRole(s.Roles, roles...)
 }
 
 type JWTCustomClaims struct {
@@ -49,12 +73,12 @@ type JWTCustomClaims struct {
 	jwt2.StandardClaims
 }
 
-func NewAuth(key *ecdsa.PrivateKey, customers customers.Repository, passwd string) *Auth {
+func NewAuth(keys *KeyRing, customers customers.Repository, users users.Repository, sessions SessionStore) *Auth {
 	return &Auth{
-		sessionKey: key,
-		customers:  customers,
-		sessions:   map[string]Session{},
-		password:   passwd,
+		keys:      keys,
+		customers: customers,
+		users:     users,
+		sessions:  sessions,
 	}
 }
 
@@ -65,20 +89,12 @@ func (auth *Auth) CreateCustomerJWT(customerId int) ([]byte, error) {
 	t.Set(jwt.ExpirationKey, time.Now().Add(MaxSessionAge))
 	t.Set(CustomerID, customerId)
 
-	return jwt.Sign(t, jwa.ES256, auth.sessionKey)
+	return auth.sign(t)
 }
 
-func (auth *Auth) GetSessions() map[string]Session {
-	auth.mux.RLock()
-	defer auth.mux.RUnlock()
-
-	sessions := map[string]Session{}
-
-	for token, session := range auth.sessions {
-		sessions[token] = session
-	}
-
-	return sessions
+// Logout ends the session, after which the JWTs and launch tokens issued for it are no longer accepted.
+func (auth *Auth) Logout(ctx context.Context, sessionID string) error {
+	return auth.sessions.Delete(ctx, sessionID)
 }
 
 func (auth *Auth) GetCustomerIDFromHeader(ctx echo.Context) (int, error) {
@@ -104,12 +120,94 @@ func (auth *Auth) CreateSessionJWT(subject string, customerId int, session strin
 	t.Set(SessionID, session)
 	t.Set(Elevated, elevated)
 
-	return jwt.Sign(t, jwa.ES256, auth.sessionKey)
+	return auth.sign(t)
+}
+
+// CreateLaunchToken creates a token for local apps to access the FHIR proxy on behalf of the session, scoped to the given patient.
+func (auth *Auth) CreateLaunchToken(customerId int, session string, patientID string) ([]byte, error) {
+	t := openid.New()
+	t.Set(jwt.AudienceKey, LaunchTokenAudience)
+	t.Set(jwt.IssuedAtKey, time.Now())
+	t.Set(jwt.ExpirationKey, time.Now().Add(MaxLaunchTokenAge))
+	t.Set(CustomerID, customerId)
+	t.Set(SessionID, session)
+	t.Set(Patient, patientID)
+	t.Set(Scope, LaunchScope)
+
+	return auth.sign(t)
+}
+
+// ValidateLaunchToken checks the launch token and whether the session it was created for still exists.
+func (auth *Auth) ValidateLaunchToken(ctx context.Context, token string) (*proxy.LaunchContext, error) {
+	t, err := auth.parse([]byte(token), jwt.WithAudience(LaunchTokenAudience))
+	if err != nil {
+		return nil, err
+	}
+
+	customerID, ok := customerIDFromToken(t)
+	if !ok {
+		return nil, errors.New("could not get customerID from token")
+	}
+	session, _ := t.Get(SessionID)
+	sessionID, ok := session.(string)
+	if !ok {
+		return nil, errors.New("could not get sessionID from token")
+	}
+	patient, _ := t.Get(Patient)
+	patientID, ok := patient.(string)
+	if !ok || patientID == "" {
+		return nil, errors.New("could not get patient from token")
+	}
+
+	if session, err := auth.sessions.Get(ctx, sessionID); err != nil {
+		return nil, err
+	} else if session == nil {
+		return nil, errors.New("session unknown")
+	}
+
+	return &proxy.LaunchContext{
+		CustomerID: customerID,
+		SessionID:  sessionID,
+		PatientID:  patientID,
+	}, nil
+}
+
+// StoreVP stores the given VP under a new session identifier.
+// When the session is elevated, the user and roles of the current session are kept.
+func (auth *Auth) StoreVP(ctx context.Context, customerID int, current *Session, VP auth.VerifiablePresentation) (string, error) {
+	session := Session{
+		Credential:  VP,
+		CustomerID:  customerID,
+		UserContext: true,
+		Roles:       DefaultRoles,
+	}
+	if current != nil {
+		session.Username = current.Username
+		session.Roles = current.Roles
+	}
+	return auth.createSession(ctx, session)
 }
 
-// StoreVP stores the given VP under a new identifier or existing identifier
-func (auth *Auth) StoreVP(customerID int, VP auth.VerifiablePresentation) string {
-	return auth.createSession(customerID, VP, true)
+// GetSessionFromHeader returns the session of the JWT in the header, or nil if the JWT isn't for a session
+// (e.g. only selects the customer).
+func (auth *Auth) GetSessionFromHeader(ctx echo.Context) (*Session, error) {
+	token, err := auth.extractJWTFromHeader(ctx)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, err)
+	}
+	sessionID, ok := token.Get(SessionID)
+	if !ok {
+		return nil, nil
+	}
+	customerID, _ := customerIDFromToken(token)
+	session, err := auth.sessions.Get(ctx.Request().Context(), fmt.Sprintf("%v", sessionID))
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.CustomerID != customerID {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "session expired or logged out")
+	}
+	return session, nil
 }
 
 // JWTHandler is like the echo JWT middleware. It checks the JWT and required claims
@@ -125,14 +223,32 @@ func (auth *Auth) JWTHandler(next echo.HandlerFunc) echo.HandlerFunc {
 					ctx.Echo().Logger.Error(err)
 					return echo.NewHTTPError(http.StatusUnauthorized, err)
 				}
-				if _, ok := token.Get(SessionID); !ok {
+				// launch tokens may only be used on the FHIR proxy
+				for _, audience := range token.Audience() {
+					if audience == LaunchTokenAudience {
+						return echo.NewHTTPError(http.StatusUnauthorized, "launch token can't be used as session token")
+					}
+				}
+				sessionID, ok := token.Get(SessionID)
+				if !ok {
 					return echo.NewHTTPError(http.StatusUnauthorized, "could not get sessionID from token")
 				}
-
 				customerId, ok := customerIDFromToken(token)
 				if !ok {
 					return echo.NewHTTPError(http.StatusUnauthorized, "could not get customerID from token")
 				}
+				session, err := auth.sessions.Get(ctx.Request().Context(), fmt.Sprintf("%v", sessionID))
+				if err != nil {
+					return err
+				}
+				if session == nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, "session expired or logged out")
+				}
+				if session.CustomerID != customerId {
+					return echo.NewHTTPError(http.StatusUnauthorized, "session belongs to another customer")
+				}
+				ctx.Set(SessionID, sessionID)
+				ctx.Set(sessionKey, *session)
 				ctx.Set(CustomerID, customerId)
 			}
 		}
@@ -140,57 +256,64 @@ func (auth *Auth) JWTHandler(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
-func createPasswordVP(customerID int, password string) auth.VerifiablePresentation {
+func createPasswordVP(customerID int, username string) auth.VerifiablePresentation {
 	return auth.VerifiablePresentation{
 		Proof: map[string]interface{}{
-			"identity": fmt.Sprintf("%d%s", customerID, password),
+			"identity": fmt.Sprintf("%d/%s", customerID, username),
 		},
 	}
 }
 
-func (auth *Auth) AuthenticatePassword(customerID int, password string) (string, error) {
-	_, err := auth.customers.FindByID(customerID)
-	if err != nil {
+// AuthenticatePassword checks the username and password of a user of the customer and starts a session for that user.
+func (auth *Auth) AuthenticatePassword(ctx context.Context, customerID int, username, password string) (string, error) {
+	customer, err := auth.customers.FindByID(ctx, customerID)
+	if err != nil || customer == nil || !customer.Active {
 		return "", errors.New("invalid customer ID")
 	}
-	if auth.password != password {
+	user, err := auth.users.Authenticate(ctx, customerID, username, password)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
 		return "", errors.New("authentication failed")
 	}
-	token := auth.createSession(customerID, createPasswordVP(customerID, password), false)
-	return token, nil
+	return auth.createSession(ctx, Session{
+		Credential: createPasswordVP(customerID, user.Username),
+		CustomerID: customerID,
+		Username:   user.Username,
+		Roles:      user.Roles,
+	})
 }
 
-func (auth *Auth) createSession(customerID int, credential auth.VerifiablePresentation, userContext bool) string {
-	auth.mux.Lock()
-	defer auth.mux.Unlock()
+func (auth *Auth) createSession(ctx context.Context, session Session) (string, error) {
+	session.StartTime = time.Now()
+	return auth.sessions.Create(ctx, session)
+}
 
-	for k, v := range auth.sessions {
-		if reflect.DeepEqual(v.Credential, credential) {
-			return k
-		}
-	}
+// EndCustomerSessions ends the sessions of all users of the customer, e.g. when the customer is deactivated.
+func (auth *Auth) EndCustomerSessions(ctx context.Context, customerID int) error {
+	return auth.sessions.DeleteByCustomer(ctx, customerID)
+}
 
-	tokenBytes := make([]byte, 64)
-	_, _ = rand.Read(tokenBytes)
+// EndUserSessions ends the sessions of the user of the customer, so the user has to log in again
+// with its current roles, or can't log in at all once it's deleted.
+func (auth *Auth) EndUserSessions(ctx context.Context, customerID int, username string) error {
+	return auth.sessions.DeleteByUser(ctx, customerID, username)
+}
 
-	token := hex.EncodeToString(tokenBytes)
-	auth.sessions[token] = Session{
-		Credential:  credential,
-		StartTime:   time.Now(),
-		CustomerID:  customerID,
-		UserContext: userContext,
-	}
+func (auth *Auth) ValidateJWT(token []byte) (jwt.Token, error) {
+	return auth.parse(token)
+}
 
-	return token
+// sign signs the token with the current key of the key ring.
+func (auth *Auth) sign(t jwt.Token) ([]byte, error) {
+	return jwt.Sign(t, jwa.ES256, auth.keys.SigningKey())
 }
 
-func (auth *Auth) ValidateJWT(token []byte) (jwt.Token, error) {
-	pubKey := auth.sessionKey.PublicKey
-	t, err := jwt.Parse(token, jwt.WithVerify(jwa.ES256, pubKey), jwt.WithValidate(true))
-	if err != nil {
-		return nil, err
-	}
-	return t, nil
+// parse parses and validates a token signed by one of the keys of the key ring.
+func (auth *Auth) parse(token []byte, options ...jwt.ParseOption) (jwt.Token, error) {
+	options = append(options, jwt.WithKeySet(auth.keys.PublicKeys()), jwt.WithValidate(true))
+	return jwt.Parse(token, options...)
 }
 
 func (auth *Auth) extractJWTFromHeader(ctx echo.Context) (jwt.Token, error) {
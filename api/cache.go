@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+)
+
+// GetCacheStats returns the usage statistics of the caches of the EHR.
+func (w Wrapper) GetCacheStats(ctx echo.Context) error {
+	result := make(map[string]cache.Stats, len(w.Caches))
+	for name, c := range w.Caches {
+		result[name] = c.Stats()
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
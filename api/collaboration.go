@@ -35,10 +35,12 @@ func (w Wrapper) CreateCollaboration(ctx echo.Context, dossierID string) error {
 
 	if err := w.EpisodeService.CreateCollaboration(
 		ctx.Request().Context(),
+		customer.Id,
 		*customer.Did,
 		dossierID,
 		*patient.Ssn,
 		request.Sender.Did,
+		request.AllowCreate != nil && *request.AllowCreate,
 	); err != nil {
 		return err
 	}
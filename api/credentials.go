@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/credentials"
+)
+
+// ListIssuedCredentials lists the active authorization credentials issued by the current customer.
+func (w Wrapper) ListIssuedCredentials(ctx echo.Context) error {
+	customer := w.getCustomer(ctx)
+	if customer == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "customer unknown")
+	}
+	results, err := w.Credentials.List(ctx.Request().Context(), *customer)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (w Wrapper) RevokeIssuedCredential(ctx echo.Context, credentialID string) error {
+	customer := w.getCustomer(ctx)
+	if customer == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "customer unknown")
+	}
+	result, err := w.Credentials.Revoke(ctx.Request().Context(), *customer, credentialID)
+	if errors.Is(err, credentials.ErrCredentialNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/onboarding"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
+)
+
+// ListAllCustomers returns all customers, including the deactivated ones.
+func (w Wrapper) ListAllCustomers(ctx echo.Context) error {
+	result, err := w.CustomerRepository.All(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// CreateCustomer onboards a new customer: it's stored, its FHIR tenant is initialized and it gets an admin user,
+// which has all roles except vendor.
+func (w Wrapper) CreateCustomer(ctx echo.Context) error {
+	request := types.CreateCustomerRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	if err := validateCustomerProperties(request.Properties); err != nil {
+		return err
+	}
+	if request.AdminPassword == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "adminPassword is required")
+	}
+
+	customer, err := w.CustomerRepository.Create(ctx.Request().Context(), request.Properties)
+	if errors.Is(err, customers.ErrDIDTaken) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	} else if err != nil {
+		return err
+	}
+	if err := w.TenantInitializer(customer.Id); err != nil {
+		return fmt.Errorf("unable to initialize tenant: %w", err)
+	}
+	if _, err := w.UserRepository.Create(ctx.Request().Context(), customer.Id, "admin", request.AdminPassword, users.CustomerRoles); err != nil {
+		return fmt.Errorf("unable to create admin user: %w", err)
+	}
+	return ctx.JSON(http.StatusOK, customer)
+}
+
+func (w Wrapper) UpdateCustomer(ctx echo.Context, customerID int) error {
+	properties := types.CustomerProperties{}
+	if err := ctx.Bind(&properties); err != nil {
+		return err
+	}
+	if err := validateCustomerProperties(properties); err != nil {
+		return err
+	}
+
+	customer, err := w.CustomerRepository.Update(ctx.Request().Context(), customerID, properties)
+	if errors.Is(err, customers.ErrCustomerNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if errors.Is(err, customers.ErrDIDTaken) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	} else if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, customer)
+}
+
+// DeactivateCustomer deactivates the customer and ends the sessions of its users. Its data is kept.
+func (w Wrapper) DeactivateCustomer(ctx echo.Context, customerID int) error {
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	if cid == customerID {
+		return echo.NewHTTPError(http.StatusBadRequest, "can't deactivate the customer you're logged in with")
+	}
+
+	err = w.CustomerRepository.Deactivate(ctx.Request().Context(), customerID)
+	if errors.Is(err, customers.ErrCustomerNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return err
+	}
+	if err := w.APIAuth.EndCustomerSessions(ctx.Request().Context(), customerID); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// OnboardCustomer sets the customer up on the Nuts network. When a step fails, the customer is still returned with
+// the DID if it was created, so the admin can see where it stopped and retry.
+func (w Wrapper) OnboardCustomer(ctx echo.Context, customerID int) error {
+	if w.Onboarding == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "onboarding is not configured")
+	}
+	customer, err := w.Onboarding.Onboard(ctx.Request().Context(), customerID)
+	if customer != nil && customer.Did != nil && w.OrganizationRegistry != nil {
+		// its services and organization credential might have changed, even if a step failed
+		w.OrganizationRegistry.Invalidate(*customer.Did)
+	}
+	if errors.Is(err, customers.ErrCustomerNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if errors.Is(err, onboarding.ErrCityRequired) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil && customer == nil {
+		return err
+	} else if err != nil {
+		// no error is returned, so the DID is stored
+		return ctx.JSON(http.StatusBadGateway, errorResponse{err})
+	}
+	return ctx.JSON(http.StatusOK, customer)
+}
+
+// GetCustomerDiagnostics checks the registration of the current customer on the Nuts network.
+func (w Wrapper) GetCustomerDiagnostics(ctx echo.Context) error {
+	customer := w.getCustomer(ctx)
+	if customer == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "customer unknown")
+	}
+	return ctx.JSON(http.StatusOK, w.Diagnostics.Check(ctx.Request().Context(), *customer))
+}
+
+func validateCustomerProperties(properties types.CustomerProperties) error {
+	if strings.TrimSpace(properties.Name) == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "name is required")
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+)
+
+// memoryCustomerRepository is a customers.Repository that doesn't need a database transaction
+type memoryCustomerRepository map[int]types.Customer
+
+func newMemoryCustomerRepository(records ...types.Customer) memoryCustomerRepository {
+	result := memoryCustomerRepository{}
+	for _, customer := range records {
+		customer.Active = true
+		result[customer.Id] = customer
+	}
+	return result
+}
+
+func (m memoryCustomerRepository) FindByID(_ context.Context, id int) (*types.Customer, error) {
+	if customer, ok := m[id]; ok {
+		return &customer, nil
+	}
+	return nil, nil
+}
+
+func (m memoryCustomerRepository) FindByDID(_ context.Context, did string) (*types.Customer, error) {
+	for _, customer := range m {
+		if customer.Did != nil && *customer.Did == did {
+			return &customer, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m memoryCustomerRepository) All(_ context.Context) ([]types.Customer, error) {
+	result := []types.Customer{}
+	for _, customer := range m {
+		result = append(result, customer)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (m memoryCustomerRepository) Create(ctx context.Context, properties types.CustomerProperties) (*types.Customer, error) {
+	return m.store(ctx, len(m)+1, true, properties)
+}
+
+func (m memoryCustomerRepository) Update(ctx context.Context, id int, properties types.CustomerProperties) (*types.Customer, error) {
+	existing, ok := m[id]
+	if !ok {
+		return nil, customers.ErrCustomerNotFound
+	}
+	return m.store(ctx, id, existing.Active, properties)
+}
+
+func (m memoryCustomerRepository) Deactivate(_ context.Context, id int) error {
+	customer, ok := m[id]
+	if !ok {
+		return customers.ErrCustomerNotFound
+	}
+	customer.Active = false
+	m[id] = customer
+	return nil
+}
+
+func (m memoryCustomerRepository) store(ctx context.Context, id int, active bool, properties types.CustomerProperties) (*types.Customer, error) {
+	if properties.Did != nil {
+		if existing, _ := m.FindByDID(ctx, *properties.Did); existing != nil && existing.Id != id {
+			return nil, customers.ErrDIDTaken
+		}
+	}
+	customer := types.Customer{
+		Id:           id,
+		Active:       active,
+		Name:         properties.Name,
+		Did:          properties.Did,
+		City:         properties.City,
+		Domain:       properties.Domain,
+		SigningMeans: properties.SigningMeans,
+		Branding:     properties.Branding,
+	}
+	m[id] = customer
+	return &customer, nil
+}
+
+// stubUsers is a users.Repository that only records the created users
+type stubUsers struct {
+	created []string
+}
+
+func (s *stubUsers) FindByID(_ context.Context, _ int, _ string) (*types.User, error) {
+	return nil, nil
+}
+
+func (s *stubUsers) Authenticate(_ context.Context, _ int, _, _ string) (*types.User, error) {
+	return nil, nil
+}
+
+func (s *stubUsers) All(_ context.Context, _ int) ([]types.User, error) {
+	return nil, nil
+}
+
+func (s *stubUsers) Create(_ context.Context, _ int, username, _ string, roles []types.UserRole) (*types.User, error) {
+	s.created = append(s.created, username)
+	return &types.User{Username: username, Roles: roles}, nil
+}
+
+func (s *stubUsers) Update(_ context.Context, _ int, _ string, _ *string, _ []types.UserRole) (*types.User, error) {
+	return nil, nil
+}
+
+func (s *stubUsers) Delete(_ context.Context, _ int, _ string) error {
+	return nil
+}
+
+func TestWrapper_CustomerAdministration(t *testing.T) {
+	did := "did:nuts:care-home"
+	newWrapper := func() (Wrapper, *stubUsers, *[]int) {
+		userRepository := &stubUsers{}
+		initialized := &[]int{}
+		return Wrapper{
+			CustomerRepository: newMemoryCustomerRepository(types.Customer{Id: 1, Name: "Care Home", Did: &did}),
+			UserRepository:     userRepository,
+			TenantInitializer: func(tenant int) error {
+				*initialized = append(*initialized, tenant)
+				return nil
+			},
+		}, userRepository, initialized
+	}
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		recorder := httptest.NewRecorder()
+		ctx := echo.New().NewContext(request, recorder)
+		ctx.Set(CustomerID, 1)
+		return ctx, recorder
+	}
+	httpStatus := func(err error) int {
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			return httpErr.Code
+		}
+		return 0
+	}
+
+	t.Run("create", func(t *testing.T) {
+		w, userRepository, initialized := newWrapper()
+		ctx, recorder := newContext(`{"properties": {"name": "Hospital", "city": "Utrecht"}, "adminPassword": "secret"}`)
+
+		err := w.CreateCustomer(ctx)
+
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.JSONEq(t, `{"id": 2, "name": "Hospital", "city": "Utrecht", "active": true}`, recorder.Body.String())
+		assert.Equal(t, []int{2}, *initialized)
+		assert.Equal(t, []string{"admin"}, userRepository.created)
+	})
+	t.Run("create without name", func(t *testing.T) {
+		w, _, _ := newWrapper()
+		ctx, _ := newContext(`{"properties": {"name": " "}, "adminPassword": "secret"}`)
+
+		assert.Equal(t, http.StatusBadRequest, httpStatus(w.CreateCustomer(ctx)))
+	})
+	t.Run("create with DID of other customer", func(t *testing.T) {
+		w, _, _ := newWrapper()
+		ctx, _ := newContext(`{"properties": {"name": "Hospital", "did": "did:nuts:care-home"}, "adminPassword": "secret"}`)
+
+		assert.Equal(t, http.StatusConflict, httpStatus(w.CreateCustomer(ctx)))
+	})
+	t.Run("update unknown customer", func(t *testing.T) {
+		w, _, _ := newWrapper()
+		ctx, _ := newContext(`{"name": "Hospital"}`)
+
+		assert.Equal(t, http.StatusNotFound, httpStatus(w.UpdateCustomer(ctx, 2)))
+	})
+	t.Run("deactivated customer isn't listed for login", func(t *testing.T) {
+		w, _, _ := newWrapper()
+		ctx, _ := newContext(`{"properties": {"name": "Hospital"}, "adminPassword": "secret"}`)
+		_ = w.CreateCustomer(ctx)
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		db.SetMaxOpenConns(1)
+		w.APIAuth = NewAuth(nil, w.CustomerRepository, nil, NewSQLiteSessionStore(db))
+
+		ctx, _ = newContext("")
+		assert.NoError(t, w.DeactivateCustomer(ctx, 2))
+
+		ctx, recorder := newContext("")
+		assert.NoError(t, w.ListCustomers(ctx))
+		assert.JSONEq(t, `[{"id": 1, "name": "Care Home", "did": "did:nuts:care-home", "active": true}]`, recorder.Body.String())
+	})
+	t.Run("deactivate own customer", func(t *testing.T) {
+		w, _, _ := newWrapper()
+		ctx, _ := newContext("")
+
+		assert.Equal(t, http.StatusBadRequest, httpStatus(w.DeactivateCustomer(ctx, 1)))
+	})
+}
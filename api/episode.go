@@ -1,11 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	httpAuth "github.com/nuts-foundation/nuts-demo-ehr/http/auth"
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
 )
 
 func (w Wrapper) CreateEpisode(ctx echo.Context) error {
@@ -63,3 +66,35 @@ func (w Wrapper) GetEpisode(ctx echo.Context, dossierID string) error {
 
 	return ctx.JSON(http.StatusOK, episode)
 }
+
+func (w Wrapper) NotifyEpisodeUpdate(ctx echo.Context, episodeID string) error {
+	// This gets called by the organization holding an episode our customer collaborates in, when its reports have changed.
+	token, ok := ctx.Get(httpAuth.AccessToken).(nutsAuthClient.TokenIntrospectionResponse)
+	if !ok {
+		// should have been caught by security filter
+		return errors.New("missing access-token")
+	}
+
+	custodianDID := token.Sub
+	if custodianDID == nil {
+		return errors.New("missing 'sub' in access-token")
+	}
+	customerDID := token.Iss
+	if customerDID == nil {
+		return errors.New("missing 'iss' in access-token")
+	}
+
+	customer, err := w.CustomerRepository.FindByDID(ctx.Request().Context(), *customerDID)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "customer unknown")
+	}
+
+	if err := w.EpisodeService.RefreshReports(ctx.Request().Context(), *customerDID, *custodianDID, episodeID); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/episode"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	httpAuth "github.com/nuts-foundation/nuts-demo-ehr/http/auth"
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// stubReports records the episodes of which the reports were refreshed
+type stubReports struct {
+	episode.Service
+	refreshed []string
+	err       error
+}
+
+func (s *stubReports) RefreshReports(_ context.Context, customerDID, custodianDID, episodeID string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.refreshed = append(s.refreshed, customerDID+" "+custodianDID+" "+episodeID)
+	return nil
+}
+
+func TestWrapper_NotifyEpisodeUpdate(t *testing.T) {
+	customerDID := "did:nuts:care-home"
+	custodianDID := "did:nuts:hospital"
+	unknownDID := "did:nuts:unknown"
+	notify := func(reports *stubReports, token *nutsAuthClient.TokenIntrospectionResponse) (*httptest.ResponseRecorder, error) {
+		wrapper := Wrapper{
+			CustomerRepository: newMemoryCustomerRepository(types.Customer{Id: 1, Did: &customerDID}),
+			EpisodeService:     reports,
+		}
+		recorder := httptest.NewRecorder()
+		ctx := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), recorder)
+		if token != nil {
+			ctx.Set(httpAuth.AccessToken, *token)
+		}
+		return recorder, wrapper.NotifyEpisodeUpdate(ctx, "episode")
+	}
+
+	t.Run("ok - reports of the custodian are refreshed", func(t *testing.T) {
+		reports := &stubReports{}
+
+		recorder, err := notify(reports, &nutsAuthClient.TokenIntrospectionResponse{Iss: &customerDID, Sub: &custodianDID})
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		assert.Equal(t, []string{customerDID + " " + custodianDID + " episode"}, reports.refreshed)
+	})
+	t.Run("error - unknown customer", func(t *testing.T) {
+		reports := &stubReports{}
+
+		_, err := notify(reports, &nutsAuthClient.TokenIntrospectionResponse{Iss: &unknownDID, Sub: &custodianDID})
+
+		assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		assert.Empty(t, reports.refreshed)
+	})
+	t.Run("error - refreshing fails", func(t *testing.T) {
+		_, err := notify(&stubReports{err: errors.New("no credential")}, &nutsAuthClient.TokenIntrospectionResponse{Iss: &customerDID, Sub: &custodianDID})
+
+		assert.EqualError(t, err, "no credential")
+	})
+	t.Run("error - no access token", func(t *testing.T) {
+		_, err := notify(&stubReports{}, nil)
+
+		assert.EqualError(t, err, "missing access-token")
+	})
+}
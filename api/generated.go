@@ -17,36 +17,102 @@ type ServerInterface interface {
 	// (POST /auth)
 	SetCustomer(ctx echo.Context) error
 
-	// (POST /auth/dummy)
-	AuthenticateWithDummy(ctx echo.Context) error
+	// (GET /auth/means)
+	ListSigningMeans(ctx echo.Context) error
 
-	// (GET /auth/dummy/session/{sessionToken}/result)
-	GetDummyAuthenticationResult(ctx echo.Context, sessionToken string) error
+	// (GET /auth/oidc)
+	GetOIDCStatus(ctx echo.Context) error
 
-	// (POST /auth/irma/session)
-	AuthenticateWithIRMA(ctx echo.Context) error
+	// (GET /auth/oidc/callback)
+	HandleOIDCCallback(ctx echo.Context, params HandleOIDCCallbackParams) error
 
-	// (GET /auth/irma/session/{sessionToken}/result)
-	GetIRMAAuthenticationResult(ctx echo.Context, sessionToken string) error
+	// (GET /auth/oidc/login)
+	StartOIDCLogin(ctx echo.Context, params StartOIDCLoginParams) error
+
+	// (POST /auth/oidc/session)
+	ExchangeOIDCSession(ctx echo.Context) error
 
 	// (POST /auth/passwd)
 	AuthenticateWithPassword(ctx echo.Context) error
 
+	// (POST /auth/{means}/session)
+	CreateSignSession(ctx echo.Context, means string) error
+
+	// (GET /auth/{means}/session/{sessionToken}/events)
+	GetSignSessionEvents(ctx echo.Context, means string, sessionToken string) error
+
+	// (GET /auth/{means}/session/{sessionToken}/result)
+	GetSignSessionResult(ctx echo.Context, means string, sessionToken string) error
+
 	// (GET /customers)
 	ListCustomers(ctx echo.Context) error
 
+	// (POST /external/episode/notify/{episodeID})
+	NotifyEpisodeUpdate(ctx echo.Context, episodeID string) error
+
 	// (POST /external/transfer/notify/{taskID})
 	NotifyTransferUpdate(ctx echo.Context, taskID string) error
 
+	// (POST /internal/customer/{customerID}/episode/{episodeID}/{resourceType})
+	CreateEpisodeResource(ctx echo.Context, customerID int, episodeID string, resourceType string) error
+
 	// (PUT /internal/customer/{customerID}/task/{taskID})
 	TaskUpdate(ctx echo.Context, customerID int, taskID string) error
 
 	// (GET /private)
 	CheckSession(ctx echo.Context) error
 
+	// (GET /private/admin/cache)
+	GetCacheStats(ctx echo.Context) error
+
+	// (GET /private/admin/customer)
+	ListAllCustomers(ctx echo.Context) error
+
+	// (POST /private/admin/customer)
+	CreateCustomer(ctx echo.Context) error
+
+	// (DELETE /private/admin/customer/{customerID})
+	DeactivateCustomer(ctx echo.Context, customerID int) error
+
+	// (PUT /private/admin/customer/{customerID})
+	UpdateCustomer(ctx echo.Context, customerID int) error
+
+	// (POST /private/admin/customer/{customerID}/onboard)
+	OnboardCustomer(ctx echo.Context, customerID int) error
+
+	// (GET /private/admin/trust/{credentialType})
+	GetCredentialIssuers(ctx echo.Context, credentialType string) error
+
+	// (DELETE /private/admin/trust/{credentialType}/{issuer})
+	UntrustIssuer(ctx echo.Context, credentialType string, issuer string) error
+
+	// (PUT /private/admin/trust/{credentialType}/{issuer})
+	TrustIssuer(ctx echo.Context, credentialType string, issuer string) error
+
+	// (GET /private/admin/user)
+	ListUsers(ctx echo.Context) error
+
+	// (POST /private/admin/user)
+	CreateUser(ctx echo.Context) error
+
+	// (DELETE /private/admin/user/{userID})
+	DeleteUser(ctx echo.Context, userID string) error
+
+	// (PUT /private/admin/user/{userID})
+	UpdateUser(ctx echo.Context, userID string) error
+
 	// (GET /private/customer)
 	GetCustomer(ctx echo.Context) error
 
+	// (GET /private/customer/credentials)
+	ListIssuedCredentials(ctx echo.Context) error
+
+	// (DELETE /private/customer/credentials/{credentialID})
+	RevokeIssuedCredential(ctx echo.Context, credentialID string) error
+
+	// (GET /private/customer/diagnostics)
+	GetCustomerDiagnostics(ctx echo.Context) error
+
 	// (POST /private/dossier)
 	CreateDossier(ctx echo.Context) error
 
@@ -65,6 +131,18 @@ type ServerInterface interface {
 	// (POST /private/episode/{episodeID}/collaboration)
 	CreateCollaboration(ctx echo.Context, episodeID string) error
 
+	// (POST /private/logout)
+	Logout(ctx echo.Context) error
+
+	// (GET /private/network/favourites)
+	GetFavourites(ctx echo.Context) error
+
+	// (DELETE /private/network/favourites/{did})
+	RemoveFavourite(ctx echo.Context, did string) error
+
+	// (PUT /private/network/favourites/{did})
+	AddFavourite(ctx echo.Context, did string) error
+
 	// (GET /private/network/inbox)
 	GetInbox(ctx echo.Context) error
 
@@ -80,6 +158,9 @@ type ServerInterface interface {
 	// (PUT /private/patient/{patientID})
 	UpdatePatient(ctx echo.Context, patientID string) error
 
+	// (POST /private/patient/{patientID}/launch)
+	CreateLaunchToken(ctx echo.Context, patientID string) error
+
 	// (GET /private/patients)
 	GetPatients(ctx echo.Context, params GetPatientsParams) error
 
@@ -142,49 +223,133 @@ func (w *ServerInterfaceWrapper) SetCustomer(ctx echo.Context) error {
 	return err
 }
 
-// AuthenticateWithDummy converts echo context to params.
-func (w *ServerInterfaceWrapper) AuthenticateWithDummy(ctx echo.Context) error {
+// ListSigningMeans converts echo context to params.
+func (w *ServerInterfaceWrapper) ListSigningMeans(ctx echo.Context) error {
 	var err error
 
 	ctx.Set(BearerAuthScopes, []string{""})
 
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.AuthenticateWithDummy(ctx)
+	err = w.Handler.ListSigningMeans(ctx)
 	return err
 }
 
-// GetDummyAuthenticationResult converts echo context to params.
-func (w *ServerInterfaceWrapper) GetDummyAuthenticationResult(ctx echo.Context) error {
+// GetOIDCStatus converts echo context to params.
+func (w *ServerInterfaceWrapper) GetOIDCStatus(ctx echo.Context) error {
 	var err error
-	// ------------- Path parameter "sessionToken" -------------
-	var sessionToken string
 
-	err = runtime.BindStyledParameterWithLocation("simple", false, "sessionToken", runtime.ParamLocationPath, ctx.Param("sessionToken"), &sessionToken)
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetOIDCStatus(ctx)
+	return err
+}
+
+// HandleOIDCCallback converts echo context to params.
+func (w *ServerInterfaceWrapper) HandleOIDCCallback(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params HandleOIDCCallbackParams
+	// ------------- Optional query parameter "code" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "code", ctx.QueryParams(), &params.Code)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter sessionToken: %s", err))
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter code: %s", err))
 	}
 
+	// ------------- Optional query parameter "state" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "state", ctx.QueryParams(), &params.State)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter state: %s", err))
+	}
+
+	// ------------- Optional query parameter "error" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "error", ctx.QueryParams(), &params.Error)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter error: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.HandleOIDCCallback(ctx, params)
+	return err
+}
+
+// StartOIDCLogin converts echo context to params.
+func (w *ServerInterfaceWrapper) StartOIDCLogin(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params StartOIDCLoginParams
+	// ------------- Optional query parameter "redirect" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "redirect", ctx.QueryParams(), &params.Redirect)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter redirect: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.StartOIDCLogin(ctx, params)
+	return err
+}
+
+// ExchangeOIDCSession converts echo context to params.
+func (w *ServerInterfaceWrapper) ExchangeOIDCSession(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ExchangeOIDCSession(ctx)
+	return err
+}
+
+// AuthenticateWithPassword converts echo context to params.
+func (w *ServerInterfaceWrapper) AuthenticateWithPassword(ctx echo.Context) error {
+	var err error
+
 	ctx.Set(BearerAuthScopes, []string{""})
 
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.GetDummyAuthenticationResult(ctx, sessionToken)
+	err = w.Handler.AuthenticateWithPassword(ctx)
 	return err
 }
 
-// AuthenticateWithIRMA converts echo context to params.
-func (w *ServerInterfaceWrapper) AuthenticateWithIRMA(ctx echo.Context) error {
+// CreateSignSession converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateSignSession(ctx echo.Context) error {
 	var err error
+	// ------------- Path parameter "means" -------------
+	var means string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "means", runtime.ParamLocationPath, ctx.Param("means"), &means)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter means: %s", err))
+	}
 
 	ctx.Set(BearerAuthScopes, []string{""})
 
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.AuthenticateWithIRMA(ctx)
+	err = w.Handler.CreateSignSession(ctx, means)
 	return err
 }
 
-// GetIRMAAuthenticationResult converts echo context to params.
-func (w *ServerInterfaceWrapper) GetIRMAAuthenticationResult(ctx echo.Context) error {
+// GetSignSessionEvents converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSignSessionEvents(ctx echo.Context) error {
 	var err error
+	// ------------- Path parameter "means" -------------
+	var means string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "means", runtime.ParamLocationPath, ctx.Param("means"), &means)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter means: %s", err))
+	}
+
 	// ------------- Path parameter "sessionToken" -------------
 	var sessionToken string
 
@@ -196,18 +361,33 @@ func (w *ServerInterfaceWrapper) GetIRMAAuthenticationResult(ctx echo.Context) e
 	ctx.Set(BearerAuthScopes, []string{""})
 
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.GetIRMAAuthenticationResult(ctx, sessionToken)
+	err = w.Handler.GetSignSessionEvents(ctx, means, sessionToken)
 	return err
 }
 
-// AuthenticateWithPassword converts echo context to params.
-func (w *ServerInterfaceWrapper) AuthenticateWithPassword(ctx echo.Context) error {
+// GetSignSessionResult converts echo context to params.
+func (w *ServerInterfaceWrapper) GetSignSessionResult(ctx echo.Context) error {
 	var err error
+	// ------------- Path parameter "means" -------------
+	var means string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "means", runtime.ParamLocationPath, ctx.Param("means"), &means)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter means: %s", err))
+	}
+
+	// ------------- Path parameter "sessionToken" -------------
+	var sessionToken string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "sessionToken", runtime.ParamLocationPath, ctx.Param("sessionToken"), &sessionToken)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter sessionToken: %s", err))
+	}
 
 	ctx.Set(BearerAuthScopes, []string{""})
 
 	// Invoke the callback with all the unmarshalled arguments
-	err = w.Handler.AuthenticateWithPassword(ctx)
+	err = w.Handler.GetSignSessionResult(ctx, means, sessionToken)
 	return err
 }
 
@@ -222,6 +402,24 @@ func (w *ServerInterfaceWrapper) ListCustomers(ctx echo.Context) error {
 	return err
 }
 
+// NotifyEpisodeUpdate converts echo context to params.
+func (w *ServerInterfaceWrapper) NotifyEpisodeUpdate(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "episodeID" -------------
+	var episodeID string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "episodeID", runtime.ParamLocationPath, ctx.Param("episodeID"), &episodeID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter episodeID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.NotifyEpisodeUpdate(ctx, episodeID)
+	return err
+}
+
 // NotifyTransferUpdate converts echo context to params.
 func (w *ServerInterfaceWrapper) NotifyTransferUpdate(ctx echo.Context) error {
 	var err error
@@ -240,6 +438,40 @@ func (w *ServerInterfaceWrapper) NotifyTransferUpdate(ctx echo.Context) error {
 	return err
 }
 
+// CreateEpisodeResource converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateEpisodeResource(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "customerID" -------------
+	var customerID int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "customerID", runtime.ParamLocationPath, ctx.Param("customerID"), &customerID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter customerID: %s", err))
+	}
+
+	// ------------- Path parameter "episodeID" -------------
+	var episodeID string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "episodeID", runtime.ParamLocationPath, ctx.Param("episodeID"), &episodeID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter episodeID: %s", err))
+	}
+
+	// ------------- Path parameter "resourceType" -------------
+	var resourceType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "resourceType", runtime.ParamLocationPath, ctx.Param("resourceType"), &resourceType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter resourceType: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.CreateEpisodeResource(ctx, customerID, episodeID, resourceType)
+	return err
+}
+
 // TaskUpdate converts echo context to params.
 func (w *ServerInterfaceWrapper) TaskUpdate(ctx echo.Context) error {
 	var err error
@@ -277,6 +509,221 @@ func (w *ServerInterfaceWrapper) CheckSession(ctx echo.Context) error {
 	return err
 }
 
+// GetCacheStats converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCacheStats(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetCacheStats(ctx)
+	return err
+}
+
+// ListAllCustomers converts echo context to params.
+func (w *ServerInterfaceWrapper) ListAllCustomers(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ListAllCustomers(ctx)
+	return err
+}
+
+// CreateCustomer converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateCustomer(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.CreateCustomer(ctx)
+	return err
+}
+
+// DeactivateCustomer converts echo context to params.
+func (w *ServerInterfaceWrapper) DeactivateCustomer(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "customerID" -------------
+	var customerID int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "customerID", runtime.ParamLocationPath, ctx.Param("customerID"), &customerID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter customerID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeactivateCustomer(ctx, customerID)
+	return err
+}
+
+// UpdateCustomer converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateCustomer(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "customerID" -------------
+	var customerID int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "customerID", runtime.ParamLocationPath, ctx.Param("customerID"), &customerID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter customerID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.UpdateCustomer(ctx, customerID)
+	return err
+}
+
+// OnboardCustomer converts echo context to params.
+func (w *ServerInterfaceWrapper) OnboardCustomer(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "customerID" -------------
+	var customerID int
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "customerID", runtime.ParamLocationPath, ctx.Param("customerID"), &customerID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter customerID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.OnboardCustomer(ctx, customerID)
+	return err
+}
+
+// GetCredentialIssuers converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCredentialIssuers(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "credentialType" -------------
+	var credentialType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "credentialType", runtime.ParamLocationPath, ctx.Param("credentialType"), &credentialType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter credentialType: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetCredentialIssuers(ctx, credentialType)
+	return err
+}
+
+// UntrustIssuer converts echo context to params.
+func (w *ServerInterfaceWrapper) UntrustIssuer(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "credentialType" -------------
+	var credentialType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "credentialType", runtime.ParamLocationPath, ctx.Param("credentialType"), &credentialType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter credentialType: %s", err))
+	}
+
+	// ------------- Path parameter "issuer" -------------
+	var issuer string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "issuer", runtime.ParamLocationPath, ctx.Param("issuer"), &issuer)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter issuer: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.UntrustIssuer(ctx, credentialType, issuer)
+	return err
+}
+
+// TrustIssuer converts echo context to params.
+func (w *ServerInterfaceWrapper) TrustIssuer(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "credentialType" -------------
+	var credentialType string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "credentialType", runtime.ParamLocationPath, ctx.Param("credentialType"), &credentialType)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter credentialType: %s", err))
+	}
+
+	// ------------- Path parameter "issuer" -------------
+	var issuer string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "issuer", runtime.ParamLocationPath, ctx.Param("issuer"), &issuer)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter issuer: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.TrustIssuer(ctx, credentialType, issuer)
+	return err
+}
+
+// ListUsers converts echo context to params.
+func (w *ServerInterfaceWrapper) ListUsers(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ListUsers(ctx)
+	return err
+}
+
+// CreateUser converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateUser(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.CreateUser(ctx)
+	return err
+}
+
+// DeleteUser converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteUser(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "userID" -------------
+	var userID string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "userID", runtime.ParamLocationPath, ctx.Param("userID"), &userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter userID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.DeleteUser(ctx, userID)
+	return err
+}
+
+// UpdateUser converts echo context to params.
+func (w *ServerInterfaceWrapper) UpdateUser(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "userID" -------------
+	var userID string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "userID", runtime.ParamLocationPath, ctx.Param("userID"), &userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter userID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.UpdateUser(ctx, userID)
+	return err
+}
+
 // GetCustomer converts echo context to params.
 func (w *ServerInterfaceWrapper) GetCustomer(ctx echo.Context) error {
 	var err error
@@ -288,6 +735,46 @@ func (w *ServerInterfaceWrapper) GetCustomer(ctx echo.Context) error {
 	return err
 }
 
+// ListIssuedCredentials converts echo context to params.
+func (w *ServerInterfaceWrapper) ListIssuedCredentials(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.ListIssuedCredentials(ctx)
+	return err
+}
+
+// RevokeIssuedCredential converts echo context to params.
+func (w *ServerInterfaceWrapper) RevokeIssuedCredential(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "credentialID" -------------
+	var credentialID string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "credentialID", runtime.ParamLocationPath, ctx.Param("credentialID"), &credentialID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter credentialID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RevokeIssuedCredential(ctx, credentialID)
+	return err
+}
+
+// GetCustomerDiagnostics converts echo context to params.
+func (w *ServerInterfaceWrapper) GetCustomerDiagnostics(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetCustomerDiagnostics(ctx)
+	return err
+}
+
 // CreateDossier converts echo context to params.
 func (w *ServerInterfaceWrapper) CreateDossier(ctx echo.Context) error {
 	var err error
@@ -382,6 +869,64 @@ func (w *ServerInterfaceWrapper) CreateCollaboration(ctx echo.Context) error {
 	return err
 }
 
+// Logout converts echo context to params.
+func (w *ServerInterfaceWrapper) Logout(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.Logout(ctx)
+	return err
+}
+
+// GetFavourites converts echo context to params.
+func (w *ServerInterfaceWrapper) GetFavourites(ctx echo.Context) error {
+	var err error
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.GetFavourites(ctx)
+	return err
+}
+
+// RemoveFavourite converts echo context to params.
+func (w *ServerInterfaceWrapper) RemoveFavourite(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "did" -------------
+	var did string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "did", runtime.ParamLocationPath, ctx.Param("did"), &did)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter did: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.RemoveFavourite(ctx, did)
+	return err
+}
+
+// AddFavourite converts echo context to params.
+func (w *ServerInterfaceWrapper) AddFavourite(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "did" -------------
+	var did string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "did", runtime.ParamLocationPath, ctx.Param("did"), &did)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter did: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.AddFavourite(ctx, did)
+	return err
+}
+
 // GetInbox converts echo context to params.
 func (w *ServerInterfaceWrapper) GetInbox(ctx echo.Context) error {
 	var err error
@@ -426,6 +971,34 @@ func (w *ServerInterfaceWrapper) SearchOrganizations(ctx echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter didServiceType: %s", err))
 	}
 
+	// ------------- Optional query parameter "bolt" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "bolt", ctx.QueryParams(), &params.Bolt)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter bolt: %s", err))
+	}
+
+	// ------------- Optional query parameter "zipcode" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "zipcode", ctx.QueryParams(), &params.Zipcode)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter zipcode: %s", err))
+	}
+
+	// ------------- Optional query parameter "maxDistance" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "maxDistance", ctx.QueryParams(), &params.MaxDistance)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter maxDistance: %s", err))
+	}
+
+	// ------------- Optional query parameter "favouritesOnly" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "favouritesOnly", ctx.QueryParams(), &params.FavouritesOnly)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter favouritesOnly: %s", err))
+	}
+
 	// Invoke the callback with all the unmarshalled arguments
 	err = w.Handler.SearchOrganizations(ctx, params)
 	return err
@@ -467,6 +1040,24 @@ func (w *ServerInterfaceWrapper) UpdatePatient(ctx echo.Context) error {
 	return err
 }
 
+// CreateLaunchToken converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateLaunchToken(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "patientID" -------------
+	var patientID string
+
+	err = runtime.BindStyledParameterWithLocation("simple", false, "patientID", runtime.ParamLocationPath, ctx.Param("patientID"), &patientID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter patientID: %s", err))
+	}
+
+	ctx.Set(BearerAuthScopes, []string{""})
+
+	// Invoke the callback with all the unmarshalled arguments
+	err = w.Handler.CreateLaunchToken(ctx, patientID)
+	return err
+}
+
 // GetPatients converts echo context to params.
 func (w *ServerInterfaceWrapper) GetPatients(ctx echo.Context) error {
 	var err error
@@ -789,27 +1380,54 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	}
 
 	router.POST(baseURL+"/auth", wrapper.SetCustomer)
-	router.POST(baseURL+"/auth/dummy", wrapper.AuthenticateWithDummy)
-	router.GET(baseURL+"/auth/dummy/session/:sessionToken/result", wrapper.GetDummyAuthenticationResult)
-	router.POST(baseURL+"/auth/irma/session", wrapper.AuthenticateWithIRMA)
-	router.GET(baseURL+"/auth/irma/session/:sessionToken/result", wrapper.GetIRMAAuthenticationResult)
+	router.GET(baseURL+"/auth/means", wrapper.ListSigningMeans)
+	router.GET(baseURL+"/auth/oidc", wrapper.GetOIDCStatus)
+	router.GET(baseURL+"/auth/oidc/callback", wrapper.HandleOIDCCallback)
+	router.GET(baseURL+"/auth/oidc/login", wrapper.StartOIDCLogin)
+	router.POST(baseURL+"/auth/oidc/session", wrapper.ExchangeOIDCSession)
 	router.POST(baseURL+"/auth/passwd", wrapper.AuthenticateWithPassword)
+	router.POST(baseURL+"/auth/:means/session", wrapper.CreateSignSession)
+	router.GET(baseURL+"/auth/:means/session/:sessionToken/events", wrapper.GetSignSessionEvents)
+	router.GET(baseURL+"/auth/:means/session/:sessionToken/result", wrapper.GetSignSessionResult)
 	router.GET(baseURL+"/customers", wrapper.ListCustomers)
+	router.POST(baseURL+"/external/episode/notify/:episodeID", wrapper.NotifyEpisodeUpdate)
 	router.POST(baseURL+"/external/transfer/notify/:taskID", wrapper.NotifyTransferUpdate)
+	router.POST(baseURL+"/internal/customer/:customerID/episode/:episodeID/:resourceType", wrapper.CreateEpisodeResource)
 	router.PUT(baseURL+"/internal/customer/:customerID/task/:taskID", wrapper.TaskUpdate)
 	router.GET(baseURL+"/private", wrapper.CheckSession)
+	router.GET(baseURL+"/private/admin/cache", wrapper.GetCacheStats)
+	router.GET(baseURL+"/private/admin/customer", wrapper.ListAllCustomers)
+	router.POST(baseURL+"/private/admin/customer", wrapper.CreateCustomer)
+	router.DELETE(baseURL+"/private/admin/customer/:customerID", wrapper.DeactivateCustomer)
+	router.PUT(baseURL+"/private/admin/customer/:customerID", wrapper.UpdateCustomer)
+	router.POST(baseURL+"/private/admin/customer/:customerID/onboard", wrapper.OnboardCustomer)
+	router.GET(baseURL+"/private/admin/trust/:credentialType", wrapper.GetCredentialIssuers)
+	router.DELETE(baseURL+"/private/admin/trust/:credentialType/:issuer", wrapper.UntrustIssuer)
+	router.PUT(baseURL+"/private/admin/trust/:credentialType/:issuer", wrapper.TrustIssuer)
+	router.GET(baseURL+"/private/admin/user", wrapper.ListUsers)
+	router.POST(baseURL+"/private/admin/user", wrapper.CreateUser)
+	router.DELETE(baseURL+"/private/admin/user/:userID", wrapper.DeleteUser)
+	router.PUT(baseURL+"/private/admin/user/:userID", wrapper.UpdateUser)
 	router.GET(baseURL+"/private/customer", wrapper.GetCustomer)
+	router.GET(baseURL+"/private/customer/credentials", wrapper.ListIssuedCredentials)
+	router.DELETE(baseURL+"/private/customer/credentials/:credentialID", wrapper.RevokeIssuedCredential)
+	router.GET(baseURL+"/private/customer/diagnostics", wrapper.GetCustomerDiagnostics)
 	router.POST(baseURL+"/private/dossier", wrapper.CreateDossier)
 	router.GET(baseURL+"/private/dossier/:patientID", wrapper.GetDossier)
 	router.POST(baseURL+"/private/episode", wrapper.CreateEpisode)
 	router.GET(baseURL+"/private/episode/:episodeID", wrapper.GetEpisode)
 	router.GET(baseURL+"/private/episode/:episodeID/collaboration", wrapper.GetCollaboration)
 	router.POST(baseURL+"/private/episode/:episodeID/collaboration", wrapper.CreateCollaboration)
+	router.POST(baseURL+"/private/logout", wrapper.Logout)
+	router.GET(baseURL+"/private/network/favourites", wrapper.GetFavourites)
+	router.DELETE(baseURL+"/private/network/favourites/:did", wrapper.RemoveFavourite)
+	router.PUT(baseURL+"/private/network/favourites/:did", wrapper.AddFavourite)
 	router.GET(baseURL+"/private/network/inbox", wrapper.GetInbox)
 	router.GET(baseURL+"/private/network/inbox/info", wrapper.GetInboxInfo)
 	router.GET(baseURL+"/private/network/organizations", wrapper.SearchOrganizations)
 	router.GET(baseURL+"/private/patient/:patientID", wrapper.GetPatient)
 	router.PUT(baseURL+"/private/patient/:patientID", wrapper.UpdatePatient)
+	router.POST(baseURL+"/private/patient/:patientID/launch", wrapper.CreateLaunchToken)
 	router.GET(baseURL+"/private/patients", wrapper.GetPatients)
 	router.POST(baseURL+"/private/patients", wrapper.NewPatient)
 	router.GET(baseURL+"/private/reports/:patientID", wrapper.GetReports)
@@ -1,7 +1,6 @@
 package api
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 
@@ -18,26 +17,17 @@ func (w Wrapper) GetTransferRequest(ctx echo.Context, requestorDID string, fhirT
 		return err
 	}
 
-	var sessionWithUserContext *Session
-
-	for _, session := range w.APIAuth.GetSessions() {
-		if session.CustomerID != cid || !session.UserContext {
-			continue
-		}
-
-		sessionWithUserContext = &session
-		break
-	}
-
-	if sessionWithUserContext == nil {
-		return errors.New("unable to get transfer request without elevation")
+	// the remote call is made on behalf of the user, so it requires the user identity of the caller's own session
+	session, err := w.getElevatedSession(ctx)
+	if err != nil {
+		return err
 	}
 
 	transferRequest, err := w.TransferReceiverService.GetTransferRequest(
 		ctx.Request().Context(),
 		cid,
 		requestorDID,
-		sessionWithUserContext.Credential,
+		session.Credential,
 		fhirTaskID,
 	)
 	if err != nil {
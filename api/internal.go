@@ -1,17 +1,23 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"io"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/monarko/fhirgo/STU3/resources"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	httpAuth "github.com/nuts-foundation/nuts-demo-ehr/http/auth"
 )
 
 func (w Wrapper) TaskUpdate(ctx echo.Context, customerID int, taskID string) error {
 	// get customer
-	customer, err := w.CustomerRepository.FindByID(customerID)
+	customer, err := w.CustomerRepository.FindByID(ctx.Request().Context(), customerID)
 	if err != nil {
 		return err
 	}
@@ -42,3 +48,45 @@ func (w Wrapper) TaskUpdate(ctx echo.Context, customerID int, taskID string) err
 
 	return ctx.NoContent(http.StatusAccepted)
 }
+
+func (w Wrapper) CreateEpisodeResource(ctx echo.Context, customerID int, episodeID string, resourceType string) error {
+	// the requester is taken from the access token the proxy introspected, it can't be supplied by the caller
+	token, ok := httpAuth.AccessTokenFromContext(ctx.Request().Context())
+	if !ok || token.Sub == nil {
+		return echo.NewHTTPError(http.StatusForbidden, "access token not found")
+	}
+
+	// get customer
+	customer, err := w.CustomerRepository.FindByID(ctx.Request().Context(), customerID)
+	if err != nil {
+		return err
+	}
+	if customer == nil {
+		// shouldn't happen since this is an internal call
+		return echo.NewHTTPError(http.StatusNotFound, "customer unknown")
+	}
+
+	data, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	resource, err := w.EpisodeService.CreateContribution(ctx.Request().Context(), customer.Id, episodeID, *token.Sub, resourceType, data)
+	if err != nil {
+		if errors.Is(err, zorginzage.ErrInvalidContribution) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return err
+	}
+
+	if customer.Did != nil {
+		// notify the other collaborators in the background, like for reports created by the customer itself
+		go func(customerDID, episodeID, contributorDID string) {
+			if err := w.EpisodeService.NotifyCollaborators(context.Background(), customerDID, episodeID, contributorDID); err != nil {
+				logrus.Errorf("Unable to notify collaborators of contribution (episode=%s): %s", episodeID, err)
+			}
+		}(*customer.Did, episodeID, *token.Sub)
+	}
+
+	return ctx.JSON(http.StatusCreated, resource)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/episode"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	httpAuth "github.com/nuts-foundation/nuts-demo-ehr/http/auth"
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// stubContributions accepts contributions to the active episode and records their author and the notifications
+type stubContributions struct {
+	episode.Service
+	authors  []string
+	notified chan string
+}
+
+func (s *stubContributions) NotifyCollaborators(_ context.Context, customerDID, episodeID, contributorDID string) error {
+	s.notified <- customerDID + " " + episodeID + " " + contributorDID
+	return nil
+}
+
+func (s *stubContributions) CreateContribution(_ context.Context, _ int, episodeID, authorDID, resourceType string, _ []byte) (interface{}, error) {
+	if episodeID != "active" {
+		return nil, fmt.Errorf("%w: episode is not active", zorginzage.ErrInvalidContribution)
+	}
+	s.authors = append(s.authors, authorDID)
+	return map[string]string{"resourceType": resourceType}, nil
+}
+
+func TestWrapper_CreateEpisodeResource(t *testing.T) {
+	did := "did:nuts:custodian"
+	collaborator := "did:nuts:collaborator"
+	call := func(customerID int, episodeID string, token *nutsAuthClient.TokenIntrospectionResponse) (*stubContributions, error) {
+		contributions := &stubContributions{notified: make(chan string, 1)}
+		wrapper := Wrapper{
+			CustomerRepository: newMemoryCustomerRepository(types.Customer{Id: 1, Did: &did}),
+			EpisodeService:     contributions,
+		}
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"resourceType": "Observation"}`))
+		if token != nil {
+			request = request.WithContext(httpAuth.WithAccessToken(request.Context(), *token))
+		}
+		ctx := echo.New().NewContext(request, httptest.NewRecorder())
+		return contributions, wrapper.CreateEpisodeResource(ctx, customerID, episodeID, "Observation")
+	}
+	token := &nutsAuthClient.TokenIntrospectionResponse{Active: true, Sub: &collaborator}
+
+	t.Run("ok - the requester of the access token is the author", func(t *testing.T) {
+		contributions, err := call(1, "active", token)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{collaborator}, contributions.authors)
+	})
+	t.Run("ok - the other collaborators are notified", func(t *testing.T) {
+		contributions, err := call(1, "active", token)
+
+		require.NoError(t, err)
+		select {
+		case notification := <-contributions.notified:
+			assert.Equal(t, did+" active "+collaborator, notification)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "collaborators weren't notified")
+		}
+	})
+	t.Run("error - invalid contribution", func(t *testing.T) {
+		contributions, err := call(1, "finished", token)
+
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		assert.Empty(t, contributions.notified)
+	})
+	t.Run("error - no access token", func(t *testing.T) {
+		contributions, err := call(1, "active", nil)
+
+		assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+		assert.Empty(t, contributions.authors)
+	})
+	t.Run("error - unknown customer", func(t *testing.T) {
+		_, err := call(2, "active", token)
+
+		assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+	})
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/sirupsen/logrus"
+)
+
+// createdAtParam is the (private) JWK parameter that records when a key was created, used to decide when to rotate.
+const createdAtParam = "created_at"
+
+// KeyRing contains the keys that sign the session tokens (and other tokens) of the EHR. The newest key signs new tokens,
+// previous keys remain valid for MaxSessionAge after they've been rotated, so tokens they signed stay valid until they expire.
+// Tokens refer to their key using the `kid` header, the public keys are published as JWK set.
+type KeyRing struct {
+	mux sync.RWMutex
+	// path is the file the keys are persisted in. If empty, the keys are only kept in memory.
+	path string
+	// keys are ordered from old to new
+	keys []jwk.Key
+	now  func() time.Time
+}
+
+// NewKeyRing loads the keys from the file at the given path, which is created with a new key if it doesn't exist.
+func NewKeyRing(path string) (*KeyRing, error) {
+	ring := &KeyRing{path: path, now: time.Now}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logrus.Infof("Session key file not found, generating new keys (file=%s)", path)
+		return ring, ring.Rotate()
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read session keys: %w", err)
+	}
+
+	set, err := jwk.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse session keys: %w", err)
+	}
+	for i := 0; i < set.Len(); i++ {
+		key, _ := set.Get(i)
+		if _, ok := key.(jwk.ECDSAPrivateKey); !ok || key.KeyID() == "" {
+			return nil, fmt.Errorf("session key file must only contain EC private keys with key ID (file=%s)", path)
+		}
+		ring.keys = append(ring.keys, key)
+	}
+	if len(ring.keys) == 0 {
+		return ring, ring.Rotate()
+	}
+
+	return ring, nil
+}
+
+// NewStaticKeyRing returns a key ring with a single key that's never rotated (e.g. the configured sessionPemKey).
+func NewStaticKeyRing(privateKey *ecdsa.PrivateKey) (*KeyRing, error) {
+	key, err := newSigningKey(privateKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &KeyRing{keys: []jwk.Key{key}, now: time.Now}, nil
+}
+
+// SigningKey returns the key new tokens are signed with.
+func (r *KeyRing) SigningKey() jwk.Key {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	return r.keys[len(r.keys)-1]
+}
+
+// PublicKeys returns the public keys tokens are validated with, including those of keys that have been rotated recently.
+func (r *KeyRing) PublicKeys() jwk.Set {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	set := jwk.NewSet()
+	for _, key := range r.keys {
+		publicKey, err := key.PublicKey()
+		if err != nil {
+			// can't happen for EC keys
+			continue
+		}
+		set.Add(publicKey)
+	}
+	return set
+}
+
+// Rotate adds a new signing key and removes the keys that were rotated more than MaxSessionAge ago.
+func (r *KeyRing) Rotate() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	now := r.now()
+	key, err := newSigningKey(privateKey, now)
+	if err != nil {
+		return err
+	}
+
+	keys := []jwk.Key{}
+	for i, existing := range r.keys {
+		// a key is retired when its successor was created, tokens it signed expire MaxSessionAge after that
+		retiredAt := now
+		if i+1 < len(r.keys) {
+			retiredAt = createdAt(r.keys[i+1])
+		}
+		if now.Sub(retiredAt) < MaxSessionAge {
+			keys = append(keys, existing)
+		}
+	}
+	keys = append(keys, key)
+
+	if err := r.save(keys); err != nil {
+		return err
+	}
+	r.keys = keys
+	logrus.Infof("Rotated session key (kid=%s)", key.KeyID())
+
+	return nil
+}
+
+// RotateIfDue rotates the keys if the signing key is older than the given interval.
+func (r *KeyRing) RotateIfDue(interval time.Duration) error {
+	if r.now().Sub(createdAt(r.SigningKey())) < interval {
+		return nil
+	}
+	return r.Rotate()
+}
+
+func (r *KeyRing) save(keys []jwk.Key) error {
+	if r.path == "" {
+		return nil
+	}
+	set := jwk.NewSet()
+	for _, key := range keys {
+		set.Add(key)
+	}
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so the keys aren't lost when the EHR stops while writing
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("unable to write session keys: %w", err)
+	}
+	return os.Rename(tmpPath, r.path)
+}
+
+// StartKeyRotation periodically rotates the keys of the key ring once the signing key is older than interval,
+// until the returned function is called.
+func StartKeyRotation(ring *KeyRing, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(time.Minute)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := ring.RotateIfDue(interval); err != nil {
+					logrus.Errorf("Unable to rotate session keys: %s", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+func newSigningKey(privateKey *ecdsa.PrivateKey, created time.Time) (jwk.Key, error) {
+	key, err := jwk.New(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	thumbprint, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	_ = key.Set(jwk.KeyIDKey, base64.RawURLEncoding.EncodeToString(thumbprint))
+	_ = key.Set(jwk.AlgorithmKey, jwa.ES256)
+	_ = key.Set(jwk.KeyUsageKey, jwk.ForSignature)
+	_ = key.Set(createdAtParam, created.Unix())
+	return key, nil
+}
+
+func createdAt(key jwk.Key) time.Time {
+	value, _ := key.Get(createdAtParam)
+	switch unix := value.(type) {
+	case float64:
+		return time.Unix(int64(unix), 0)
+	case int64:
+		return time.Unix(unix, 0)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/lestrrat-go/jwx/jwt/openid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRing(t *testing.T) {
+	newRing := func(t *testing.T) (*KeyRing, string) {
+		path := filepath.Join(t.TempDir(), "session-keys.json")
+		ring, err := NewKeyRing(path)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return ring, path
+	}
+	sign := func(auth *Auth) []byte {
+		token := openid.New()
+		token.Set(jwt.ExpirationKey, time.Now().Add(time.Minute))
+		signed, _ := auth.sign(token)
+		return signed
+	}
+
+	t.Run("keys are persisted", func(t *testing.T) {
+		ring, path := newRing(t)
+
+		loaded, err := NewKeyRing(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ring.SigningKey().KeyID(), loaded.SigningKey().KeyID())
+		assert.NotEmpty(t, ring.SigningKey().KeyID())
+	})
+	t.Run("tokens of previous keys are accepted after rotation", func(t *testing.T) {
+		ring, path := newRing(t)
+		auth := &Auth{keys: ring}
+		token := sign(auth)
+		previousKeyID := ring.SigningKey().KeyID()
+
+		assert.NoError(t, ring.Rotate())
+		assert.NotEqual(t, previousKeyID, ring.SigningKey().KeyID())
+
+		_, err := auth.ValidateJWT(token)
+		assert.NoError(t, err)
+
+		// also after a restart
+		loaded, _ := NewKeyRing(path)
+		_, err = (&Auth{keys: loaded}).ValidateJWT(token)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, loaded.PublicKeys().Len())
+	})
+	t.Run("previous keys are removed when their tokens have expired", func(t *testing.T) {
+		ring, _ := newRing(t)
+		auth := &Auth{keys: ring}
+		token := sign(auth)
+		now := time.Now()
+
+		ring.now = func() time.Time { return now.Add(time.Minute) }
+		assert.NoError(t, ring.Rotate())
+		ring.now = func() time.Time { return now.Add(MaxSessionAge + 2*time.Minute) }
+		assert.NoError(t, ring.Rotate())
+
+		assert.Equal(t, 2, ring.PublicKeys().Len())
+		_, err := auth.ValidateJWT(token)
+		assert.Error(t, err)
+	})
+	t.Run("rotate if due", func(t *testing.T) {
+		ring, _ := newRing(t)
+		keyID := ring.SigningKey().KeyID()
+
+		assert.NoError(t, ring.RotateIfDue(time.Hour))
+		assert.Equal(t, keyID, ring.SigningKey().KeyID())
+
+		ring.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		assert.NoError(t, ring.RotateIfDue(time.Hour))
+		assert.NotEqual(t, keyID, ring.SigningKey().KeyID())
+	})
+	t.Run("public keys don't contain private keys", func(t *testing.T) {
+		ring, _ := newRing(t)
+
+		key, _ := ring.PublicKeys().Get(0)
+
+		_, isPrivate := key.(interface{ D() []byte })
+		assert.False(t, isPrivate)
+	})
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/lestrrat-go/jwx/jwt/openid"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// MaxOIDCLoginDuration is the maximum time a user may take to log in at the OpenID Connect provider.
+const MaxOIDCLoginDuration = 10 * time.Minute
+
+// OIDCStateAudience is the audience of the state parameter, which is a JWT signed by the EHR itself.
+const OIDCStateAudience = "oidc-state"
+const Nonce = "nonce"
+const Redirect = "redirect"
+
+// OIDCNonceCookie binds the login to the browser that started it, it contains the nonce of the state.
+const OIDCNonceCookie = "oidc-nonce"
+
+// OIDCSessionCookie passes the session token to the browser that logged in, so it doesn't end up in a URL.
+const OIDCSessionCookie = "oidc-session"
+
+// OIDCConfig configures logging in with an OpenID Connect provider, using the authorization code flow.
+type OIDCConfig struct {
+	// Issuer is the issuer URL of the provider, its metadata is discovered at {Issuer}/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL of the callback endpoint (/web/auth/oidc/callback) as registered at the provider.
+	RedirectURL string
+	Scopes      []string
+	// CustomerClaim is the ID token claim that contains the ID of the customer the user works for.
+	// Nested claims are separated by dots, e.g. `attributes.customer`, which also applies to the other claims.
+	CustomerClaim string
+	// UsernameClaim is the ID token claim that contains the username, the subject is used if it's not present.
+	UsernameClaim string
+	// RolesClaim is the ID token claim that contains the roles of the user, as list or space separated string.
+	RolesClaim string
+	// RoleMapping maps the roles of the provider to the roles of the EHR. If empty, the roles must match the EHR's roles.
+	RoleMapping map[string]types.UserRole
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+// oidcProvider logs in users at an OpenID Connect provider.
+// The metadata of the provider is discovered on first use, so the EHR starts when the provider isn't available.
+type oidcProvider struct {
+	config   OIDCConfig
+	client   *http.Client
+	mux      sync.Mutex
+	metadata *oidcMetadata
+}
+
+// oidcLogin is the result of logging in at the provider.
+type oidcLogin struct {
+	SessionID    string
+	CustomerID   int
+	RedirectPath string
+}
+
+// EnableOIDC allows users to log in with the given OpenID Connect provider.
+func (auth *Auth) EnableOIDC(config OIDCConfig) {
+	auth.oidc = &oidcProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// OIDCEnabled returns true if users can log in with an OpenID Connect provider.
+func (auth *Auth) OIDCEnabled() bool {
+	return auth.oidc != nil
+}
+
+// OIDCAuthorizationURL returns the URL at the provider where the user logs in. After logging in, the user is redirected
+// to the callback endpoint and eventually to redirectPath in the EHR.
+// The state parameter is a short-lived JWT signed by the EHR, so no login state has to be kept on the server.
+// The returned nonce must be stored in the browser of the user (OIDCNonceCookie), since AuthenticateOIDC only accepts
+// the state together with it.
+func (auth *Auth) OIDCAuthorizationURL(ctx context.Context, redirectPath string) (string, string, error) {
+	if auth.oidc == nil {
+		return "", "", errors.New("OpenID Connect login is not enabled")
+	}
+	metadata, err := auth.oidc.getMetadata(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonceBytes := make([]byte, 32)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return "", "", err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+
+	t := openid.New()
+	t.Set(jwt.AudienceKey, OIDCStateAudience)
+	t.Set(jwt.IssuedAtKey, time.Now())
+	t.Set(jwt.ExpirationKey, time.Now().Add(MaxOIDCLoginDuration))
+	t.Set(Nonce, nonce)
+	t.Set(Redirect, redirectPath)
+	state, err := auth.sign(t)
+	if err != nil {
+		return "", "", err
+	}
+
+	authorizationURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := authorizationURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", auth.oidc.config.ClientID)
+	query.Set("redirect_uri", auth.oidc.config.RedirectURL)
+	query.Set("scope", strings.Join(auth.oidc.config.Scopes, " "))
+	query.Set("state", string(state))
+	query.Set("nonce", nonce)
+	authorizationURL.RawQuery = query.Encode()
+
+	return authorizationURL.String(), nonce, nil
+}
+
+// AuthenticateOIDC exchanges the authorization code for an ID token and starts a session for the user it identifies.
+// The customer and roles of the user are taken from the claims of the ID token.
+// The browserNonce is the nonce stored in the browser that started the login, it must match the nonce of the state,
+// so an attacker can't log the user in with the attacker's account.
+func (auth *Auth) AuthenticateOIDC(ctx context.Context, code, state, browserNonce string) (*oidcLogin, error) {
+	if auth.oidc == nil {
+		return nil, errors.New("OpenID Connect login is not enabled")
+	}
+	stateToken, err := auth.parse([]byte(state), jwt.WithAudience(OIDCStateAudience))
+	if err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	nonce, _ := stateToken.Get(Nonce)
+	redirectPath, _ := stateToken.Get(Redirect)
+	if browserNonce == "" || subtle.ConstantTimeCompare([]byte(fmt.Sprintf("%v", nonce)), []byte(browserNonce)) != 1 {
+		return nil, errors.New("state wasn't issued to this browser")
+	}
+
+	idToken, err := auth.oidc.exchangeCode(ctx, code, fmt.Sprintf("%v", nonce))
+	if err != nil {
+		return nil, err
+	}
+
+	config := auth.oidc.config
+	rawCustomerID, _ := claimValue(idToken, config.CustomerClaim)
+	customerID, ok := parseCustomerID(rawCustomerID)
+	if !ok {
+		return nil, fmt.Errorf("ID token doesn't contain a customer ID (claim=%s)", config.CustomerClaim)
+	}
+	if customer, err := auth.customers.FindByID(ctx, customerID); err != nil || customer == nil || !customer.Active {
+		return nil, fmt.Errorf("unknown customer in ID token (customer=%d)", customerID)
+	}
+
+	username := idToken.Subject()
+	if value, ok := claimValue(idToken, config.UsernameClaim); ok {
+		if s, ok := value.(string); ok && s != "" {
+			username = s
+		}
+	}
+	rawRoles, _ := claimValue(idToken, config.RolesClaim)
+
+	sessionID, err := auth.createSession(ctx, Session{
+		Credential: createOIDCVP(idToken),
+		CustomerID: customerID,
+		Username:   username,
+		Roles:      mapOIDCRoles(rawRoles, config.RoleMapping),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &oidcLogin{
+		SessionID:    sessionID,
+		CustomerID:   customerID,
+		RedirectPath: fmt.Sprintf("%v", redirectPath),
+	}, nil
+}
+
+func createOIDCVP(idToken jwt.Token) auth.VerifiablePresentation {
+	return auth.VerifiablePresentation{
+		Proof: map[string]interface{}{
+			"identity": fmt.Sprintf("%s/%s", idToken.Issuer(), idToken.Subject()),
+		},
+	}
+}
+
+func (p *oidcProvider) getMetadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover OpenID Connect provider: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to discover OpenID Connect provider (status=%d)", response.StatusCode)
+	}
+
+	metadata := oidcMetadata{}
+	if err := json.NewDecoder(response.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("invalid OpenID Connect provider metadata: %w", err)
+	}
+	if metadata.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("OpenID Connect provider metadata is of another issuer (issuer=%s)", metadata.Issuer)
+	}
+
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// exchangeCode exchanges the authorization code at the token endpoint and returns the verified ID token.
+func (p *oidcProvider) exchangeCode(ctx context.Context, code string, nonce string) (jwt.Token, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to exchange authorization code: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to exchange authorization code (status=%d)", response.StatusCode)
+	}
+	tokenResponse := oidcTokenResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, errors.New("token response doesn't contain an ID token")
+	}
+
+	// the keys are fetched for every login, so rotated keys of the provider are picked up
+	keys, err := jwk.Fetch(ctx, metadata.JWKSURI, jwk.WithHTTPClient(p.client))
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch keys of OpenID Connect provider: %w", err)
+	}
+	idToken, err := jwt.Parse([]byte(tokenResponse.IDToken),
+		jwt.WithKeySet(keys), jwt.UseDefaultKey(true), jwt.InferAlgorithmFromKey(true),
+		jwt.WithValidate(true), jwt.WithIssuer(p.config.Issuer), jwt.WithAudience(p.config.ClientID),
+		jwt.WithClaimValue(Nonce, nonce))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	return idToken, nil
+}
+
+// claimValue returns the value of the claim, where the path of nested claims is separated by dots.
+func claimValue(token jwt.Token, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	value, ok := token.Get(parts[0])
+	for _, part := range parts[1:] {
+		if !ok {
+			break
+		}
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return nil, false
+		}
+		value, ok = object[part]
+	}
+	return value, ok
+}
+
+// mapOIDCRoles maps the roles of the provider to roles of the EHR, roles that can't be mapped are ignored.
+// The vendor role can't be obtained through the provider, since it applies to all customers.
+func mapOIDCRoles(rawRoles interface{}, mapping map[string]types.UserRole) []types.UserRole {
+	var values []string
+	switch roles := rawRoles.(type) {
+	case string:
+		values = strings.Fields(roles)
+	case []interface{}:
+		for _, role := range roles {
+			if s, ok := role.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	result := []types.UserRole{}
+	for _, value := range values {
+		role := types.UserRole(value)
+		if len(mapping) > 0 {
+			var ok bool
+			if role, ok = mapping[value]; !ok {
+				continue
+			}
+		}
+		if users.HasRole(users.CustomerRoles, role) && !users.HasRole(result, role) {
+			result = append(result, role)
+		}
+	}
+	return result
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/lestrrat-go/jwx/jwt/openid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+)
+
+// mockOIDCProvider is a minimal OpenID Connect provider that issues an ID token with the given claims for every code.
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+	claims map[string]interface{}
+	// nonce is taken from the authorization request, like a real provider would
+	nonce string
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	provider := &mockOIDCProvider{key: key, claims: map[string]interface{}{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(writer http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(writer).Encode(oidcMetadata{
+			Issuer:                provider.server.URL,
+			AuthorizationEndpoint: provider.server.URL + "/authorize",
+			TokenEndpoint:         provider.server.URL + "/token",
+			JWKSURI:               provider.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(writer http.ResponseWriter, _ *http.Request) {
+		publicKey, _ := jwk.New(key.PublicKey)
+		_ = publicKey.Set(jwk.KeyIDKey, "test")
+		set := jwk.NewSet()
+		set.Add(publicKey)
+		_ = json.NewEncoder(writer).Encode(set)
+	})
+	mux.HandleFunc("/token", func(writer http.ResponseWriter, request *http.Request) {
+		if clientID, secret, _ := request.BasicAuth(); clientID != "ehr" || secret != "secret" || request.FormValue("code") != "code" {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token := openid.New()
+		token.Set(jwt.IssuerKey, provider.server.URL)
+		token.Set(jwt.SubjectKey, "user-1")
+		token.Set(jwt.AudienceKey, "ehr")
+		token.Set(jwt.ExpirationKey, time.Now().Add(time.Minute))
+		token.Set(Nonce, provider.nonce)
+		for name, value := range provider.claims {
+			token.Set(name, value)
+		}
+		signingKey, _ := jwk.New(key)
+		_ = signingKey.Set(jwk.KeyIDKey, "test")
+		signed, _ := jwt.Sign(token, jwa.ES256, signingKey)
+		_ = json.NewEncoder(writer).Encode(map[string]string{"id_token": string(signed)})
+	})
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func TestAuth_OIDC(t *testing.T) {
+	newAuth := func(provider *mockOIDCProvider) *Auth {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		db.SetMaxOpenConns(1)
+		sessionKeys, _ := NewKeyRing(filepath.Join(t.TempDir(), "session-keys.json"))
+		auth := NewAuth(sessionKeys, newMemoryCustomerRepository(types.Customer{Id: 1, Name: "Care Home"}), nil, NewSQLiteSessionStore(db))
+		auth.EnableOIDC(OIDCConfig{
+			Issuer:        provider.server.URL,
+			ClientID:      "ehr",
+			ClientSecret:  "secret",
+			RedirectURL:   "http://localhost:1304/web/auth/oidc/callback",
+			Scopes:        []string{"openid"},
+			CustomerClaim: "customer_id",
+			UsernameClaim: "preferred_username",
+			RolesClaim:    "realm_access.roles",
+			RoleMapping:   map[string]types.UserRole{"care-planner": types.UserRolePlanner},
+		})
+		return auth
+	}
+	// startLogin starts the login like the user's browser would and returns the state from the authorization URL
+	// and the nonce stored in the browser
+	startLogin := func(t *testing.T, auth *Auth, provider *mockOIDCProvider) (string, string) {
+		authorizationURL, nonce, err := auth.OIDCAuthorizationURL(context.Background(), "/ehr/patients")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		parsed, _ := url.Parse(authorizationURL)
+		assert.Equal(t, provider.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+		assert.Equal(t, "ehr", parsed.Query().Get("client_id"))
+		provider.nonce = parsed.Query().Get("nonce")
+		assert.Equal(t, provider.nonce, nonce)
+		return parsed.Query().Get("state"), nonce
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		provider.claims["customer_id"] = "1"
+		provider.claims["preferred_username"] = "alice"
+		provider.claims["realm_access"] = map[string]interface{}{"roles": []interface{}{"care-planner", "offline_access"}}
+		auth := newAuth(provider)
+		state, nonce := startLogin(t, auth, provider)
+
+		login, err := auth.AuthenticateOIDC(context.Background(), "code", state, nonce)
+
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 1, login.CustomerID)
+		assert.Equal(t, "/ehr/patients", login.RedirectPath)
+		session, _ := auth.sessions.Get(context.Background(), login.SessionID)
+		if assert.NotNil(t, session) {
+			assert.Equal(t, "alice", session.Username)
+			assert.Equal(t, []types.UserRole{types.UserRolePlanner}, session.Roles)
+			assert.False(t, session.UserContext)
+		}
+	})
+	t.Run("error - invalid state", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		provider.claims["customer_id"] = 1
+		auth := newAuth(provider)
+		_, nonce := startLogin(t, auth, provider)
+
+		_, err := auth.AuthenticateOIDC(context.Background(), "code", "forged", nonce)
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid state")
+		}
+	})
+	t.Run("error - state of another browser", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		provider.claims["customer_id"] = 1
+		auth := newAuth(provider)
+		// the attacker starts a login and lures the user to the callback with the attacker's code and state
+		state, _ := startLogin(t, auth, provider)
+		_, userNonce := startLogin(t, auth, provider)
+
+		_, err := auth.AuthenticateOIDC(context.Background(), "code", state, userNonce)
+		assert.EqualError(t, err, "state wasn't issued to this browser")
+		_, err = auth.AuthenticateOIDC(context.Background(), "code", state, "")
+		assert.EqualError(t, err, "state wasn't issued to this browser")
+	})
+	t.Run("error - nonce mismatch", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		provider.claims["customer_id"] = 1
+		auth := newAuth(provider)
+		state, nonce := startLogin(t, auth, provider)
+		provider.nonce = "replayed"
+
+		_, err := auth.AuthenticateOIDC(context.Background(), "code", state, nonce)
+
+		assert.Error(t, err)
+	})
+	t.Run("error - unknown customer", func(t *testing.T) {
+		provider := newMockOIDCProvider(t)
+		provider.claims["customer_id"] = 2
+		auth := newAuth(provider)
+		state, nonce := startLogin(t, auth, provider)
+
+		_, err := auth.AuthenticateOIDC(context.Background(), "code", state, nonce)
+
+		assert.EqualError(t, err, "unknown customer in ID token (customer=2)")
+	})
+}
+
+func TestWrapper_OIDCLogin(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.claims["customer_id"] = "1"
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	db.SetMaxOpenConns(1)
+	sessionKeys, _ := NewKeyRing(filepath.Join(t.TempDir(), "session-keys.json"))
+	customers := newMemoryCustomerRepository(types.Customer{Id: 1, Name: "Care Home"})
+	w := Wrapper{CustomerRepository: customers, APIAuth: NewAuth(sessionKeys, customers, nil, NewSQLiteSessionStore(db))}
+	w.APIAuth.EnableOIDC(OIDCConfig{
+		Issuer:        provider.server.URL,
+		ClientID:      "ehr",
+		ClientSecret:  "secret",
+		RedirectURL:   "http://localhost:1304/web/auth/oidc/callback",
+		CustomerClaim: "customer_id",
+	})
+	// request sends the cookies like the browser would and returns the cookies set by the response
+	request := func(cookies []*http.Cookie, handler func(ctx echo.Context) error) (*httptest.ResponseRecorder, map[string]*http.Cookie) {
+		httpRequest := httptest.NewRequest(http.MethodGet, "/", nil)
+		for _, cookie := range cookies {
+			httpRequest.AddCookie(cookie)
+		}
+		recorder := httptest.NewRecorder()
+		assert.NoError(t, handler(echo.New().NewContext(httpRequest, recorder)))
+		set := map[string]*http.Cookie{}
+		for _, cookie := range recorder.Result().Cookies() {
+			set[cookie.Name] = cookie
+		}
+		return recorder, set
+	}
+	startLogin := func() (string, *http.Cookie) {
+		recorder, cookies := request(nil, func(ctx echo.Context) error {
+			return w.StartOIDCLogin(ctx, StartOIDCLoginParams{})
+		})
+		nonceCookie := cookies[OIDCNonceCookie]
+		if assert.NotNil(t, nonceCookie) {
+			assert.True(t, nonceCookie.HttpOnly)
+			assert.Equal(t, http.SameSiteLaxMode, nonceCookie.SameSite)
+			assert.Equal(t, "/web/auth/oidc", nonceCookie.Path)
+		}
+		authorizationURL, _ := url.Parse(recorder.Header().Get("Location"))
+		provider.nonce = authorizationURL.Query().Get("nonce")
+		return authorizationURL.Query().Get("state"), nonceCookie
+	}
+	callback := func(state string, cookies ...*http.Cookie) (string, map[string]*http.Cookie) {
+		code := "code"
+		recorder, set := request(cookies, func(ctx echo.Context) error {
+			return w.HandleOIDCCallback(ctx, HandleOIDCCallbackParams{Code: &code, State: &state})
+		})
+		return recorder.Header().Get("Location"), set
+	}
+
+	t.Run("ok - session token is only passed to the browser that logged in", func(t *testing.T) {
+		state, nonceCookie := startLogin()
+
+		location, cookies := callback(state, nonceCookie)
+
+		assert.Equal(t, "/#/auth/oidc?", location, "token isn't passed in the URL")
+		assert.Equal(t, -1, cookies[OIDCNonceCookie].MaxAge, "nonce is removed")
+		sessionCookie := cookies[OIDCSessionCookie]
+		if !assert.NotNil(t, sessionCookie) {
+			return
+		}
+		assert.True(t, sessionCookie.HttpOnly)
+		recorder, cookies := request([]*http.Cookie{sessionCookie}, w.ExchangeOIDCSession)
+		assert.Contains(t, recorder.Body.String(), sessionCookie.Value)
+		assert.Equal(t, -1, cookies[OIDCSessionCookie].MaxAge, "token can only be retrieved once")
+	})
+	t.Run("error - callback in a browser that didn't start the login", func(t *testing.T) {
+		state, _ := startLogin()
+
+		location, cookies := callback(state)
+
+		assert.Equal(t, "/#/auth/oidc?error=authentication+failed", location)
+		assert.Nil(t, cookies[OIDCSessionCookie])
+	})
+	t.Run("error - exchange without login", func(t *testing.T) {
+		err := w.ExchangeOIDCSession(echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder()))
+
+		assert.Equal(t, http.StatusUnauthorized, err.(*echo.HTTPError).Code)
+	})
+}
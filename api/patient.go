@@ -93,6 +93,37 @@ func (w Wrapper) GetPatient(ctx echo.Context, patientID string) error {
 
 }
 
+func (w Wrapper) CreateLaunchToken(ctx echo.Context, patientID string) error {
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	sessionID, ok := ctx.Get(SessionID).(string)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "could not get sessionID from token")
+	}
+	patient, err := w.PatientRepository.FindByID(ctx.Request().Context(), cid, patientID)
+	if err != nil {
+		return err
+	}
+	if patient == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	token, err := w.APIAuth.CreateLaunchToken(cid, sessionID, patientID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	return ctx.JSON(http.StatusOK, types.LaunchToken{
+		AccessToken: string(token),
+		TokenType:   "Bearer",
+		ExpiresIn:   int(MaxLaunchTokenAge.Seconds()),
+		Scope:       LaunchScope,
+		Patient:     patientID,
+	})
+}
+
 func (w Wrapper) getCustomerID(ctx echo.Context) (int, error) {
 	customer := w.getCustomer(ctx)
 	if customer == nil {
@@ -106,20 +137,33 @@ func (w Wrapper) getCustomer(ctx echo.Context) *types.Customer {
 	if !ok {
 		return nil
 	}
-	customer, _ := w.CustomerRepository.FindByID(cid)
-	if customer.Id != cid {
+	customer, _ := w.CustomerRepository.FindByID(ctx.Request().Context(), cid)
+	if customer == nil || customer.Id != cid {
 		return nil
 	}
 	return customer
 }
 
+// getElevatedSession returns the caller's session if it contains a user identity (e.g. from IRMA), which is required
+// for remote calls on behalf of the user. Sessions of other users are never used, even if they're of the same customer.
+func (w Wrapper) getElevatedSession(ctx echo.Context) (*Session, error) {
+	session, ok := ctx.Get(sessionKey).(Session)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "no session")
+	}
+	if !session.UserContext {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "session is not elevated, log in with a means that identifies the user (e.g. IRMA)")
+	}
+	return &session, nil
+}
+
 func (w Wrapper) getCustomerDID(ctx echo.Context) *string {
 	cid, ok := ctx.Get(CustomerID).(int)
 	if !ok {
 		return nil
 	}
-	customer, _ := w.CustomerRepository.FindByID(cid)
-	if customer.Id != cid {
+	customer, _ := w.CustomerRepository.FindByID(ctx.Request().Context(), cid)
+	if customer == nil || customer.Id != cid {
 		return nil
 	}
 
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapper_getElevatedSession(t *testing.T) {
+	newContext := func() echo.Context {
+		return echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/web/private", nil), httptest.NewRecorder())
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		ctx := newContext()
+		ctx.Set(sessionKey, Session{CustomerID: 1, UserContext: true})
+
+		session, err := Wrapper{}.getElevatedSession(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, session.CustomerID)
+	})
+	t.Run("not elevated", func(t *testing.T) {
+		ctx := newContext()
+		ctx.Set(sessionKey, Session{CustomerID: 1})
+
+		_, err := Wrapper{}.getElevatedSession(ctx)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+		}
+	})
+	t.Run("no session", func(t *testing.T) {
+		_, err := Wrapper{}.getElevatedSession(newContext())
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusUnauthorized, err.(*echo.HTTPError).Code)
+		}
+	})
+}
@@ -5,32 +5,94 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/organizations"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
 )
 
 type SearchOrganizationsParams = types.SearchOrganizationsParams
 
+// boltServiceTypes maps the Bolts to the type of the service of the care organizations that receive its data
+var boltServiceTypes = map[types.SearchOrganizationsParamsBolt]string{
+	"eOverdracht": transfer.ReceiverServiceName,
+	"zorginzage":  zorginzage.ServiceName,
+}
+
 func (w Wrapper) SearchOrganizations(ctx echo.Context, params SearchOrganizationsParams) error {
 	customer := w.getCustomer(ctx)
 	if customer == nil {
 		return errors.New("customer not found")
 	}
 
-	organizations, err := w.OrganizationRegistry.Search(ctx.Request().Context(), params.Query, params.DidServiceType)
-	if err != nil {
+	searchParams := organizations.SearchParams{
+		Query:          params.Query,
+		DIDServiceType: params.DidServiceType,
+		Zipcode:        params.Zipcode,
+		MaxDistance:    params.MaxDistance,
+		FavouritesOnly: params.FavouritesOnly != nil && *params.FavouritesOnly,
+	}
+	if params.Bolt != nil {
+		serviceType, ok := boltServiceTypes[*params.Bolt]
+		if !ok {
+			return echo.NewHTTPError(http.StatusBadRequest, "unknown bolt")
+		}
+		if params.DidServiceType != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "bolt can't be combined with didServiceType")
+		}
+		searchParams.DIDServiceType = &serviceType
+	}
+
+	results, err := w.OrganizationSearch.Search(ctx.Request().Context(), *customer, searchParams)
+	if errors.Is(err, organizations.ErrUnknownZipcode) || errors.Is(err, organizations.ErrZipcodeRequired) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err)
 	}
 
-	results := []types.Organization{}
+	return ctx.JSON(http.StatusOK, results)
+}
 
-	for _, organization := range organizations {
-		// Hide our own organization
-		if organization.Did == *customer.Did {
-			continue
-		}
+func (w Wrapper) GetFavourites(ctx echo.Context) error {
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	favourites, err := w.FavouriteRepository.All(ctx.Request().Context(), cid)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, favourites)
+}
 
-		results = append(results, organization)
+// AddFavourite adds the care organization as favourite partner. Its name and city are taken from its (trusted)
+// organization credential.
+func (w Wrapper) AddFavourite(ctx echo.Context, did string) error {
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	organization, err := w.OrganizationRegistry.Get(ctx.Request().Context(), did)
+	if errors.Is(err, registry.ErrOrganizationNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return err
 	}
+	if err := w.FavouriteRepository.Add(ctx.Request().Context(), cid, *organization); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, organization)
+}
 
-	return ctx.JSON(http.StatusOK, results)
+func (w Wrapper) RemoveFavourite(ctx echo.Context, did string) error {
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := w.FavouriteRepository.Remove(ctx.Request().Context(), cid, did); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
 }
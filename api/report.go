@@ -1,10 +1,12 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/sirupsen/logrus"
 )
 
 // GetReportsParams defines parameters for GetReports.
@@ -55,5 +57,14 @@ func (w Wrapper) CreateReport(ctx echo.Context, patientID string) error {
 		return err
 	}
 
+	if customerDID := w.getCustomerDID(ctx); reportToCreate.EpisodeID != nil && customerDID != nil {
+		// notify in the background: creating the report shouldn't fail or wait on collaborators being unavailable
+		go func(customerDID, episodeID string) {
+			if err := w.EpisodeService.NotifyCollaborators(context.Background(), customerDID, episodeID, ""); err != nil {
+				logrus.Errorf("Unable to notify collaborators of new report (episode=%s): %s", episodeID, err)
+			}
+		}(*customerDID, string(*reportToCreate.EpisodeID))
+	}
+
 	return ctx.NoContent(http.StatusOK)
 }
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+)
+
+// roleRules maps the routes (method and path as registered in echo) to the roles that may call them.
+// Routes that aren't listed may be called by every logged in user.
+var roleRules = map[string][]types.UserRole{
+	// transfers are planned and confirmed by planners
+	"POST /web/private/transfer":                                       {types.UserRolePlanner},
+	"PUT /web/private/transfer/:transferID":                            {types.UserRolePlanner},
+	"DELETE /web/private/transfer/:transferID":                         {types.UserRolePlanner},
+	"PUT /web/private/transfer/:transferID/assign":                     {types.UserRolePlanner},
+	"POST /web/private/transfer/:transferID/negotiation":               {types.UserRolePlanner},
+	"PUT /web/private/transfer/:transferID/negotiation/:negotiationID": {types.UserRolePlanner},
+	"POST /web/private/transfer-request/:requestorDID/:fhirTaskID":     {types.UserRolePlanner},
+	"POST /web/private/episode/:episodeID/collaboration":               {types.UserRolePlanner},
+	// as are the favourite partners they transfer patients to
+	"PUT /web/private/network/favourites/:did":    {types.UserRolePlanner},
+	"DELETE /web/private/network/favourites/:did": {types.UserRolePlanner},
+	// care is recorded by nurses
+	"POST /web/private/patients":           {types.UserRoleNurse},
+	"PUT /web/private/patient/:patientID":  {types.UserRoleNurse},
+	"POST /web/private/reports/:patientID": {types.UserRoleNurse},
+	"POST /web/private/dossier":            {types.UserRoleNurse},
+	"POST /web/private/episode":            {types.UserRoleNurse},
+	// users are managed by admins
+	"GET /web/private/admin/user":            {types.UserRoleAdmin},
+	"POST /web/private/admin/user":           {types.UserRoleAdmin},
+	"PUT /web/private/admin/user/:userID":    {types.UserRoleAdmin},
+	"DELETE /web/private/admin/user/:userID": {types.UserRoleAdmin},
+	// customers are managed and onboarded by the vendor, since that applies to all customers
+	"GET /web/private/admin/customer":                      {types.UserRoleVendor},
+	"POST /web/private/admin/customer":                     {types.UserRoleVendor},
+	"PUT /web/private/admin/customer/:customerID":          {types.UserRoleVendor},
+	"DELETE /web/private/admin/customer/:customerID":       {types.UserRoleVendor},
+	"POST /web/private/admin/customer/:customerID/onboard": {types.UserRoleVendor},
+	// as are the caches of the EHR, which are shared by all customers
+	"GET /web/private/admin/cache": {types.UserRoleVendor},
+	// as is the trust of the Nuts node, which applies to all customers
+	"GET /web/private/admin/trust/:credentialType":            {types.UserRoleVendor},
+	"PUT /web/private/admin/trust/:credentialType/:issuer":    {types.UserRoleVendor},
+	"DELETE /web/private/admin/trust/:credentialType/:issuer": {types.UserRoleVendor},
+	// the authorization credentials the customer issued are managed by its admins
+	"GET /web/private/customer/credentials":                  {types.UserRoleAdmin},
+	"DELETE /web/private/customer/credentials/:credentialID": {types.UserRoleAdmin},
+	// as is checking its registration on the Nuts network, which calls the endpoints of the customer
+	"GET /web/private/customer/diagnostics": {types.UserRoleAdmin},
+}
+
+// RoleHandler checks whether the user of the session has a role that may call the route. It must be registered after the JWTHandler.
+func RoleHandler(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		roles, ok := roleRules[ctx.Request().Method+" "+ctx.Path()]
+		if !ok {
+			return next(ctx)
+		}
+		session, ok := ctx.Get(sessionKey).(Session)
+		if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "no session")
+		}
+		if !session.HasRole(roles...) {
+			return echo.NewHTTPError(http.StatusForbidden, "user doesn't have the required role")
+		}
+		return next(ctx)
+	}
+}
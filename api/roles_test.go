@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+)
+
+func TestRoleHandler(t *testing.T) {
+	handler := RoleHandler(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+	newContext := func(method, path string) echo.Context {
+		ctx := echo.New().NewContext(httptest.NewRequest(method, "/", nil), httptest.NewRecorder())
+		ctx.SetPath(path)
+		return ctx
+	}
+
+	t.Run("ok - user has role", func(t *testing.T) {
+		ctx := newContext(http.MethodPost, "/web/private/episode/:episodeID/collaboration")
+		ctx.Set(sessionKey, Session{Roles: []types.UserRole{types.UserRolePlanner}})
+
+		assert.NoError(t, handler(ctx))
+	})
+	t.Run("ok - route without rules", func(t *testing.T) {
+		ctx := newContext(http.MethodGet, "/web/private/patients")
+
+		assert.NoError(t, handler(ctx))
+	})
+	t.Run("error - user doesn't have role", func(t *testing.T) {
+		ctx := newContext(http.MethodPut, "/web/private/transfer/:transferID/assign")
+		ctx.Set(sessionKey, Session{Roles: []types.UserRole{types.UserRoleNurse}})
+
+		err := handler(ctx)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+		}
+	})
+	t.Run("error - admin can't manage customers", func(t *testing.T) {
+		ctx := newContext(http.MethodPut, "/web/private/admin/customer/:customerID")
+		ctx.Set(sessionKey, Session{Roles: []types.UserRole{types.UserRoleNurse, types.UserRolePlanner, types.UserRoleAdmin}})
+
+		err := handler(ctx)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+		}
+
+		ctx.Set(sessionKey, Session{Roles: []types.UserRole{types.UserRoleVendor}})
+		assert.NoError(t, handler(ctx))
+	})
+	t.Run("error - admin can't manage the trust of the Nuts node", func(t *testing.T) {
+		ctx := newContext(http.MethodPut, "/web/private/admin/trust/:credentialType/:issuer")
+		ctx.Set(sessionKey, Session{Roles: []types.UserRole{types.UserRoleAdmin}})
+
+		err := handler(ctx)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+		}
+	})
+	t.Run("error - only admins run the diagnostics", func(t *testing.T) {
+		ctx := newContext(http.MethodGet, "/web/private/customer/diagnostics")
+		ctx.Set(sessionKey, Session{Roles: []types.UserRole{types.UserRoleNurse, types.UserRolePlanner}})
+
+		err := handler(ctx)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+		}
+
+		ctx.Set(sessionKey, Session{Roles: []types.UserRole{types.UserRoleAdmin}})
+		assert.NoError(t, handler(ctx))
+	})
+	t.Run("error - no session", func(t *testing.T) {
+		ctx := newContext(http.MethodGet, "/web/private/admin/user")
+
+		err := handler(ctx)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusUnauthorized, err.(*echo.HTTPError).Code)
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+// SessionStore stores the sessions of logged in users. Sessions expire MaxSessionAge after they've been started.
+type SessionStore interface {
+	// Create stores the session under a new identifier, which is returned.
+	Create(ctx context.Context, session Session) (string, error)
+	// Get returns the session with the given identifier, or nil if it doesn't exist or has expired.
+	Get(ctx context.Context, id string) (*Session, error)
+	// Delete removes the session, e.g. when the user logs out.
+	Delete(ctx context.Context, id string) error
+	// DeleteByCustomer removes all sessions of the customer, e.g. when it's deactivated.
+	DeleteByCustomer(ctx context.Context, customerID int) error
+	// DeleteByUser removes all sessions of the user of the customer, e.g. when its roles changed or it's deleted.
+	DeleteByUser(ctx context.Context, customerID int, username string) error
+	// DeleteExpired removes all sessions which have expired and returns the number of removed sessions.
+	DeleteExpired() (int64, error)
+}
+
+const sessionSchema = `
+	CREATE TABLE IF NOT EXISTS session (
+		id char(128) NOT NULL,
+		customer_id integer(11) NOT NULL,
+		credential text NOT NULL,
+		user_context boolean NOT NULL,
+		username varchar(100) NOT NULL DEFAULT '',
+		roles varchar(100) NOT NULL DEFAULT '',
+		start_time datetime NOT NULL,
+		expires_at datetime NOT NULL,
+		PRIMARY KEY (id)
+	);
+`
+
+type sqlSession struct {
+	ID          string    `db:"id"`
+	CustomerID  int       `db:"customer_id"`
+	Credential  string    `db:"credential"`
+	UserContext bool      `db:"user_context"`
+	Username    string    `db:"username"`
+	Roles       string    `db:"roles"`
+	StartTime   time.Time `db:"start_time"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+func (dbSession sqlSession) MarshalToSession() (*Session, error) {
+	session := Session{
+		CustomerID:  dbSession.CustomerID,
+		StartTime:   dbSession.StartTime,
+		UserContext: dbSession.UserContext,
+		Username:    dbSession.Username,
+		Roles:       []types.UserRole{},
+	}
+	for _, role := range strings.Split(dbSession.Roles, ",") {
+		if role != "" {
+			session.Roles = append(session.Roles, types.UserRole(role))
+		}
+	}
+	if err := json.Unmarshal([]byte(dbSession.Credential), &session.Credential); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SQLiteSessionStore is a SessionStore that survives restarts of the application.
+// It takes part in the request's transaction if there is one, otherwise it would wait for the single connection held by
+// that transaction. Sessions are also checked before the transaction is started, e.g. by the JWTHandler.
+type SQLiteSessionStore struct {
+	db  *sqlx.DB
+	now func() time.Time
+}
+
+func NewSQLiteSessionStore(db *sqlx.DB) *SQLiteSessionStore {
+	if db == nil {
+		panic("missing db for SessionStore")
+	}
+	tx, _ := db.Beginx()
+	tx.MustExec(sessionSchema)
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	return &SQLiteSessionStore{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+func (s SQLiteSessionStore) Create(ctx context.Context, session Session) (string, error) {
+	credential, err := json.Marshal(session.Credential)
+	if err != nil {
+		return "", err
+	}
+
+	roles := make([]string, len(session.Roles))
+	for i, role := range session.Roles {
+		roles[i] = string(role)
+	}
+
+	idBytes := make([]byte, 64)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+
+	dbSession := sqlSession{
+		ID:          hex.EncodeToString(idBytes),
+		CustomerID:  session.CustomerID,
+		Credential:  string(credential),
+		UserContext: session.UserContext,
+		Username:    session.Username,
+		Roles:       strings.Join(roles, ","),
+		StartTime:   session.StartTime.UTC(),
+		ExpiresAt:   session.StartTime.Add(MaxSessionAge).UTC(),
+	}
+
+	const query = `INSERT INTO session
+		(id, customer_id, credential, user_context, username, roles, start_time, expires_at)
+		VALUES (:id, :customer_id, :credential, :user_context, :username, :roles, :start_time, :expires_at)`
+
+	db, err := s.connection(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, err := sqlx.NamedExecContext(ctx, db, query, dbSession); err != nil {
+		return "", err
+	}
+
+	return dbSession.ID, nil
+}
+
+func (s SQLiteSessionStore) Get(ctx context.Context, id string) (*Session, error) {
+	const query = `SELECT * FROM session WHERE id = ? AND expires_at > ?`
+
+	db, err := s.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dbSession := sqlSession{}
+	err = sqlx.GetContext(ctx, db, &dbSession, query, id, s.now().UTC())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return dbSession.MarshalToSession()
+}
+
+func (s SQLiteSessionStore) Delete(ctx context.Context, id string) error {
+	db, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `DELETE FROM session WHERE id = ?`, id)
+	return err
+}
+
+func (s SQLiteSessionStore) DeleteByCustomer(ctx context.Context, customerID int) error {
+	db, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `DELETE FROM session WHERE customer_id = ?`, customerID)
+	return err
+}
+
+func (s SQLiteSessionStore) DeleteByUser(ctx context.Context, customerID int, username string) error {
+	db, err := s.connection(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, `DELETE FROM session WHERE customer_id = ? AND username = ?`, customerID, username)
+	return err
+}
+
+func (s SQLiteSessionStore) DeleteExpired() (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM session WHERE expires_at <= ?`, s.now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// connection returns the transaction of the request, or the database if the context doesn't have a transaction manager.
+func (s SQLiteSessionStore) connection(ctx context.Context) (sqlx.ExtContext, error) {
+	if _, err := sqlUtil.GetTransactionManager(ctx); err != nil {
+		return s.db, nil
+	}
+	return sqlUtil.GetTransaction(ctx)
+}
+
+// StartCleanup periodically removes the expired sessions from the store, until the returned function is called.
+func StartCleanup(store SessionStore, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				count, err := store.DeleteExpired()
+				if err != nil {
+					logrus.Errorf("Unable to remove expired sessions: %s", err)
+				} else if count > 0 {
+					logrus.Debugf("Removed %d expired session(s)", count)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
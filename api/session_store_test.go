@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+func TestSQLiteSessionStore(t *testing.T) {
+	newStore := func() *SQLiteSessionStore {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		// every connection to :memory: has its own database
+		db.SetMaxOpenConns(1)
+		return NewSQLiteSessionStore(db)
+	}
+	session := Session{
+		Credential:  auth.VerifiablePresentation{Proof: map[string]interface{}{"identity": "1demo"}},
+		CustomerID:  1,
+		StartTime:   time.Now(),
+		UserContext: true,
+		Username:    "admin",
+		Roles:       []types.UserRole{types.UserRoleNurse, types.UserRoleAdmin},
+	}
+
+	t.Run("create and get", func(t *testing.T) {
+		store := newStore()
+
+		id, err := store.Create(context.Background(), session)
+		if !assert.NoError(t, err) {
+			return
+		}
+		actual, err := store.Get(context.Background(), id)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, session.CustomerID, actual.CustomerID)
+			assert.Equal(t, session.Credential, actual.Credential)
+			assert.True(t, actual.UserContext)
+			assert.Equal(t, session.Username, actual.Username)
+			assert.Equal(t, session.Roles, actual.Roles)
+		}
+	})
+	t.Run("sessions are unique", func(t *testing.T) {
+		store := newStore()
+
+		id1, _ := store.Create(context.Background(), session)
+		id2, _ := store.Create(context.Background(), session)
+
+		assert.NotEqual(t, id1, id2)
+	})
+	t.Run("delete", func(t *testing.T) {
+		store := newStore()
+		id, _ := store.Create(context.Background(), session)
+
+		assert.NoError(t, store.Delete(context.Background(), id))
+
+		actual, err := store.Get(context.Background(), id)
+		assert.NoError(t, err)
+		assert.Nil(t, actual)
+	})
+	t.Run("expired sessions", func(t *testing.T) {
+		store := newStore()
+		id, _ := store.Create(context.Background(), session)
+
+		store.now = func() time.Time { return time.Now().Add(MaxSessionAge + time.Minute) }
+
+		actual, err := store.Get(context.Background(), id)
+		assert.NoError(t, err)
+		assert.Nil(t, actual)
+
+		count, err := store.DeleteExpired()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	nutsClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	nutsAuth "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// The states of a signing session, independent of the signing means.
+const (
+	SignSessionPending   = "pending"
+	SignSessionCompleted = "completed"
+	SignSessionCancelled = "cancelled"
+	SignSessionExpired   = "expired"
+	SignSessionFailed    = "failed"
+)
+
+// signSessionRetention is the time a finished signing session is kept, so its result can still be fetched.
+const signSessionRetention = 5 * time.Minute
+
+// ErrUnknownSignSession is returned when a signing session isn't tracked, e.g. because it was started by another customer.
+var ErrUnknownSignSession = errors.New("unknown signing session")
+
+// ErrTooManySignSessions is returned when the customer already has the maximum number of tracked signing sessions.
+var ErrTooManySignSessions = errors.New("too many signing sessions")
+
+// SignSessionStatus is the status of a signing session as sent to the login page.
+type SignSessionStatus struct {
+	// State is one of pending, completed, cancelled, expired or failed.
+	State string `json:"state"`
+	// Status is the status as reported by the signing means of the Nuts node, e.g. CONNECTED for IRMA.
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Done returns true if the status won't change anymore.
+func (s SignSessionStatus) Done() bool {
+	return s.State != SignSessionPending
+}
+
+type trackedSignSession struct {
+	customerID   int
+	means        SigningMeans
+	status       SignSessionStatus
+	presentation *nutsAuth.VerifiablePresentation
+	subscribers  map[chan SignSessionStatus]struct{}
+	stop         context.CancelFunc
+}
+
+// SignSessionTracker polls the status of signing sessions at the Nuts node in the background,
+// so requests of the login page don't block while the user signs.
+type SignSessionTracker struct {
+	client   nutsClient.Auth
+	interval time.Duration
+	maxAge   time.Duration
+	// maxPerCustomer bounds the signing sessions tracked per customer, since they're started before the user logged in
+	maxPerCustomer int
+	mux            sync.Mutex
+	sessions       map[string]*trackedSignSession
+}
+
+// NewSignSessionTracker creates a tracker that polls every interval, until the signing session is done or older than maxAge.
+// It tracks at most maxPerCustomer signing sessions per customer, including finished sessions that are still retained.
+func NewSignSessionTracker(client nutsClient.Auth, interval time.Duration, maxAge time.Duration, maxPerCustomer int) *SignSessionTracker {
+	return &SignSessionTracker{
+		client:         client,
+		interval:       interval,
+		maxAge:         maxAge,
+		maxPerCustomer: maxPerCustomer,
+		sessions:       map[string]*trackedSignSession{},
+	}
+}
+
+// Full returns true if the customer can't start another signing session, since its maximum is tracked already.
+func (t *SignSessionTracker) Full(customerID int) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.count(customerID) >= t.maxPerCustomer
+}
+
+// Track starts polling the signing session the customer started.
+// It returns ErrTooManySignSessions if the customer already has the maximum number of tracked signing sessions.
+func (t *SignSessionTracker) Track(customerID int, means SigningMeans, sessionToken string) error {
+	t.mux.Lock()
+	if t.count(customerID) >= t.maxPerCustomer {
+		t.mux.Unlock()
+		return ErrTooManySignSessions
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), t.maxAge)
+	t.sessions[sessionToken] = &trackedSignSession{
+		customerID:  customerID,
+		means:       means,
+		status:      SignSessionStatus{State: SignSessionPending},
+		subscribers: map[chan SignSessionStatus]struct{}{},
+		stop:        cancel,
+	}
+	t.mux.Unlock()
+
+	go t.poll(ctx, sessionToken)
+	return nil
+}
+
+// count returns the number of signing sessions tracked for the customer. The lock must be held by the caller.
+func (t *SignSessionTracker) count(customerID int) int {
+	result := 0
+	for _, session := range t.sessions {
+		if session.customerID == customerID {
+			result++
+		}
+	}
+	return result
+}
+
+func (t *SignSessionTracker) poll(ctx context.Context, sessionToken string) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				t.update(sessionToken, SignSessionStatus{State: SignSessionExpired, Error: "signing session took too long"}, nil)
+			}
+			return
+		case <-ticker.C:
+			if done := t.refresh(ctx, sessionToken); done {
+				return
+			}
+		}
+	}
+}
+
+// refresh fetches the status of the signing session from the Nuts node and returns true when it's done.
+func (t *SignSessionTracker) refresh(ctx context.Context, sessionToken string) bool {
+	t.mux.Lock()
+	session, ok := t.sessions[sessionToken]
+	done := ok && session.status.Done()
+	t.mux.Unlock()
+	if !ok || done {
+		return true
+	}
+
+	result, err := t.client.GetSignSessionResult(ctx, sessionToken)
+	if err != nil {
+		if ctx.Err() == nil {
+			logrus.Warnf("Unable to get status of signing session: %s", err)
+		}
+		return false
+	}
+
+	status := SignSessionStatus{State: signSessionState(session.means, result.Status), Status: result.Status}
+	if status.State == SignSessionCompleted && result.VerifiablePresentation == nil {
+		status = SignSessionStatus{State: SignSessionFailed, Status: result.Status, Error: "signing session completed without signature"}
+	}
+	return t.update(sessionToken, status, result.VerifiablePresentation)
+}
+
+// update stores the status and notifies the subscribers if it changed. It returns true when the signing session is done.
+func (t *SignSessionTracker) update(sessionToken string, status SignSessionStatus, presentation *nutsAuth.VerifiablePresentation) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	session, ok := t.sessions[sessionToken]
+	if !ok || session.status.Done() {
+		return true
+	}
+	if session.status == status {
+		return false
+	}
+
+	session.status = status
+	session.presentation = presentation
+	for subscriber := range session.subscribers {
+		sendLatest(subscriber, status)
+	}
+
+	if status.Done() {
+		session.stop()
+		time.AfterFunc(signSessionRetention, func() {
+			t.mux.Lock()
+			delete(t.sessions, sessionToken)
+			t.mux.Unlock()
+		})
+	}
+	return status.Done()
+}
+
+// Subscribe returns a channel that receives the current status of the signing session and every change after that.
+// The returned function must be called to stop receiving updates.
+func (t *SignSessionTracker) Subscribe(customerID int, sessionToken string) (<-chan SignSessionStatus, func(), error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	session, ok := t.sessions[sessionToken]
+	if !ok || session.customerID != customerID {
+		return nil, nil, ErrUnknownSignSession
+	}
+
+	updates := make(chan SignSessionStatus, 1)
+	updates <- session.status
+	session.subscribers[updates] = struct{}{}
+
+	return updates, func() {
+		t.mux.Lock()
+		delete(session.subscribers, updates)
+		t.mux.Unlock()
+	}, nil
+}
+
+// Result returns the status of the signing session and the signed contract when completed. If the session is still
+// pending, its status is refreshed first, so the result is available as soon as the Nuts node has it.
+func (t *SignSessionTracker) Result(ctx context.Context, customerID int, sessionToken string) (SignSessionStatus, *nutsAuth.VerifiablePresentation, error) {
+	t.mux.Lock()
+	session, ok := t.sessions[sessionToken]
+	t.mux.Unlock()
+	if !ok || session.customerID != customerID {
+		return SignSessionStatus{}, nil, ErrUnknownSignSession
+	}
+
+	t.refresh(ctx, sessionToken)
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return session.status, session.presentation, nil
+}
+
+// signSessionState maps the status of the signing means to the state of the session.
+func signSessionState(means SigningMeans, status string) string {
+	if status == means.CompletedStatus {
+		return SignSessionCompleted
+	}
+	switch strings.ToLower(status) {
+	case "cancelled", "canceled":
+		return SignSessionCancelled
+	case "timeout", "expired":
+		return SignSessionExpired
+	case "errored", "error", "failed":
+		return SignSessionFailed
+	}
+	return SignSessionPending
+}
+
+// sendLatest sends the status without blocking, replacing a status the subscriber hasn't received yet.
+func sendLatest(subscriber chan SignSessionStatus, status SignSessionStatus) {
+	select {
+	case subscriber <- status:
+	default:
+		select {
+		case <-subscriber:
+		default:
+		}
+		subscriber <- status
+	}
+}
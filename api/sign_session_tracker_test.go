@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignSessionTracker(t *testing.T) {
+	means := SigningMeans{CompletedStatus: "DONE"}
+
+	t.Run("subscribers receive updates until completed", func(t *testing.T) {
+		tracker := NewSignSessionTracker(&stubSigning{status: "DONE", polls: 2}, time.Millisecond, time.Minute, 10)
+		tracker.Track(1, means, "123")
+
+		updates, unsubscribe, err := tracker.Subscribe(1, "123")
+		assert.NoError(t, err)
+		defer unsubscribe()
+
+		last := SignSessionStatus{State: SignSessionPending}
+		timeout := time.After(5 * time.Second)
+		for !last.Done() {
+			select {
+			case last = <-updates:
+			case <-timeout:
+				t.Fatal("signing session didn't complete")
+			}
+		}
+		assert.Equal(t, SignSessionStatus{State: SignSessionCompleted, Status: "DONE"}, last)
+
+		status, presentation, err := tracker.Result(context.Background(), 1, "123")
+		assert.NoError(t, err)
+		assert.Equal(t, SignSessionCompleted, status.State)
+		assert.NotNil(t, presentation)
+	})
+	t.Run("session expires", func(t *testing.T) {
+		tracker := NewSignSessionTracker(&stubSigning{status: "DONE", polls: 1000}, time.Millisecond, 20*time.Millisecond, 10)
+		tracker.Track(1, means, "123")
+
+		updates, unsubscribe, _ := tracker.Subscribe(1, "123")
+		defer unsubscribe()
+
+		last := <-updates
+		for !last.Done() {
+			last = <-updates
+		}
+		assert.Equal(t, SignSessionExpired, last.State)
+	})
+	t.Run("unknown session", func(t *testing.T) {
+		tracker := NewSignSessionTracker(&stubSigning{}, time.Hour, time.Minute, 10)
+		tracker.Track(1, means, "123")
+
+		_, _, err := tracker.Subscribe(2, "123")
+		assert.ErrorIs(t, err, ErrUnknownSignSession)
+		_, _, err = tracker.Result(context.Background(), 1, "456")
+		assert.ErrorIs(t, err, ErrUnknownSignSession)
+	})
+	t.Run("limited per customer", func(t *testing.T) {
+		tracker := NewSignSessionTracker(&stubSigning{}, time.Hour, time.Minute, 2)
+		assert.NoError(t, tracker.Track(1, means, "1"))
+		assert.False(t, tracker.Full(1))
+		assert.NoError(t, tracker.Track(1, means, "2"))
+
+		assert.True(t, tracker.Full(1))
+		assert.ErrorIs(t, tracker.Track(1, means, "3"), ErrTooManySignSessions)
+		assert.False(t, tracker.Full(2), "other customers aren't limited")
+		assert.NoError(t, tracker.Track(2, means, "4"))
+	})
+}
+
+func TestSignSessionState(t *testing.T) {
+	means := SigningMeans{CompletedStatus: "DONE"}
+
+	assert.Equal(t, SignSessionCompleted, signSessionState(means, "DONE"))
+	assert.Equal(t, SignSessionPending, signSessionState(means, "CONNECTED"))
+	assert.Equal(t, SignSessionCancelled, signSessionState(means, "CANCELLED"))
+	assert.Equal(t, SignSessionExpired, signSessionState(means, "TIMEOUT"))
+	assert.Equal(t, SignSessionFailed, signSessionState(means, "ERRORED"))
+}
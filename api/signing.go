@@ -0,0 +1,225 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+// SigningMeans is a means of the Nuts node (e.g. irma, uzi or employeeid) users can sign the login contract with,
+// to log in or elevate their session. Signing a contract identifies the user towards other care organizations.
+type SigningMeans struct {
+	// Params are passed to the means for every signing session, the parameters of the request are added to them.
+	// Parameters of the request can't override the configured ones.
+	Params map[string]interface{}
+	// CompletedStatus is the status of a signing session when the user has signed the contract (e.g. DONE for IRMA).
+	CompletedStatus string
+}
+
+func (w Wrapper) ListSigningMeans(ctx echo.Context) error {
+	customer, err := w.getCustomerFromHeader(ctx)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, w.allowedSigningMeans(*customer))
+}
+
+func (w Wrapper) CreateSignSession(ctx echo.Context, means string) error {
+	customer, err := w.getCustomerFromHeader(ctx)
+	if err != nil {
+		return err
+	}
+	signingMeans, err := w.getSigningMeans(*customer, means)
+	if err != nil {
+		return err
+	}
+	request := types.CreateSignSessionRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	params := map[string]interface{}{}
+	if request.Params != nil {
+		for key, value := range *request.Params {
+			params[key] = value
+		}
+	}
+	// the configured params are applied last, so users can't override them
+	for key, value := range signingMeans.Params {
+		params[key] = value
+	}
+
+	// the login page is public, so the signing sessions (and their polling) are limited per customer
+	if w.SignSessions.Full(customer.Id) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, ErrTooManySignSessions.Error())
+	}
+
+	// forward to node
+	bytes, err := w.NutsAuth.CreateSignSession(ctx.Request().Context(), *customer, means, params)
+	if err != nil {
+		return err
+	}
+
+	// convert to map so echo rendering doesn't escape double quotes
+	j := map[string]interface{}{}
+	if err := json.Unmarshal(bytes, &j); err != nil {
+		return err
+	}
+	sessionToken, ok := j["sessionID"].(string)
+	if !ok {
+		return errors.New("signing session response doesn't contain a sessionID")
+	}
+	if err := w.SignSessions.Track(customer.Id, *signingMeans, sessionToken); err != nil {
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, j)
+}
+
+func (w Wrapper) GetSignSessionResult(ctx echo.Context, means string, sessionToken string) error {
+	customer, err := w.getCustomerFromHeader(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := w.getSigningMeans(*customer, means); err != nil {
+		return err
+	}
+
+	status, presentation, err := w.SignSessions.Result(ctx.Request().Context(), customer.Id, sessionToken)
+	if errors.Is(err, ErrUnknownSignSession) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return err
+	}
+	switch status.State {
+	case SignSessionPending:
+		return echo.NewHTTPError(http.StatusNotFound, "signing session not completed")
+	case SignSessionCompleted:
+	default:
+		return echo.NewHTTPError(http.StatusGone, signSessionError(status))
+	}
+
+	// when elevating a session, the user stays logged in with the same roles
+	currentSession, err := w.APIAuth.GetSessionFromHeader(ctx)
+	if err != nil {
+		return err
+	}
+	sessionID, err := w.APIAuth.StoreVP(ctx.Request().Context(), customer.Id, currentSession, *presentation)
+	if err != nil {
+		return err
+	}
+
+	newToken, err := w.APIAuth.CreateSessionJWT(customer.Name, customer.Id, sessionID, true)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err)
+	}
+
+	return ctx.JSON(http.StatusOK, types.SessionToken{Token: string(newToken)})
+}
+
+// GetSignSessionEvents streams the status of the signing session as Server-Sent Events, until the session is done or
+// the client disconnects. When the session has completed, the session token is fetched with GetSignSessionResult.
+func (w Wrapper) GetSignSessionEvents(ctx echo.Context, means string, sessionToken string) error {
+	customer, err := w.getCustomerFromHeader(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := w.getSigningMeans(*customer, means); err != nil {
+		return err
+	}
+	updates, unsubscribe, err := w.SignSessions.Subscribe(customer.Id, sessionToken)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	defer unsubscribe()
+	// the stream can take minutes, so the transaction of the request is released to not block other requests
+	if transactionManager, err := sqlUtil.GetTransactionManager(ctx.Request().Context()); err == nil {
+		if err := transactionManager.Commit(); err != nil {
+			return err
+		}
+	}
+
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.WriteHeader(http.StatusOK)
+
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+		case status := <-updates:
+			data, err := json.Marshal(status)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(response, "event: status\ndata: %s\n\n", data); err != nil {
+				return nil
+			}
+			response.Flush()
+			if status.Done() {
+				return nil
+			}
+		}
+	}
+}
+
+func signSessionError(status SignSessionStatus) string {
+	if status.Error != "" {
+		return status.Error
+	}
+	return "signing session " + status.State
+}
+
+func (w Wrapper) getCustomerFromHeader(ctx echo.Context) (*types.Customer, error) {
+	customerID, err := w.APIAuth.GetCustomerIDFromHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	customer, err := w.CustomerRepository.FindByID(ctx.Request().Context(), customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil || !customer.Active {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "unknown customer")
+	}
+	return customer, nil
+}
+
+// allowedSigningMeans returns the configured signing means the customer allows, or all if the customer doesn't restrict them.
+func (w Wrapper) allowedSigningMeans(customer types.Customer) []string {
+	result := []string{}
+	for name := range w.SigningMeans {
+		if customer.SigningMeans == nil || containsString(*customer.SigningMeans, name) {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (w Wrapper) getSigningMeans(customer types.Customer, name string) (*SigningMeans, error) {
+	signingMeans, ok := w.SigningMeans[name]
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "unknown signing means: "+name)
+	}
+	if !containsString(w.allowedSigningMeans(customer), name) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "signing means not allowed for customer: "+name)
+	}
+	return &signingMeans, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	nutsAuth "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+// stubSigning is a Nuts node whose signing sessions complete after the given number of polls
+type stubSigning struct {
+	mux    sync.Mutex
+	status string
+	polls  int
+	// params contains the params of the last created session
+	params map[string]interface{}
+}
+
+func (s *stubSigning) CreateSignSession(_ context.Context, _ types.Customer, _ string, params map[string]interface{}) ([]byte, error) {
+	s.params = params
+	return []byte(`{"sessionID": "123"}`), nil
+}
+
+func (s *stubSigning) GetSignSessionResult(_ context.Context, _ string) (*nutsAuth.SignSessionStatusResponse, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.polls--
+	if s.polls > 0 {
+		return &nutsAuth.SignSessionStatusResponse{Status: "pending"}, nil
+	}
+	return &nutsAuth.SignSessionStatusResponse{Status: s.status, VerifiablePresentation: &nutsAuth.VerifiablePresentation{}}, nil
+}
+
+func TestWrapper_SigningMeans(t *testing.T) {
+	customerRepository := newMemoryCustomerRepository(
+		types.Customer{Id: 1, Name: "Care Home", SigningMeans: &[]string{"irma"}},
+		types.Customer{Id: 2, Name: "Hospital"},
+	)
+
+	newWrapper := func(signing *stubSigning) Wrapper {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		db.SetMaxOpenConns(1)
+		keys, _ := NewKeyRing(filepath.Join(t.TempDir(), "session-keys.json"))
+		return Wrapper{
+			APIAuth:            NewAuth(keys, customerRepository, nil, NewSQLiteSessionStore(db)),
+			NutsAuth:           signing,
+			CustomerRepository: customerRepository,
+			SigningMeans: map[string]SigningMeans{
+				"irma":       {CompletedStatus: "DONE"},
+				"dummy":      {CompletedStatus: "completed"},
+				"employeeid": {CompletedStatus: "completed", Params: map[string]interface{}{"employer": "did:nuts:123"}},
+			},
+			// polls slowly, so the tests decide when the result is fetched
+			SignSessions: NewSignSessionTracker(signing, time.Hour, time.Minute, 10),
+		}
+	}
+	newContext := func(w Wrapper, customerID int) (echo.Context, *httptest.ResponseRecorder) {
+		token, _ := w.APIAuth.CreateCustomerJWT(customerID)
+		request := httptest.NewRequest(http.MethodPost, "/", nil)
+		request.Header.Set(echo.HeaderAuthorization, "Bearer "+string(token))
+		recorder := httptest.NewRecorder()
+		return echo.New().NewContext(request, recorder), recorder
+	}
+	httpStatus := func(err error) int {
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			return httpErr.Code
+		}
+		return 0
+	}
+
+	t.Run("list allowed means", func(t *testing.T) {
+		w := newWrapper(&stubSigning{})
+
+		ctx, recorder := newContext(w, 1)
+		assert.NoError(t, w.ListSigningMeans(ctx))
+		assert.JSONEq(t, `["irma"]`, recorder.Body.String())
+
+		ctx, recorder = newContext(w, 2)
+		assert.NoError(t, w.ListSigningMeans(ctx))
+		assert.JSONEq(t, `["dummy", "employeeid", "irma"]`, recorder.Body.String())
+	})
+	t.Run("means not allowed for customer", func(t *testing.T) {
+		w := newWrapper(&stubSigning{})
+		ctx, _ := newContext(w, 1)
+
+		assert.Equal(t, http.StatusForbidden, httpStatus(w.CreateSignSession(ctx, "dummy")))
+	})
+	t.Run("unknown means", func(t *testing.T) {
+		w := newWrapper(&stubSigning{})
+		ctx, _ := newContext(w, 2)
+
+		assert.Equal(t, http.StatusNotFound, httpStatus(w.CreateSignSession(ctx, "uzi")))
+	})
+	t.Run("configured params can't be overridden", func(t *testing.T) {
+		signing := &stubSigning{}
+		w := newWrapper(signing)
+		ctx, _ := newContext(w, 2)
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"params": {"employer": "did:nuts:other", "name": "Alice"}}`))
+		request.Header = ctx.Request().Header
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		ctx.SetRequest(request)
+
+		assert.NoError(t, w.CreateSignSession(ctx, "employeeid"))
+		assert.Equal(t, map[string]interface{}{"employer": "did:nuts:123", "name": "Alice"}, signing.params)
+	})
+	t.Run("too many sessions", func(t *testing.T) {
+		signing := &stubSigning{}
+		w := newWrapper(signing)
+		w.SignSessions = NewSignSessionTracker(signing, time.Hour, time.Minute, 1)
+		ctx, _ := newContext(w, 2)
+		assert.NoError(t, w.CreateSignSession(ctx, "dummy"))
+		signing.params = nil
+
+		ctx, _ = newContext(w, 2)
+		assert.Equal(t, http.StatusTooManyRequests, httpStatus(w.CreateSignSession(ctx, "dummy")))
+		assert.Nil(t, signing.params, "no signing session must be created at the Nuts node")
+	})
+	t.Run("unknown session", func(t *testing.T) {
+		w := newWrapper(&stubSigning{status: "completed"})
+		ctx, _ := newContext(w, 2)
+
+		assert.Equal(t, http.StatusNotFound, httpStatus(w.GetSignSessionResult(ctx, "dummy", "123")))
+	})
+	t.Run("result once completed", func(t *testing.T) {
+		w := newWrapper(&stubSigning{status: "completed", polls: 2})
+		ctx, _ := newContext(w, 2)
+		assert.NoError(t, w.CreateSignSession(ctx, "dummy"))
+
+		ctx, _ = newContext(w, 2)
+		assert.Equal(t, http.StatusNotFound, httpStatus(w.GetSignSessionResult(ctx, "dummy", "123")))
+
+		ctx, recorder := newContext(w, 2)
+		assert.NoError(t, w.GetSignSessionResult(ctx, "dummy", "123"))
+		assert.Contains(t, recorder.Body.String(), "token")
+	})
+	t.Run("session of other customer", func(t *testing.T) {
+		w := newWrapper(&stubSigning{status: "DONE"})
+		ctx, _ := newContext(w, 1)
+		assert.NoError(t, w.CreateSignSession(ctx, "irma"))
+
+		ctx, _ = newContext(w, 2)
+		assert.Equal(t, http.StatusNotFound, httpStatus(w.GetSignSessionResult(ctx, "irma", "123")))
+	})
+	t.Run("session cancelled", func(t *testing.T) {
+		w := newWrapper(&stubSigning{status: "CANCELLED"})
+		ctx, _ := newContext(w, 1)
+		assert.NoError(t, w.CreateSignSession(ctx, "irma"))
+
+		ctx, _ = newContext(w, 1)
+		assert.Equal(t, http.StatusGone, httpStatus(w.GetSignSessionResult(ctx, "irma", "123")))
+	})
+	t.Run("events", func(t *testing.T) {
+		w := newWrapper(&stubSigning{status: "DONE"})
+		ctx, _ := newContext(w, 1)
+		assert.NoError(t, w.CreateSignSession(ctx, "irma"))
+		ctx, _ = newContext(w, 1)
+		_ = w.GetSignSessionResult(ctx, "irma", "123")
+
+		ctx, recorder := newContext(w, 1)
+		assert.NoError(t, w.GetSignSessionEvents(ctx, "irma", "123"))
+
+		assert.Equal(t, "text/event-stream", recorder.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "event: status\ndata: {\"state\":\"completed\",\"status\":\"DONE\"}\n\n", recorder.Body.String())
+	})
+	t.Run("events don't hold the transaction of the request", func(t *testing.T) {
+		w := newWrapper(&stubSigning{status: "DONE", polls: 100})
+		ctx, _ := newContext(w, 1)
+		assert.NoError(t, w.CreateSignSession(ctx, "irma"))
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		db.SetMaxOpenConns(1)
+		started := make(chan struct{})
+		handler := sql.Transactional(db)(func(ctx echo.Context) error {
+			// like the customer lookup does
+			_, _ = sql.GetTransaction(ctx.Request().Context())
+			close(started)
+			return w.GetSignSessionEvents(ctx, "irma", "123")
+		})
+
+		ctx, _ = newContext(w, 1)
+		requestCtx, cancel := context.WithCancel(context.Background())
+		ctx.SetRequest(ctx.Request().WithContext(requestCtx))
+		done := make(chan error)
+		go func() {
+			done <- handler(ctx)
+		}()
+		<-started
+
+		// the database has a single connection, which is only available if the transaction was released
+		queryCtx, cancelQuery := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelQuery()
+		_, err := db.ExecContext(queryCtx, "SELECT 1")
+		assert.NoError(t, err)
+
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
 	httpAuth "github.com/nuts-foundation/nuts-demo-ehr/http/auth"
 	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
 	"github.com/sirupsen/logrus"
 
 	"github.com/labstack/echo/v4"
@@ -90,11 +91,10 @@ func (w Wrapper) UpdateTransfer(ctx echo.Context, transferID string) error {
 		return err
 	}
 
-	_, err = w.TransferSenderRepo.Update(ctx.Request().Context(), cid, transferID, func(t *types.Transfer) (*types.Transfer, error) {
-		//t.Description = updateRequest.Description
-		t.TransferDate = updateRequest.TransferDate
-		return t, nil
-	})
+	err = w.TransferSenderService.UpdateTransferDate(ctx.Request().Context(), cid, transferID, updateRequest.TransferDate.Time)
+	if err != nil {
+		return err
+	}
 
 	transfer, err := w.TransferSenderService.GetTransferByID(ctx.Request().Context(), cid, transferID)
 	if err != nil {
@@ -221,7 +221,7 @@ func (w Wrapper) NotifyTransferUpdate(ctx echo.Context, taskID string) error {
 		return errors.New("missing 'Iss' in access-token")
 	}
 
-	customer, err := w.CustomerRepository.FindByDID(*customerDID)
+	customer, err := w.CustomerRepository.FindByDID(ctx.Request().Context(), *customerDID)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, &proxy.OperationOutcome{
 			Text: "an error occurred",
@@ -250,12 +250,24 @@ func (w Wrapper) NotifyTransferUpdate(ctx echo.Context, taskID string) error {
 		})
 	}
 
-	if err := w.NotificationHandler.Handle(ctx.Request().Context(), notification.Notification{
+	err = w.NotificationHandler.Handle(ctx.Request().Context(), notification.Notification{
 		TaskID:      taskID,
 		SenderDID:   *senderDID,
 		CustomerDID: *customerDID,
 		CustomerID:  customer.Id,
-	}); err != nil {
+	})
+	if errors.Is(err, registry.ErrOrganizationNotFound) {
+		return ctx.JSON(http.StatusForbidden, &proxy.OperationOutcome{
+			Text: "sender not trusted",
+			Issue: &proxy.Issue{
+				Code:     "forbidden",
+				Severity: "error",
+				Details: &proxy.IssueDetails{
+					Text: err.Error(),
+				},
+			},
+		})
+	} else if err != nil {
 		return err
 	}
 
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/trust"
+)
+
+// GetCredentialIssuers lists the trusted and untrusted issuers of credentials of the type.
+func (w Wrapper) GetCredentialIssuers(ctx echo.Context, credentialType string) error {
+	issuers, err := w.Trust.Issuers(ctx.Request().Context(), credentialType)
+	if err != nil {
+		return trustError(err)
+	}
+	return ctx.JSON(http.StatusOK, issuers)
+}
+
+func (w Wrapper) TrustIssuer(ctx echo.Context, credentialType string, issuer string) error {
+	if err := w.Trust.Trust(ctx.Request().Context(), credentialType, issuer); err != nil {
+		return trustError(err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (w Wrapper) UntrustIssuer(ctx echo.Context, credentialType string, issuer string) error {
+	if err := w.Trust.Untrust(ctx.Request().Context(), credentialType, issuer); err != nil {
+		return trustError(err)
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func trustError(err error) error {
+	if errors.Is(err, trust.ErrUnsupportedCredentialType) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+}
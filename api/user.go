@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
+)
+
+func (w Wrapper) ListUsers(ctx echo.Context) error {
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	result, err := w.UserRepository.All(ctx.Request().Context(), cid)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (w Wrapper) CreateUser(ctx echo.Context) error {
+	request := types.CreateUserRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	if request.Username == "" || request.Password == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "username and password are required")
+	}
+	if err := checkVendorRoles(ctx, request.Roles); err != nil {
+		return err
+	}
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := w.UserRepository.Create(ctx.Request().Context(), cid, request.Username, request.Password, request.Roles)
+	if errors.Is(err, users.ErrUsernameTaken) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	} else if errors.Is(err, users.ErrUnknownRole) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, user)
+}
+
+func (w Wrapper) UpdateUser(ctx echo.Context, userID string) error {
+	request := types.UpdateUserRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return err
+	}
+	if request.Password != nil && *request.Password == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "password can't be empty")
+	}
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := w.UserRepository.FindByID(ctx.Request().Context(), cid, userID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return echo.NewHTTPError(http.StatusNotFound, users.ErrUserNotFound.Error())
+	}
+	if err := checkVendorRoles(ctx, append(existing.Roles, request.Roles...)); err != nil {
+		return err
+	}
+	user, err := w.UserRepository.Update(ctx.Request().Context(), cid, userID, request.Password, request.Roles)
+	if errors.Is(err, users.ErrUserNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if errors.Is(err, users.ErrUnknownRole) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	} else if err != nil {
+		return err
+	}
+	// sessions contain the roles of the user at login
+	if err := w.APIAuth.EndUserSessions(ctx.Request().Context(), cid, user.Username); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, user)
+}
+
+func (w Wrapper) DeleteUser(ctx echo.Context, userID string) error {
+	cid, err := w.getCustomerID(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := w.UserRepository.FindByID(ctx.Request().Context(), cid, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return echo.NewHTTPError(http.StatusNotFound, users.ErrUserNotFound.Error())
+	}
+	if err := checkVendorRoles(ctx, user.Roles); err != nil {
+		return err
+	}
+	err = w.UserRepository.Delete(ctx.Request().Context(), cid, userID)
+	if errors.Is(err, users.ErrUserNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	} else if err != nil {
+		return err
+	}
+	if err := w.APIAuth.EndUserSessions(ctx.Request().Context(), cid, user.Username); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// checkVendorRoles returns an error if the roles contain the vendor role and the user of the session isn't a vendor,
+// so admins of a customer can't make themselves or others vendor, nor change the vendor's account.
+func checkVendorRoles(ctx echo.Context, roles []types.UserRole) error {
+	if !users.HasRole(roles, types.UserRoleVendor) {
+		return nil
+	}
+	if session, ok := ctx.Get(sessionKey).(Session); !ok || !session.HasRole(types.UserRoleVendor) {
+		return echo.NewHTTPError(http.StatusForbidden, "only vendors may manage vendors")
+	}
+	return nil
+}
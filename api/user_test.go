@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/echo/v4"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+func TestWrapper_UserSessions(t *testing.T) {
+	// runs the test with alice and bob logged in, it returns whether their sessions are still valid afterwards
+	run := func(t *testing.T, test func(ctx context.Context, w Wrapper, alice *types.User) error) (bool, bool) {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		// every connection to :memory: has its own database
+		db.SetMaxOpenConns(1)
+		sessions := NewSQLiteSessionStore(db)
+		w := Wrapper{
+			CustomerRepository: newMemoryCustomerRepository(types.Customer{Id: 1, Name: "Care Home"}),
+			UserRepository:     users.NewSQLiteUserRepository(db),
+			APIAuth:            NewAuth(nil, nil, nil, sessions),
+		}
+		var aliceLoggedIn, bobLoggedIn bool
+		err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			alice, err := w.UserRepository.Create(ctx, 1, "alice", "secret", []types.UserRole{types.UserRoleNurse})
+			require.NoError(t, err)
+			aliceSession, _ := sessions.Create(ctx, Session{CustomerID: 1, Username: "alice", Roles: alice.Roles, StartTime: time.Now()})
+			bobSession, _ := sessions.Create(ctx, Session{CustomerID: 1, Username: "bob", Roles: alice.Roles, StartTime: time.Now()})
+
+			if err := test(ctx, w, alice); err != nil {
+				return err
+			}
+
+			session, _ := sessions.Get(ctx, aliceSession)
+			aliceLoggedIn = session != nil
+			session, _ = sessions.Get(ctx, bobSession)
+			bobLoggedIn = session != nil
+			return nil
+		})
+		require.NoError(t, err)
+		return aliceLoggedIn, bobLoggedIn
+	}
+	newContext := func(ctx context.Context, body string) echo.Context {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)).WithContext(ctx)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		echoCtx := echo.New().NewContext(request, httptest.NewRecorder())
+		echoCtx.Set(CustomerID, 1)
+		return echoCtx
+	}
+
+	t.Run("update ends the sessions of the user", func(t *testing.T) {
+		alice, bob := run(t, func(ctx context.Context, w Wrapper, user *types.User) error {
+			return w.UpdateUser(newContext(ctx, `{"roles": ["nurse", "planner"]}`), string(user.Id))
+		})
+
+		assert.False(t, alice)
+		assert.True(t, bob)
+	})
+	t.Run("delete ends the sessions of the user", func(t *testing.T) {
+		alice, bob := run(t, func(ctx context.Context, w Wrapper, user *types.User) error {
+			return w.DeleteUser(newContext(ctx, ""), string(user.Id))
+		})
+
+		assert.False(t, alice)
+		assert.True(t, bob)
+	})
+	t.Run("delete unknown user", func(t *testing.T) {
+		alice, bob := run(t, func(ctx context.Context, w Wrapper, _ *types.User) error {
+			err := w.DeleteUser(newContext(ctx, ""), "unknown")
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+			return nil
+		})
+
+		assert.True(t, alice)
+		assert.True(t, bob)
+	})
+}
+
+func TestWrapper_VendorUsers(t *testing.T) {
+	admin := Session{CustomerID: 1, Roles: users.CustomerRoles}
+	vendor := Session{CustomerID: 1, Roles: users.AllRoles}
+	call := func(t *testing.T, session Session, test func(ctx func(body string) echo.Context, w Wrapper, vendorUser *types.User) error) error {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		db.SetMaxOpenConns(1)
+		w := Wrapper{
+			CustomerRepository: newMemoryCustomerRepository(types.Customer{Id: 1, Name: "Care Home"}),
+			UserRepository:     users.NewSQLiteUserRepository(db),
+			APIAuth:            NewAuth(nil, nil, nil, NewSQLiteSessionStore(db)),
+		}
+		var result error
+		err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			vendorUser, err := w.UserRepository.Create(ctx, 1, "admin", "secret", users.AllRoles)
+			require.NoError(t, err)
+			newContext := func(body string) echo.Context {
+				request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)).WithContext(ctx)
+				request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				echoCtx := echo.New().NewContext(request, httptest.NewRecorder())
+				echoCtx.Set(CustomerID, 1)
+				echoCtx.Set(sessionKey, session)
+				return echoCtx
+			}
+			result = test(newContext, w, vendorUser)
+			return nil
+		})
+		require.NoError(t, err)
+		return result
+	}
+	createVendor := func(ctx func(body string) echo.Context, w Wrapper, _ *types.User) error {
+		return w.CreateUser(ctx(`{"username": "bob", "password": "secret", "roles": ["vendor"]}`))
+	}
+	demoteVendor := func(ctx func(body string) echo.Context, w Wrapper, vendorUser *types.User) error {
+		return w.UpdateUser(ctx(`{"roles": ["admin"]}`), string(vendorUser.Id))
+	}
+	deleteVendor := func(ctx func(body string) echo.Context, w Wrapper, vendorUser *types.User) error {
+		return w.DeleteUser(ctx(""), string(vendorUser.Id))
+	}
+
+	t.Run("admin can't manage vendors", func(t *testing.T) {
+		for _, test := range []func(ctx func(body string) echo.Context, w Wrapper, vendorUser *types.User) error{createVendor, demoteVendor, deleteVendor} {
+			err := call(t, admin, test)
+
+			if assert.IsType(t, &echo.HTTPError{}, err) {
+				assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+			}
+		}
+	})
+	t.Run("vendor can manage vendors", func(t *testing.T) {
+		for _, test := range []func(ctx func(body string) echo.Context, w Wrapper, vendorUser *types.User) error{createVendor, demoteVendor, deleteVendor} {
+			assert.NoError(t, call(t, vendor, test))
+		}
+	})
+}
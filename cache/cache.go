@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats contains the usage statistics of a Cache.
+type Stats struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	Entries int     `json:"entries"`
+	HitRate float64 `json:"hitRate"`
+}
+
+// Cache is a thread-safe, size-bounded key/value store of which the entries expire after a fixed TTL.
+// When the cache is full the least recently used entry is evicted.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	// mux is used to secure access to internal state of this struct to prevent racy behaviour
+	mux     sync.Mutex
+	entries map[string]*list.Element
+	// lru keeps the entries ordered by usage, most recently used first
+	lru    *list.List
+	hits   uint64
+	misses uint64
+	now    func() time.Time
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+// New creates a Cache which keeps entries for at most the given TTL. If maxEntries is 0 or less the size is unbounded.
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// Get returns the value stored under the given key. The second return value indicates whether a non-expired entry was found.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	current := element.Value.(*entry)
+	if !c.now().Before(current.expiresAt) {
+		c.removeElement(element)
+		c.misses++
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	c.hits++
+
+	return current.value, true
+}
+
+// Set stores the value under the given key, expiring it after the TTL of the cache.
+func (c *Cache) Set(key string, value interface{}) {
+	c.SetWithExpiry(key, value, time.Time{})
+}
+
+// SetWithExpiry stores the value under the given key, expiring it at the given moment or after the TTL of the cache,
+// whichever comes first. A zero expiry means only the TTL applies.
+func (c *Cache) SetWithExpiry(key string, value interface{}, expiry time.Time) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if !expiry.IsZero() && expiry.Before(expiresAt) {
+		expiresAt = expiry
+	}
+
+	if element, ok := c.entries[key]; ok {
+		current := element.Value.(*entry)
+		current.value = value
+		current.expiresAt = expiresAt
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// Delete removes the entry stored under the given key, if any.
+func (c *Cache) Delete(key string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// DeletePrefix removes all entries of which the key starts with the given prefix.
+func (c *Cache) DeletePrefix(prefix string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(element)
+		}
+	}
+}
+
+// Stats returns the usage statistics of the cache.
+func (c *Cache) Stats() Stats {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	stats := Stats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.lru.Len(),
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+
+	return stats
+}
+
+func (c *Cache) removeElement(element *list.Element) {
+	c.lru.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
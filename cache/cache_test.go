@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Get(t *testing.T) {
+	t.Run("hit", func(t *testing.T) {
+		c := New(time.Minute, 0)
+		c.Set("key", "value")
+
+		value, ok := c.Get("key")
+
+		assert.True(t, ok)
+		assert.Equal(t, "value", value)
+	})
+	t.Run("miss", func(t *testing.T) {
+		c := New(time.Minute, 0)
+
+		value, ok := c.Get("key")
+
+		assert.False(t, ok)
+		assert.Nil(t, value)
+	})
+	t.Run("expired by TTL", func(t *testing.T) {
+		now := time.Now()
+		c := New(time.Minute, 0)
+		c.now = func() time.Time { return now }
+		c.Set("key", "value")
+
+		c.now = func() time.Time { return now.Add(time.Minute) }
+		_, ok := c.Get("key")
+
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Stats().Entries)
+	})
+	t.Run("expired by explicit expiry", func(t *testing.T) {
+		now := time.Now()
+		c := New(time.Hour, 0)
+		c.now = func() time.Time { return now }
+		c.SetWithExpiry("key", "value", now.Add(time.Second))
+
+		c.now = func() time.Time { return now.Add(2 * time.Second) }
+		_, ok := c.Get("key")
+
+		assert.False(t, ok)
+	})
+	t.Run("explicit expiry does not exceed TTL", func(t *testing.T) {
+		now := time.Now()
+		c := New(time.Second, 0)
+		c.now = func() time.Time { return now }
+		c.SetWithExpiry("key", "value", now.Add(time.Hour))
+
+		c.now = func() time.Time { return now.Add(2 * time.Second) }
+		_, ok := c.Get("key")
+
+		assert.False(t, ok)
+	})
+}
+
+func TestCache_Set(t *testing.T) {
+	t.Run("evicts least recently used entry when full", func(t *testing.T) {
+		c := New(time.Minute, 2)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+
+		_, okA := c.Get("a")
+		_, okB := c.Get("b")
+		_, okC := c.Get("c")
+
+		assert.True(t, okA)
+		assert.False(t, okB)
+		assert.True(t, okC)
+		assert.Equal(t, 2, c.Stats().Entries)
+	})
+	t.Run("overwrites existing entry", func(t *testing.T) {
+		c := New(time.Minute, 0)
+		c.Set("key", 1)
+		c.Set("key", 2)
+
+		value, _ := c.Get("key")
+
+		assert.Equal(t, 2, value)
+		assert.Equal(t, 1, c.Stats().Entries)
+	})
+}
+
+func TestCache_Delete(t *testing.T) {
+	c := New(time.Minute, 0)
+	c.Set("key", "value")
+
+	c.Delete("key")
+
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+}
+
+func TestCache_DeletePrefix(t *testing.T) {
+	c := New(time.Minute, 0)
+	c.Set("a|1", "value")
+	c.Set("a|2", "value")
+	c.Set("b|1", "value")
+
+	c.DeletePrefix("a|")
+
+	assert.Equal(t, 1, c.Stats().Entries)
+	_, ok := c.Get("b|1")
+	assert.True(t, ok)
+}
+
+func TestCache_Stats(t *testing.T) {
+	c := New(time.Minute, 0)
+	c.Set("key", "value")
+	c.Get("key")
+	c.Get("key")
+	c.Get("other")
+	c.Get("other")
+
+	stats := c.Stats()
+
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, 0.5, stats.HitRate)
+}
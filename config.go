@@ -2,19 +2,27 @@ package main
 
 import (
 	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/nuts-foundation/nuts-demo-ehr/api"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/onboarding"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
@@ -31,6 +39,14 @@ const defaultHTTPPort = 1304
 const defaultNutsNodeAddress = "http://localhost:1323"
 const defaultCustomerFile = "customers.json"
 const defaultLogLevel = "info"
+const defaultCacheTTL = time.Minute
+const defaultCacheMaxEntries = 1000
+const defaultCacheNotFoundTTL = 10 * time.Second
+const defaultCacheCredentialTTL = 10 * time.Second
+const defaultSessionKeyFile = "session-keys.json"
+const defaultSessionKeyRotationInterval = 24 * time.Hour
+const defaultTransferGracePeriod = 7 * 24 * time.Hour
+const defaultEpisodeGracePeriod = 24 * time.Hour
 
 // defaultHAPIFHIRServer configures usage of the HAPI FHIR Server (https://hapifhir.io/)
 var defaultHAPIFHIRServer = FHIRServer{
@@ -49,10 +65,39 @@ func defaultConfig() Config {
 				Path:   "/fhir",
 			},
 		},
-		CustomersFile:      defaultCustomerFile,
-		Credentials:        Credentials{Password: "demo"},
+		CustomersFile: defaultCustomerFile,
+		Cache: Cache{
+			TTL:        defaultCacheTTL,
+			MaxEntries: defaultCacheMaxEntries,
+			// organizations that aren't found are retried soon, since they might just have been onboarded
+			NotFoundTTL: defaultCacheNotFoundTTL,
+			// revocations are only noticed once the cached credential expires, so it's kept well below the TTL
+			CredentialTTL: defaultCacheCredentialTTL,
+		},
+		Credentials:        Credentials{Password: "demo", Vendor: VendorAccount{Username: "vendor"}},
 		DBConnectionString: "demo-ehr.db?cache=shared",
 		LoadTestPatients:   false,
+		SessionKeys: SessionKeys{
+			File:             defaultSessionKeyFile,
+			RotationInterval: defaultSessionKeyRotationInterval,
+		},
+		SigningMeans: map[string]SigningMeans{
+			"irma":  {CompletedStatus: "DONE"},
+			"dummy": {CompletedStatus: "completed"},
+		},
+		OIDC: OIDC{
+			Scopes: []string{"openid", "profile"},
+			Claims: OIDCClaims{
+				Customer: "customer_id",
+				Username: "preferred_username",
+				Roles:    "roles",
+			},
+		},
+		Expiration: Expiration{
+			EOverdracht: ExpirationPolicy{GracePeriod: defaultTransferGracePeriod},
+			// the episode end is a date, so its credentials are valid until the end of that day
+			Zorginzage: ExpirationPolicy{GracePeriod: defaultEpisodeGracePeriod},
+		},
 	}
 }
 
@@ -64,29 +109,185 @@ type Config struct {
 	FHIR            FHIR        `koanf:"fhir"`
 	CustomersFile   string      `koanf:"customersfile"`
 	Branding        Branding    `koanf:"branding"`
+	Cache           Cache       `koanf:"cache"`
+	OIDC            OIDC        `koanf:"oidc"`
+	// Public configures a separate listener for the endpoints called by other care organizations.
+	Public PublicListener `koanf:"public"`
+	// TLS configures mutual TLS with other care organizations.
+	TLS TLS `koanf:"tls"`
+	// Onboarding configures setting new customers up on the Nuts network.
+	Onboarding Onboarding `koanf:"onboarding"`
+	// Trust configures which issuers of credentials are trusted.
+	Trust Trust `koanf:"trust"`
+	// Expiration configures when the authorization credentials issued to other care organizations expire.
+	Expiration Expiration `koanf:"expiration"`
+	// SigningMeans configures the means of the Nuts node users can sign the login contract with, by name of the means.
+	SigningMeans map[string]SigningMeans `koanf:"signingmeans"`
 	// Database connection string, accepts all options for the sqlite3 driver
 	// https://github.com/mattn/go-sqlite3#connection-string
 	DBConnectionString string `koanf:"dbConnectionString"`
 	// Load a set of test patients on startup. Should be disabled for permanent data stores.
 	LoadTestPatients bool `koanf:"loadTestPatients"`
-	// If set, this key wil be used to sign JWTs. If not set, a new one is generated on each start up.
-	// Developer tip: set the sessionPemKey so the session keeps valid after a server reboot.
-	SessionPemKey string `koanf:"sessionPemKey"`
+	// If set, this key wil be used to sign JWTs and it's never rotated. If not set, the keys of SessionKeys are used.
+	SessionPemKey string      `koanf:"sessionPemKey"`
+	SessionKeys   SessionKeys `koanf:"sessionkeys"`
 	sessionKey    *ecdsa.PrivateKey
 }
 
+// SessionKeys configures the rotating keys that sign the session tokens.
+type SessionKeys struct {
+	// File persists the keys, so sessions stay valid after a restart.
+	File string `koanf:"file"`
+	// RotationInterval is the time after which a new key is used to sign tokens. Previous keys are accepted until the
+	// tokens they signed have expired.
+	RotationInterval time.Duration `koanf:"rotationinterval"`
+}
+
+// PublicListener configures the listener for the endpoints called by other care organizations: /web/external and the
+// FHIR proxy. When enabled, /web/external is no longer served on the (internal) HTTPPort. The FHIR proxy is, for local apps.
+type PublicListener struct {
+	// Port of the public listener, if 0 all endpoints are served on HTTPPort.
+	Port int `koanf:"port"`
+}
+
+func (p PublicListener) Enabled() bool {
+	return p.Port != 0
+}
+
+// TLS configures mutual TLS with other care organizations. When enabled, the public listener requires a client
+// certificate issued by a CA in the truststore (except for /status), and the EHR presents its certificate when calling
+// other care organizations.
+type TLS struct {
+	CertFile    string `koanf:"certfile"`
+	CertKeyFile string `koanf:"certkeyfile"`
+	// TrustStoreFile contains the PEM encoded certificates of the CAs that issue the certificates of other care organizations.
+	TrustStoreFile string `koanf:"truststorefile"`
+	// BindRequester requires the client certificate to belong to the requesting care organization: it must contain the DID
+	// of the organization as URI subject alternative name, or the serial number configured in RequesterSerialNumbers.
+	BindRequester bool `koanf:"bindrequester"`
+	// RequesterSerialNumbers configures the subject serial numbers (e.g. URA) of the client certificates of care
+	// organizations whose certificates don't contain their DID.
+	RequesterSerialNumbers []RequesterSerialNumber `koanf:"requesterserialnumbers"`
+}
+
+// RequesterSerialNumber binds the client certificates with the subject serial number to the care organization with the DID.
+type RequesterSerialNumber struct {
+	DID          string `koanf:"did"`
+	SerialNumber string `koanf:"serialnumber"`
+}
+
+// SerialNumbers returns the configured subject serial numbers of the client certificates by the DID of their organization.
+func (t TLS) SerialNumbers() map[string]string {
+	result := map[string]string{}
+	for _, requester := range t.RequesterSerialNumbers {
+		result[requester.DID] = requester.SerialNumber
+	}
+	return result
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// ServerConfig returns the configuration of the public listener, which verifies client certificates. They're only
+// verified if given, so health checks can reach /status; the listener requires them for the other paths.
+func (t TLS) ServerConfig() (*tls.Config, error) {
+	certificate, trustStore, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientCAs:    trustStore,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientConfig returns the configuration for calling other care organizations, nil if TLS isn't enabled.
+func (t TLS) ClientConfig() (*tls.Config, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+	certificate, trustStore, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      trustStore,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func (t TLS) load() (tls.Certificate, *x509.CertPool, error) {
+	certificate, err := tls.LoadX509KeyPair(t.CertFile, t.CertKeyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("unable to load TLS certificate: %w", err)
+	}
+	data, err := os.ReadFile(t.TrustStoreFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("unable to read TLS truststore: %w", err)
+	}
+	trustStore := x509.NewCertPool()
+	if !trustStore.AppendCertsFromPEM(data) {
+		return tls.Certificate{}, nil, fmt.Errorf("TLS truststore doesn't contain PEM encoded certificates (file=%s)", t.TrustStoreFile)
+	}
+	return certificate, trustStore, nil
+}
+
 type FHIR struct {
 	Server FHIRServer `koanf:"server"`
 	Proxy  FHIRProxy  `koanf:"proxy"`
 }
 
 type FHIRServer struct {
-	Type    string `koanf:"type"`
-	Address string `koanf:"address"`
+	Type    string      `koanf:"type"`
+	Address string      `koanf:"address"`
+	Tenancy FHIRTenancy `koanf:"tenancy"`
+}
+
+// FHIRTenancy configures how the data of the customers is separated on the FHIR server.
+type FHIRTenancy struct {
+	// Strategy is one of `path`, `baseurl` or `header`.
+	// If not set, `path` is used for `hapi-multi-tenant` FHIR servers, otherwise the data isn't separated.
+	Strategy string `koanf:"strategy"`
+	// Header contains the name of the HTTP header that identifies the customer, used by the `header` strategy.
+	Header string `koanf:"header"`
+	// BaseURLs maps customer IDs to the base URL of their FHIR server, used by the `baseurl` strategy.
+	BaseURLs map[string]string `koanf:"baseurls"`
 }
 
-func (server FHIRServer) SupportsMultiTenancy() bool {
-	return server.Type == "hapi-multi-tenant"
+// TenantStrategy returns the strategy used to separate the data of the customers on the FHIR server.
+func (server FHIRServer) TenantStrategy() (fhir.TenantStrategy, error) {
+	strategy := server.Tenancy.Strategy
+	if strategy == "" && server.Type == "hapi-multi-tenant" {
+		strategy = "path"
+	}
+
+	switch strategy {
+	case "":
+		return fhir.NoTenancy{BaseURL: server.Address}, nil
+	case "path":
+		return fhir.PathPrefixTenancy{BaseURL: server.Address, HAPIPartitions: server.Type == "hapi-multi-tenant"}, nil
+	case "baseurl":
+		baseURLs := map[int]string{}
+		for key, baseURL := range server.Tenancy.BaseURLs {
+			customerID, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid customer ID in fhir.server.tenancy.baseurls: %s", key)
+			}
+			baseURLs[customerID] = baseURL
+		}
+		return fhir.BaseURLTenancy{BaseURLs: baseURLs}, nil
+	case "header":
+		if server.Tenancy.Header == "" {
+			return nil, errors.New("fhir.server.tenancy.header must be set when using the header strategy")
+		}
+		return fhir.HeaderTenancy{BaseURL: server.Address, HeaderName: server.Tenancy.Header}, nil
+	default:
+		return nil, fmt.Errorf("invalid FHIR tenancy strategy, valid options are: 'path', 'baseurl' or 'header' (strategy=%s)", strategy)
+	}
 }
 
 type FHIRProxy struct {
@@ -94,33 +295,167 @@ type FHIRProxy struct {
 	Path   string `koanf:"path"`
 }
 
-type Credentials struct {
-	Password string `koanf:"password" json:"-"` // json omit tag to avoid having it printed in server log
+// Cache configures the caches for introspected access tokens and resolved credentials used by the security filters,
+// and for the organizations and their endpoints.
+type Cache struct {
+	// TTL is the maximum time an entry is cached. Access tokens are never cached beyond their expiry.
+	TTL time.Duration `koanf:"ttl"`
+	// MaxEntries bounds the number of entries per cache. When full, the least recently used entry is evicted.
+	MaxEntries int `koanf:"maxentries"`
+	// NotFoundTTL is the time DIDs without organization credential are cached for.
+	NotFoundTTL time.Duration `koanf:"notfoundttl"`
+	// CredentialTTL is the time resolved credentials are cached for. It bounds how long a credential revoked by its
+	// issuer is still accepted, so it must be positive and can't exceed the TTL.
+	CredentialTTL time.Duration `koanf:"credentialttl"`
 }
 
-type Branding struct {
-	// Logo defines a path that points to a file on disk to be used as logo, to be displayed in the application.
-	Logo string `koanf:"logo"`
+// OIDC configures logging in with an OpenID Connect provider. It's enabled when the issuer is set.
+type OIDC struct {
+	Issuer       string `koanf:"issuer"`
+	ClientID     string `koanf:"clientid"`
+	ClientSecret string `koanf:"clientsecret" json:"-"`
+	// RedirectURL is the URL of the callback endpoint as registered at the provider, e.g. http://localhost:1304/web/auth/oidc/callback
+	RedirectURL string     `koanf:"redirecturl"`
+	Scopes      []string   `koanf:"scopes"`
+	Claims      OIDCClaims `koanf:"claims"`
+	// RoleMapping maps roles of the provider to the roles of the EHR (nurse, planner or admin).
+	RoleMapping map[string]string `koanf:"rolemapping"`
 }
 
-func (c Credentials) Empty() bool {
-	return len(c.Password) == 0
+// OIDCClaims contains the names of the ID token claims that identify the user, nested claims are separated by dots.
+type OIDCClaims struct {
+	Customer string `koanf:"customer"`
+	Username string `koanf:"username"`
+	Roles    string `koanf:"roles"`
 }
 
-func generateSessionKey() (*ecdsa.PrivateKey, error) {
-	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		logrus.Printf("failed to generate private key: %s", err)
-		return nil, err
+// Onboarding configures setting customers up on the Nuts network. Their DIDs are controlled by the vendor of the EHR,
+// since they don't have a Nuts node of their own.
+type Onboarding struct {
+	// VendorDID controls the DIDs of the customers and issues their organization credentials.
+	// Its DID document must contain an oauth service, which is referenced by the services of the customers.
+	VendorDID string `koanf:"vendordid"`
+	// PublicURL is the URL on which other care organizations reach the EHR, e.g. https://ehr.example.com.
+	PublicURL string `koanf:"publicurl"`
+}
+
+// Trust configures which issuers of credentials are trusted. Credentials of other issuers aren't accepted.
+type Trust struct {
+	// OrganizationIssuers are trusted as issuers of NutsOrganizationCredentials on startup, e.g. the vendors of other EHRs.
+	OrganizationIssuers []string `koanf:"organizationissuers"`
+	// AuthorizationPolicy determines which issuers of NutsAuthorizationCredentials are trusted: `organizations` (default)
+	// trusts issuers with a trusted NutsOrganizationCredential, `vcr` the issuers the Nuts node trusts and `all` any issuer.
+	AuthorizationPolicy string `koanf:"authorizationpolicy"`
+}
+
+// Expiration configures when the NutsAuthorizationCredentials issued to other care organizations expire, per Bolt.
+type Expiration struct {
+	// EOverdracht credentials expire the grace period after the transfer date.
+	EOverdracht ExpirationPolicy `koanf:"eoverdracht"`
+	// Zorginzage credentials expire the grace period after the end of the episode. Credentials of episodes without an
+	// end expire the validity after they're issued.
+	Zorginzage ExpirationPolicy `koanf:"zorginzage"`
+}
+
+type ExpirationPolicy struct {
+	GracePeriod time.Duration `koanf:"graceperiod"`
+	// Validity is used when the date the access is needed until is unknown. If 0, the credentials don't expire.
+	Validity time.Duration `koanf:"validity"`
+}
+
+func (p ExpirationPolicy) Policy() registry.ExpirationPolicy {
+	return registry.ExpirationPolicy{GracePeriod: p.GracePeriod, Validity: p.Validity}
+}
+
+// TrustedOrganizationIssuers returns the issuers of NutsOrganizationCredentials that are trusted on startup. When
+// onboarding is enabled, the vendor is trusted too, since it issues the organization credentials of the customers.
+func (c Config) TrustedOrganizationIssuers() []string {
+	issuers := append([]string{}, c.Trust.OrganizationIssuers...)
+	if c.Onboarding.Enabled() {
+		issuers = append(issuers, c.Onboarding.VendorDID)
 	}
-	keyBytes, err := x509.MarshalECPrivateKey(key)
-	if err != nil {
-		return nil, err
+	return issuers
+}
+
+func (o Onboarding) Enabled() bool {
+	return o.VendorDID != "" && o.PublicURL != ""
+}
+
+// Endpoints returns the URLs of the EHR that are registered as services of the customers.
+func (o Onboarding) Endpoints(fhirProxyPath string) onboarding.Endpoints {
+	baseURL := strings.TrimSuffix(o.PublicURL, "/")
+	return onboarding.Endpoints{
+		FHIR:                 baseURL + fhirProxyPath,
+		TransferNotification: baseURL + "/web/external/transfer/notify",
+		EpisodeNotification:  baseURL + "/web/external/episode/notify",
+	}
+}
+
+func (o OIDC) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// AuthConfig returns the configuration for the API's OpenID Connect login.
+func (o OIDC) AuthConfig() api.OIDCConfig {
+	roleMapping := map[string]types.UserRole{}
+	for providerRole, role := range o.RoleMapping {
+		roleMapping[providerRole] = types.UserRole(role)
+	}
+	return api.OIDCConfig{
+		Issuer:        o.Issuer,
+		ClientID:      o.ClientID,
+		ClientSecret:  o.ClientSecret,
+		RedirectURL:   o.RedirectURL,
+		Scopes:        o.Scopes,
+		CustomerClaim: o.Claims.Customer,
+		UsernameClaim: o.Claims.Username,
+		RolesClaim:    o.Claims.Roles,
+		RoleMapping:   roleMapping,
+	}
+}
+
+// SigningMeans configures a means of the Nuts node, e.g. irma, uzi or employeeid.
+type SigningMeans struct {
+	// Params are passed to the means for every signing session.
+	Params map[string]interface{} `koanf:"params"`
+	// CompletedStatus is the status of the signing session when the user has signed, e.g. DONE for IRMA. Defaults to completed.
+	CompletedStatus string `koanf:"completedstatus"`
+}
+
+// APISigningMeans returns the signing means for the API.
+func (c Config) APISigningMeans() map[string]api.SigningMeans {
+	result := map[string]api.SigningMeans{}
+	for name, means := range c.SigningMeans {
+		completedStatus := means.CompletedStatus
+		if completedStatus == "" {
+			completedStatus = "completed"
+		}
+		result[name] = api.SigningMeans{
+			Params:          means.Params,
+			CompletedStatus: completedStatus,
+		}
 	}
-	block := pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}
-	pem.Encode(log.Writer(), &block)
+	return result
+}
 
-	return key, nil
+type Credentials struct {
+	Password string `koanf:"password" json:"-"` // json omit tag to avoid having it printed in server log
+	// Vendor is the account of the operator of the EHR, which is the only account that's given the vendor role.
+	Vendor VendorAccount `koanf:"vendor"`
+}
+
+// VendorAccount configures the account of the operator, which manages all customers, the trust of the Nuts node and the caches.
+type VendorAccount struct {
+	// Customer is the ID of the customer the operator logs in with. No vendor account is created if it isn't set.
+	Customer int    `koanf:"customer"`
+	Username string `koanf:"username"`
+	// Password of the account, it's generated and printed in the log when the account is created if it isn't set.
+	Password string `koanf:"password" json:"-"`
+}
+
+type Branding struct {
+	// Logo defines a path that points to a file on disk to be used as logo, to be displayed in the application.
+	Logo string `koanf:"logo"`
 }
 
 func (c Config) Print(writer io.Writer) error {
@@ -179,12 +514,6 @@ func loadConfig() Config {
 			log.Fatalf("unable to parse sessionPemKey as EC Private key: %v", err)
 		}
 		config.sessionKey = key
-	} else {
-		sessionKey, err := generateSessionKey()
-		if err != nil {
-			log.Fatalf("unable to generate session key: %v", err)
-		}
-		config.sessionKey = sessionKey
 	}
 
 	return config
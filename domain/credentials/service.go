@@ -0,0 +1,230 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nuts-foundation/go-did/vc"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/sirupsen/logrus"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/episode"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer/sender"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	nutsClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+)
+
+// ErrCredentialNotFound is returned when the customer didn't issue an active credential with the ID.
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// Service gives an overview of the NutsAuthorizationCredentials customers issued to other care organizations.
+type Service interface {
+	// List returns the active authorization credentials issued by the customer, with the transfer or episode they
+	// give access to. Credentials that are no longer needed by their transfer or episode are marked as orphaned.
+	List(ctx context.Context, customer types.Customer) ([]types.IssuedCredential, error)
+	// Revoke revokes the authorization credential issued by the customer and returns it.
+	// It returns ErrCredentialNotFound if the customer didn't issue an active credential with the ID.
+	Revoke(ctx context.Context, customer types.Customer, credentialID string) (*types.IssuedCredential, error)
+}
+
+type service struct {
+	vcr           registry.VerifiableCredentialRegistry
+	organizations registry.OrganizationRegistry
+	transfers     sender.TransferRepository
+	// collaborations holds the IDs of the credentials issued to the collaborators in episodes
+	collaborations episode.CollaborationRepository
+	// factory creates clients for the FHIR server of the customer, holding its episodes
+	factory fhir.Factory
+}
+
+func NewService(vcr registry.VerifiableCredentialRegistry, organizations registry.OrganizationRegistry, transfers sender.TransferRepository, collaborations episode.CollaborationRepository, factory fhir.Factory) Service {
+	return &service{
+		vcr:            vcr,
+		organizations:  organizations,
+		transfers:      transfers,
+		collaborations: collaborations,
+		factory:        factory,
+	}
+}
+
+func (s service) List(ctx context.Context, customer types.Customer) ([]types.IssuedCredential, error) {
+	if customer.Did == nil {
+		return []types.IssuedCredential{}, nil
+	}
+	credentials, err := s.vcr.FindIssuedAuthorizationCredentials(ctx, &registry.VCRSearchParams{Issuer: *customer.Did})
+	if err != nil {
+		return nil, fmt.Errorf("unable to find issued credentials: %w", err)
+	}
+
+	results := make([]types.IssuedCredential, 0, len(credentials))
+	for _, authCredential := range credentials {
+		if authCredential.ID == nil {
+			continue
+		}
+		result, err := s.toIssuedCredential(ctx, customer, authCredential)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+func (s service) Revoke(ctx context.Context, customer types.Customer, credentialID string) (*types.IssuedCredential, error) {
+	notFound := fmt.Errorf("%w: %s", ErrCredentialNotFound, credentialID)
+	if customer.Did == nil {
+		return nil, notFound
+	}
+	authCredential, err := s.vcr.ResolveIssuedCredential(ctx, credentialID)
+	if errors.Is(err, nutsClient.ErrCredentialNotFound) || errors.Is(err, nutsClient.ErrCredentialRevoked) {
+		return nil, notFound
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to resolve credential (id=%s): %w", credentialID, err)
+	}
+	// only active authorization credentials the customer issued itself may be revoked
+	if authCredential.ID == nil || authCredential.Issuer.String() != *customer.Did ||
+		!authCredential.IsType(*credential.NutsAuthorizationCredentialTypeURI) ||
+		(authCredential.ExpirationDate != nil && authCredential.ExpirationDate.Before(time.Now())) {
+		return nil, notFound
+	}
+
+	result, err := s.toIssuedCredential(ctx, customer, *authCredential)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vcr.RevokeCredential(ctx, credentialID); err != nil {
+		return nil, fmt.Errorf("unable to revoke credential (id=%s): %w", credentialID, err)
+	}
+	result.Status = types.IssuedCredentialStatusRevoked
+	return result, nil
+}
+
+// toIssuedCredential converts the credential, which must have an ID, and links it to its transfer or episode.
+func (s service) toIssuedCredential(ctx context.Context, customer types.Customer, authCredential vc.VerifiableCredential) (*types.IssuedCredential, error) {
+	id := authCredential.ID.String()
+	var subjects []credential.NutsAuthorizationCredentialSubject
+	if err := authCredential.UnmarshalCredentialSubject(&subjects); err != nil {
+		return nil, fmt.Errorf("invalid content for NutsAuthorizationCredential credentialSubject (id=%s): %w", id, err)
+	}
+	if len(subjects) == 0 {
+		return nil, fmt.Errorf("NutsAuthorizationCredential without credentialSubject (id=%s)", id)
+	}
+	subject := subjects[0]
+
+	result := &types.IssuedCredential{
+		Id:              id,
+		OrganizationDID: subject.ID,
+		Patient:         subject.Subject,
+		PurposeOfUse:    subject.PurposeOfUse,
+		Resources:       make([]string, 0, len(subject.Resources)),
+		IssuanceDate:    authCredential.IssuanceDate,
+		ExpirationDate:  authCredential.ExpirationDate,
+		Status:          types.IssuedCredentialStatusActive,
+	}
+	for _, resource := range subject.Resources {
+		result.Resources = append(result.Resources, resource.Path)
+	}
+
+	if organization, err := s.organizations.Get(ctx, subject.ID); err == nil {
+		result.OrganizationName = &organization.Name
+	} else {
+		logrus.Debugf("Unable to find the organization an authorization credential was issued to (did=%s): %s", subject.ID, err)
+	}
+
+	var err error
+	switch subject.PurposeOfUse {
+	case transfer.SenderServiceName:
+		err = s.linkTransfer(ctx, customer, result)
+	case zorginzage.ServiceName:
+		err = s.linkEpisode(ctx, customer, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// linkTransfer links the credential to the transfer negotiation of the Task it gives access to. The credential is
+// orphaned when the negotiation is cancelled or completed, since it should have been revoked, or when the negotiation
+// refers to another credential, which superseded it.
+func (s service) linkTransfer(ctx context.Context, customer types.Customer, result *types.IssuedCredential) error {
+	taskID := resourceID(result.Resources, "Task")
+	if taskID == "" {
+		orphan(result, "the credential doesn't give access to a transfer task")
+		return nil
+	}
+	negotiation, err := s.transfers.FindNegotiationByTaskID(ctx, customer.Id, taskID)
+	if err != nil {
+		return err
+	}
+	if negotiation == nil {
+		orphan(result, "no transfer negotiation exists for task %s", taskID)
+		return nil
+	}
+	result.TransferID = &negotiation.TransferID
+	result.NegotiationID = &negotiation.Id
+	switch negotiation.Status {
+	case transfer.CancelledState, transfer.CompletedState:
+		orphan(result, "the transfer negotiation is %s", negotiation.Status)
+		return nil
+	}
+	if negotiation.CredentialID != nil && *negotiation.CredentialID != "" && *negotiation.CredentialID != result.Id {
+		orphan(result, "the credential was superseded by %s", *negotiation.CredentialID)
+	}
+	return nil
+}
+
+// linkEpisode links the credential to the episode it gives access to. The credential is orphaned when the episode
+// no longer exists or has ended, or when another credential was issued to the collaborator, which superseded it.
+func (s service) linkEpisode(ctx context.Context, customer types.Customer, result *types.IssuedCredential) error {
+	episodeID := resourceID(result.Resources, "EpisodeOfCare")
+	if episodeID == "" {
+		orphan(result, "the credential doesn't give access to an episode")
+		return nil
+	}
+	result.EpisodeID = &episodeID
+	episode, err := zorginzage.NewService(s.factory(fhir.WithTenant(customer.Id))).GetEpisode(ctx, episodeID)
+	if errors.Is(err, fhir.ErrNotFound) {
+		orphan(result, "episode %s doesn't exist", episodeID)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	switch episode.Status {
+	case fhir.EpisodeStatusFinished, fhir.EpisodeStatusCancelled, fhir.EpisodeStatusEnteredInError:
+		orphan(result, "the episode is %s", episode.Status)
+		return nil
+	}
+	credentialID, err := s.collaborations.FindCredentialID(ctx, customer.Id, episodeID, result.OrganizationDID)
+	if err != nil {
+		return err
+	}
+	if credentialID != "" && credentialID != result.Id {
+		orphan(result, "the credential was superseded by %s", credentialID)
+	}
+	return nil
+}
+
+func orphan(result *types.IssuedCredential, format string, args ...interface{}) {
+	reason := fmt.Sprintf(format, args...)
+	result.Orphaned = true
+	result.OrphanReason = &reason
+}
+
+// resourceID returns the ID of the first resource of the type in the paths, or an empty string if there is none.
+func resourceID(paths []string, resourceType string) string {
+	prefix := "/" + resourceType + "/"
+	for _, path := range paths {
+		if strings.HasPrefix(path, prefix) {
+			return strings.TrimPrefix(path, prefix)
+		}
+	}
+	return ""
+}
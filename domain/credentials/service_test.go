@@ -0,0 +1,272 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	ssi "github.com/nuts-foundation/go-did"
+	"github.com/nuts-foundation/go-did/vc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/episode"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer/sender"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	nutsClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+)
+
+const customerDID = "did:nuts:care-home"
+
+// stubVCR contains the credentials issued by the customer
+type stubVCR struct {
+	registry.VerifiableCredentialRegistry
+	credentials []vc.VerifiableCredential
+	revoked     []string
+}
+
+func (s *stubVCR) FindIssuedAuthorizationCredentials(_ context.Context, _ *registry.VCRSearchParams) ([]vc.VerifiableCredential, error) {
+	return s.credentials, nil
+}
+
+func (s *stubVCR) ResolveIssuedCredential(_ context.Context, credentialID string) (*vc.VerifiableCredential, error) {
+	for _, revoked := range s.revoked {
+		if revoked == credentialID {
+			return nil, fmt.Errorf("%w: %s", nutsClient.ErrCredentialRevoked, credentialID)
+		}
+	}
+	for _, authCredential := range s.credentials {
+		if authCredential.ID.String() == credentialID {
+			return &authCredential, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", nutsClient.ErrCredentialNotFound, credentialID)
+}
+
+func (s *stubVCR) RevokeCredential(_ context.Context, credentialID string) error {
+	s.revoked = append(s.revoked, credentialID)
+	return nil
+}
+
+type stubOrganizations struct {
+	registry.OrganizationRegistry
+}
+
+func (stubOrganizations) Get(_ context.Context, organizationDID string) (*types.Organization, error) {
+	if organizationDID != "did:nuts:hospital" {
+		return nil, registry.ErrOrganizationNotFound
+	}
+	return &types.Organization{Did: organizationDID, Name: "Ziekenhuis Amersfoort"}, nil
+}
+
+// stubTransfers contains the negotiations by their task ID
+type stubTransfers struct {
+	sender.TransferRepository
+	negotiations map[string]types.TransferNegotiation
+}
+
+func (s stubTransfers) FindNegotiationByTaskID(_ context.Context, _ int, taskID string) (*types.TransferNegotiation, error) {
+	negotiation, ok := s.negotiations[taskID]
+	if !ok {
+		return nil, nil
+	}
+	return &negotiation, nil
+}
+
+// stubCollaborations contains the IDs of the credentials issued for the episodes by their ID
+type stubCollaborations struct {
+	episode.CollaborationRepository
+	credentialIDs map[string]string
+}
+
+func (s stubCollaborations) FindCredentialID(_ context.Context, _ int, episodeID, _ string) (string, error) {
+	return s.credentialIDs[episodeID], nil
+}
+
+// stubFHIR contains the statuses of the episodes by their ID
+type stubFHIR struct {
+	fhir.Client
+	episodes map[string]string
+}
+
+func (s stubFHIR) ReadOne(_ context.Context, path string, result interface{}) error {
+	status, ok := s.episodes[strings.TrimPrefix(path, "EpisodeOfCare/")]
+	if !ok {
+		return fmt.Errorf("unable to read FHIR resource (path=%s): %w", path, fhir.ErrNotFound)
+	}
+	data, _ := json.Marshal(map[string]string{"resourceType": "EpisodeOfCare", "status": status})
+	return json.Unmarshal(data, result)
+}
+
+func authorizationCredential(t *testing.T, id, purposeOfUse, path string) vc.VerifiableCredential {
+	data, _ := json.Marshal(map[string]interface{}{
+		"@context":     []string{"https://www.w3.org/2018/credentials/v1"},
+		"id":           id,
+		"type":         []string{"VerifiableCredential", "NutsAuthorizationCredential"},
+		"issuer":       customerDID,
+		"issuanceDate": "2022-01-10T12:00:00Z",
+		"credentialSubject": map[string]interface{}{
+			"id":           "did:nuts:hospital",
+			"purposeOfUse": purposeOfUse,
+			"resources":    []map[string]interface{}{{"path": path, "operations": []string{"read"}}},
+		},
+	})
+	result := vc.VerifiableCredential{}
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestService_List(t *testing.T) {
+	ctx := context.Background()
+	did := customerDID
+	customer := types.Customer{Id: 1, Did: &did}
+	reissuedID := did + "#2"
+	transfers := stubTransfers{negotiations: map[string]types.TransferNegotiation{
+		"requested": {Id: "n1", TransferID: "t1", TransferNegotiationStatus: types.TransferNegotiationStatus{Status: transfer.RequestedState}},
+		"cancelled": {Id: "n2", TransferID: "t1", TransferNegotiationStatus: types.TransferNegotiationStatus{Status: transfer.CancelledState}},
+		"reissued":  {Id: "n3", TransferID: "t2", CredentialID: &reissuedID, TransferNegotiationStatus: types.TransferNegotiationStatus{Status: transfer.RequestedState}},
+	}}
+	collaborations := stubCollaborations{credentialIDs: map[string]string{"active": did + "#1", "reissued": did + "#2"}}
+	factory := func(...fhir.ClientOpt) fhir.Client {
+		return stubFHIR{episodes: map[string]string{"active": "active", "finished": "finished", "reissued": "active"}}
+	}
+	list := func(t *testing.T, credential vc.VerifiableCredential) types.IssuedCredential {
+		service := NewService(&stubVCR{credentials: []vc.VerifiableCredential{credential}}, stubOrganizations{}, transfers, collaborations, factory)
+		results, err := service.List(ctx, customer)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		return results[0]
+	}
+
+	t.Run("linked to a transfer", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", transfer.SenderServiceName, "/Task/requested"))
+
+		assert.Equal(t, did+"#1", result.Id)
+		assert.Equal(t, "did:nuts:hospital", result.OrganizationDID)
+		assert.Equal(t, "Ziekenhuis Amersfoort", *result.OrganizationName)
+		assert.Equal(t, []string{"/Task/requested"}, result.Resources)
+		assert.Equal(t, types.IssuedCredentialStatusActive, result.Status)
+		assert.Equal(t, types.ObjectID("t1"), *result.TransferID)
+		assert.Equal(t, types.ObjectID("n1"), *result.NegotiationID)
+		assert.False(t, result.Orphaned)
+	})
+	t.Run("orphaned by a cancelled negotiation", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", transfer.SenderServiceName, "/Task/cancelled"))
+
+		assert.Equal(t, types.ObjectID("t1"), *result.TransferID)
+		assert.True(t, result.Orphaned)
+		assert.Equal(t, "the transfer negotiation is cancelled", *result.OrphanReason)
+	})
+	t.Run("orphaned by a re-issued transfer credential", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", transfer.SenderServiceName, "/Task/reissued"))
+
+		assert.Equal(t, types.ObjectID("t2"), *result.TransferID)
+		assert.True(t, result.Orphaned)
+		assert.Equal(t, "the credential was superseded by "+did+"#2", *result.OrphanReason)
+	})
+	t.Run("orphaned by an unknown task", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", transfer.SenderServiceName, "/Task/unknown"))
+
+		assert.Nil(t, result.TransferID)
+		assert.True(t, result.Orphaned)
+	})
+	t.Run("linked to an episode", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", zorginzage.ServiceName, "/EpisodeOfCare/active"))
+
+		assert.Equal(t, "active", *result.EpisodeID)
+		assert.False(t, result.Orphaned)
+	})
+	t.Run("orphaned by a finished episode", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", zorginzage.ServiceName, "/EpisodeOfCare/finished"))
+
+		assert.True(t, result.Orphaned)
+		assert.Equal(t, "the episode is finished", *result.OrphanReason)
+	})
+	t.Run("orphaned by a re-issued episode credential", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", zorginzage.ServiceName, "/EpisodeOfCare/reissued"))
+
+		assert.True(t, result.Orphaned)
+		assert.Equal(t, "the credential was superseded by "+did+"#2", *result.OrphanReason)
+	})
+	t.Run("orphaned by a deleted episode", func(t *testing.T) {
+		result := list(t, authorizationCredential(t, did+"#1", zorginzage.ServiceName, "/EpisodeOfCare/deleted"))
+
+		assert.True(t, result.Orphaned)
+		assert.Equal(t, "episode deleted doesn't exist", *result.OrphanReason)
+	})
+	t.Run("customer without DID", func(t *testing.T) {
+		results, err := NewService(&stubVCR{}, stubOrganizations{}, transfers, collaborations, factory).List(ctx, types.Customer{Id: 1})
+
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestService_Revoke(t *testing.T) {
+	ctx := context.Background()
+	did := customerDID
+	customer := types.Customer{Id: 1, Did: &did}
+	otherIssuer := authorizationCredential(t, "did:nuts:other#1", transfer.SenderServiceName, "/Task/unknown")
+	otherIssuer.Issuer = ssi.MustParseURI("did:nuts:other")
+	expired := authorizationCredential(t, did+"#expired", transfer.SenderServiceName, "/Task/unknown")
+	expirationDate := time.Now().Add(-time.Hour)
+	expired.ExpirationDate = &expirationDate
+	newService := func() (*stubVCR, Service) {
+		vcr := &stubVCR{credentials: []vc.VerifiableCredential{
+			authorizationCredential(t, did+"#1", transfer.SenderServiceName, "/Task/unknown"),
+			otherIssuer,
+			expired,
+		}}
+		return vcr, NewService(vcr, stubOrganizations{}, stubTransfers{}, stubCollaborations{}, nil)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		vcr, service := newService()
+
+		result, err := service.Revoke(ctx, customer, did+"#1")
+
+		require.NoError(t, err)
+		assert.Equal(t, types.IssuedCredentialStatusRevoked, result.Status)
+		assert.True(t, result.Orphaned, "it's linked to its transfer like in the overview")
+		assert.Equal(t, []string{did + "#1"}, vcr.revoked)
+	})
+	t.Run("already revoked", func(t *testing.T) {
+		_, service := newService()
+		_, _ = service.Revoke(ctx, customer, did+"#1")
+
+		_, err := service.Revoke(ctx, customer, did+"#1")
+
+		assert.ErrorIs(t, err, ErrCredentialNotFound)
+	})
+	t.Run("unknown credential", func(t *testing.T) {
+		vcr, service := newService()
+
+		_, err := service.Revoke(ctx, customer, did+"#unknown")
+
+		assert.ErrorIs(t, err, ErrCredentialNotFound)
+		assert.Empty(t, vcr.revoked)
+	})
+	t.Run("not issued by the customer", func(t *testing.T) {
+		vcr, service := newService()
+
+		_, err := service.Revoke(ctx, customer, "did:nuts:other#1")
+
+		assert.ErrorIs(t, err, ErrCredentialNotFound)
+		assert.Empty(t, vcr.revoked)
+	})
+	t.Run("expired", func(t *testing.T) {
+		vcr, service := newService()
+
+		_, err := service.Revoke(ctx, customer, did+"#expired")
+
+		assert.ErrorIs(t, err, ErrCredentialNotFound)
+		assert.Empty(t, vcr.revoked)
+	})
+}
@@ -1,122 +1,55 @@
 package customers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"sort"
-	"sync"
 
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
 )
 
-type Repository interface {
-	FindByID(id int) (*types.Customer, error)
-	FindByDID(did string) (*types.Customer, error)
-	All() ([]types.Customer, error)
-}
-
-type jsonFileRepo struct {
-	filepath string
-	mutex    sync.Mutex
-	// records is a cache
-	records map[string]types.Customer
-}
-
-func NewJsonFileRepository(filepath string) *jsonFileRepo {
-	f, err := os.OpenFile(filepath, os.O_RDONLY, 0666)
-	defer f.Close()
-	if err != nil {
-		panic(err)
-	}
-
-	repo := jsonFileRepo{
-		filepath: filepath,
-		mutex:    sync.Mutex{},
-		records:  make(map[string]types.Customer, 0),
-	}
-
-	if err := repo.readAll(); err != nil {
-		panic(err)
-	}
-
-	return &repo
-}
-
-func (db *jsonFileRepo) FindByID(id int) (*types.Customer, error) {
-	if len(db.records) == 0 {
-		if err := db.readAll(); err != nil {
-			return nil, err
-		}
-	}
+// ErrCustomerNotFound is returned when updating or deactivating a customer that doesn't exist.
+var ErrCustomerNotFound = errors.New("customer not found")
 
-	for _, r := range db.records {
-		if r.Id == id {
-			// Hazardous to return a pointer, but this is a demo.
-			return &r, nil
-		}
-	}
+// ErrDIDTaken is returned when a customer is given the DID of another customer.
+var ErrDIDTaken = errors.New("DID already belongs to another customer")
 
-	// Not found
-	return nil, nil
-}
-
-func (db *jsonFileRepo) FindByDID(did string) (*types.Customer, error) {
-	if len(db.records) == 0 {
-		if err := db.readAll(); err != nil {
-			return nil, err
-		}
-	}
-
-	for _, r := range db.records {
-		if r.Did != nil && *r.Did == did {
-			// Hazardous to return a pointer, but this is a demo.
-			return &r, nil
-		}
-	}
-
-	// Not found
-	return nil, nil
+type Repository interface {
+	// FindByID returns the customer with the given ID, or nil if it doesn't exist. Deactivated customers are returned too.
+	FindByID(ctx context.Context, id int) (*types.Customer, error)
+	// FindByDID returns the customer with the given DID, or nil if it doesn't exist.
+	FindByDID(ctx context.Context, did string) (*types.Customer, error)
+	// All returns all customers ordered by name, including deactivated customers.
+	All(ctx context.Context) ([]types.Customer, error)
+	// Create stores a new, active customer and returns it with its assigned ID.
+	Create(ctx context.Context, properties types.CustomerProperties) (*types.Customer, error)
+	// Update replaces the properties of the customer.
+	Update(ctx context.Context, id int, properties types.CustomerProperties) (*types.Customer, error)
+	// Deactivate marks the customer as inactive, after which its users can't log in. The customer isn't removed.
+	Deactivate(ctx context.Context, id int) error
 }
 
-func (db *jsonFileRepo) readAll() error {
-	//log.Debug("Reading full customer list from file")
-	bytes, err := os.ReadFile(db.filepath)
+// ReadJSONFile reads the customers from a customers.json file, which maps the customer IDs to the customers.
+// It's used to import the customers of the previous, file based, repository.
+func ReadJSONFile(path string) ([]types.Customer, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("unable to read db from file: %w", err)
+		return nil, fmt.Errorf("unable to read customers from file: %w", err)
 	}
-
-	if len(bytes) == 0 {
-		return nil
+	if len(data) == 0 {
+		return nil, nil
 	}
 
-	v := map[string]types.Customer{}
-	if err = json.Unmarshal(bytes, &v); err != nil {
-		return fmt.Errorf("unable to unmarshall db from file: %w", err)
+	records := map[string]types.Customer{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal customers from file: %w", err)
 	}
 
-	db.records = v
-
-	return nil
-}
-
-func (db *jsonFileRepo) All() ([]types.Customer, error) {
-	db.mutex.Lock()
-	defer db.mutex.Unlock()
-
-	if err := db.readAll(); err != nil {
-		return nil, err
-	}
-
-	v := make([]types.Customer, len(db.records))
-
-	idx := 0
-	for _, value := range db.records {
-		v[idx] = value
-		idx = idx + 1
+	result := make([]types.Customer, 0, len(records))
+	for _, customer := range records {
+		result = append(result, customer)
 	}
-	sort.SliceStable(v, func(i, j int) bool {
-		return v[i].Name < v[j].Name
-	})
-	return v, nil
+	return result, nil
 }
@@ -0,0 +1,292 @@
+package customers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+type sqlCustomer struct {
+	ID     int            `db:"id"`
+	DID    sql.NullString `db:"did"`
+	Name   string         `db:"name"`
+	City   sql.NullString `db:"city"`
+	Domain sql.NullString `db:"domain"`
+	Active bool           `db:"active"`
+	// SigningMeans is a JSON array, NULL if all signing means are allowed
+	SigningMeans sql.NullString `db:"signing_means"`
+	Logo         sql.NullString `db:"logo"`
+}
+
+func (dbCustomer sqlCustomer) MarshalToDomainCustomer() (*types.Customer, error) {
+	customer := types.Customer{
+		Id:     dbCustomer.ID,
+		Name:   dbCustomer.Name,
+		Did:    fromNullString(dbCustomer.DID),
+		City:   fromNullString(dbCustomer.City),
+		Domain: fromNullString(dbCustomer.Domain),
+		Active: dbCustomer.Active,
+	}
+	if dbCustomer.SigningMeans.Valid {
+		signingMeans := []string{}
+		if err := json.Unmarshal([]byte(dbCustomer.SigningMeans.String), &signingMeans); err != nil {
+			return nil, err
+		}
+		customer.SigningMeans = &signingMeans
+	}
+	if dbCustomer.Logo.Valid {
+		customer.Branding = &types.CustomerBranding{Logo: fromNullString(dbCustomer.Logo)}
+	}
+	return &customer, nil
+}
+
+func (dbCustomer *sqlCustomer) setProperties(properties types.CustomerProperties) error {
+	dbCustomer.Name = properties.Name
+	dbCustomer.DID = toNullString(properties.Did)
+	dbCustomer.City = toNullString(properties.City)
+	dbCustomer.Domain = toNullString(properties.Domain)
+	dbCustomer.SigningMeans = sql.NullString{}
+	if properties.SigningMeans != nil {
+		data, err := json.Marshal(*properties.SigningMeans)
+		if err != nil {
+			return err
+		}
+		dbCustomer.SigningMeans = sql.NullString{String: string(data), Valid: true}
+	}
+	dbCustomer.Logo = sql.NullString{}
+	if properties.Branding != nil {
+		dbCustomer.Logo = toNullString(properties.Branding.Logo)
+	}
+	return nil
+}
+
+func fromNullString(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func toNullString(value *string) sql.NullString {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+const schema = `
+	CREATE TABLE IF NOT EXISTS customer (
+		id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+		did varchar(200) NULL UNIQUE,
+		name varchar(200) NOT NULL,
+		city varchar(200) NULL,
+		domain varchar(200) NULL,
+		active boolean NOT NULL,
+		signing_means varchar(200) NULL,
+		logo varchar(1000) NULL
+	);
+`
+
+type SQLiteCustomerRepository struct{}
+
+func NewSQLiteCustomerRepository(db *sqlx.DB) *SQLiteCustomerRepository {
+	if db == nil {
+		panic("missing db for CustomerRepository")
+	}
+	tx, _ := db.Beginx()
+	tx.MustExec(schema)
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	return &SQLiteCustomerRepository{}
+}
+
+func (r SQLiteCustomerRepository) FindByID(ctx context.Context, id int) (*types.Customer, error) {
+	return r.find(ctx, `SELECT * FROM customer WHERE id = ?`, id)
+}
+
+func (r SQLiteCustomerRepository) FindByDID(ctx context.Context, did string) (*types.Customer, error) {
+	return r.find(ctx, `SELECT * FROM customer WHERE did = ?`, did)
+}
+
+func (r SQLiteCustomerRepository) All(ctx context.Context) ([]types.Customer, error) {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dbCustomers := []sqlCustomer{}
+	if err := tx.SelectContext(ctx, &dbCustomers, `SELECT * FROM customer ORDER BY name ASC`); err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Customer, len(dbCustomers))
+	for idx, dbCustomer := range dbCustomers {
+		customer, err := dbCustomer.MarshalToDomainCustomer()
+		if err != nil {
+			return nil, err
+		}
+		result[idx] = *customer
+	}
+	return result, nil
+}
+
+func (r SQLiteCustomerRepository) Create(ctx context.Context, properties types.CustomerProperties) (*types.Customer, error) {
+	dbCustomer := sqlCustomer{Active: true}
+	if err := dbCustomer.setProperties(properties); err != nil {
+		return nil, err
+	}
+	if err := r.checkDID(ctx, 0, dbCustomer.DID); err != nil {
+		return nil, err
+	}
+
+	const query = `INSERT INTO customer
+		(did, name, city, domain, active, signing_means, logo)
+		VALUES (:did, :name, :city, :domain, :active, :signing_means, :logo)`
+
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := tx.NamedExecContext(ctx, query, dbCustomer)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	dbCustomer.ID = int(id)
+
+	return dbCustomer.MarshalToDomainCustomer()
+}
+
+func (r SQLiteCustomerRepository) Update(ctx context.Context, id int, properties types.CustomerProperties) (*types.Customer, error) {
+	dbCustomer, err := r.findSQL(ctx, `SELECT * FROM customer WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if dbCustomer == nil {
+		return nil, ErrCustomerNotFound
+	}
+	if err := dbCustomer.setProperties(properties); err != nil {
+		return nil, err
+	}
+	if err := r.checkDID(ctx, id, dbCustomer.DID); err != nil {
+		return nil, err
+	}
+
+	const query = `UPDATE customer SET did = :did, name = :name, city = :city, domain = :domain,
+		signing_means = :signing_means, logo = :logo WHERE id = :id`
+
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.NamedExecContext(ctx, query, dbCustomer); err != nil {
+		return nil, err
+	}
+
+	return dbCustomer.MarshalToDomainCustomer()
+}
+
+func (r SQLiteCustomerRepository) Deactivate(ctx context.Context, id int) error {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `UPDATE customer SET active = false WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return ErrCustomerNotFound
+	}
+	return nil
+}
+
+// Import stores the given customers with their IDs, if there are no customers yet. It returns the number of imported
+// customers. The customers are imported as active, since the file based repository didn't support deactivation.
+func (r SQLiteCustomerRepository) Import(ctx context.Context, customers []types.Customer) (int, error) {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM customer`); err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, nil
+	}
+
+	const query = `INSERT INTO customer
+		(id, did, name, city, domain, active, signing_means, logo)
+		VALUES (:id, :did, :name, :city, :domain, :active, :signing_means, :logo)`
+
+	for _, customer := range customers {
+		dbCustomer := sqlCustomer{ID: customer.Id, Active: true}
+		err := dbCustomer.setProperties(types.CustomerProperties{
+			Did:          customer.Did,
+			Name:         customer.Name,
+			City:         customer.City,
+			Domain:       customer.Domain,
+			SigningMeans: customer.SigningMeans,
+			Branding:     customer.Branding,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.NamedExecContext(ctx, query, dbCustomer); err != nil {
+			return 0, err
+		}
+	}
+	return len(customers), nil
+}
+
+// checkDID returns ErrDIDTaken if another customer than the one with the given ID has the DID.
+func (r SQLiteCustomerRepository) checkDID(ctx context.Context, id int, did sql.NullString) error {
+	if !did.Valid {
+		return nil
+	}
+	existing, err := r.findSQL(ctx, `SELECT * FROM customer WHERE did = ? AND id != ?`, did.String, id)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrDIDTaken
+	}
+	return nil
+}
+
+func (r SQLiteCustomerRepository) find(ctx context.Context, query string, args ...interface{}) (*types.Customer, error) {
+	dbCustomer, err := r.findSQL(ctx, query, args...)
+	if err != nil || dbCustomer == nil {
+		return nil, err
+	}
+	return dbCustomer.MarshalToDomainCustomer()
+}
+
+func (r SQLiteCustomerRepository) findSQL(ctx context.Context, query string, args ...interface{}) (*sqlCustomer, error) {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dbCustomer := sqlCustomer{}
+	err = tx.GetContext(ctx, &dbCustomer, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &dbCustomer, nil
+}
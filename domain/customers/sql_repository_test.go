@@ -0,0 +1,149 @@
+package customers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteCustomerRepository(t *testing.T) {
+	newRepo := func() (*sqlx.DB, *SQLiteCustomerRepository) {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		// every connection to :memory: has its own database
+		db.SetMaxOpenConns(1)
+		return db, NewSQLiteCustomerRepository(db)
+	}
+	did := "did:nuts:care-home"
+	properties := types.CustomerProperties{
+		Did:          &did,
+		Name:         "Care Home",
+		SigningMeans: &[]string{"irma"},
+		Branding:     &types.CustomerBranding{Logo: stringPtr("https://example.com/logo.png")},
+	}
+
+	t.Run("create and find", func(t *testing.T) {
+		db, repo := newRepo()
+
+		err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			created, err := repo.Create(ctx, properties)
+			if !assert.NoError(t, err) {
+				return err
+			}
+			assert.Equal(t, 1, created.Id)
+			assert.True(t, created.Active)
+
+			customer, err := repo.FindByID(ctx, created.Id)
+			assert.NoError(t, err)
+			assert.Equal(t, created, customer)
+
+			customer, err = repo.FindByDID(ctx, did)
+			assert.NoError(t, err)
+			assert.Equal(t, created, customer)
+
+			customer, err = repo.FindByID(ctx, 2)
+			assert.NoError(t, err)
+			assert.Nil(t, customer)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+	t.Run("DID is unique", func(t *testing.T) {
+		db, repo := newRepo()
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			_, _ = repo.Create(ctx, properties)
+			_, err := repo.Create(ctx, types.CustomerProperties{Name: "Hospital", Did: &did})
+			assert.ErrorIs(t, err, ErrDIDTaken)
+
+			other, _ := repo.Create(ctx, types.CustomerProperties{Name: "Hospital"})
+			_, err = repo.Update(ctx, other.Id, types.CustomerProperties{Name: "Hospital", Did: &did})
+			assert.ErrorIs(t, err, ErrDIDTaken)
+			return nil
+		})
+	})
+	t.Run("update", func(t *testing.T) {
+		db, repo := newRepo()
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			created, _ := repo.Create(ctx, properties)
+			updated, err := repo.Update(ctx, created.Id, types.CustomerProperties{Name: "Care Home Utrecht", Did: &did, City: stringPtr("Utrecht")})
+			if !assert.NoError(t, err) {
+				return err
+			}
+
+			customer, _ := repo.FindByID(ctx, created.Id)
+			assert.Equal(t, updated, customer)
+			assert.Equal(t, "Care Home Utrecht", customer.Name)
+			assert.Equal(t, "Utrecht", *customer.City)
+			// not restricted anymore
+			assert.Nil(t, customer.SigningMeans)
+			assert.Nil(t, customer.Branding)
+
+			_, err = repo.Update(ctx, 2, properties)
+			assert.ErrorIs(t, err, ErrCustomerNotFound)
+			return nil
+		})
+	})
+	t.Run("deactivate", func(t *testing.T) {
+		db, repo := newRepo()
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			created, _ := repo.Create(ctx, properties)
+			assert.NoError(t, repo.Deactivate(ctx, created.Id))
+
+			customer, _ := repo.FindByID(ctx, created.Id)
+			assert.False(t, customer.Active)
+			all, _ := repo.All(ctx)
+			assert.Len(t, all, 1)
+
+			assert.ErrorIs(t, repo.Deactivate(ctx, 2), ErrCustomerNotFound)
+			return nil
+		})
+	})
+	t.Run("import from JSON file", func(t *testing.T) {
+		db, repo := newRepo()
+		file := filepath.Join(t.TempDir(), "customers.json")
+		_ = os.WriteFile(file, []byte(`{
+			"1": {"id": 1, "name": "Care Home", "did": "did:nuts:care-home"},
+			"5": {"id": 5, "name": "Hospital", "signingMeans": ["irma"]}
+		}`), 0600)
+		records, err := ReadJSONFile(file)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			count, err := repo.Import(ctx, records)
+			assert.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			customer, _ := repo.FindByID(ctx, 5)
+			if assert.NotNil(t, customer) {
+				assert.Equal(t, "Hospital", customer.Name)
+				assert.True(t, customer.Active)
+				assert.Equal(t, []string{"irma"}, *customer.SigningMeans)
+			}
+			// IDs of new customers follow the imported ones
+			created, _ := repo.Create(ctx, types.CustomerProperties{Name: "GP"})
+			assert.Equal(t, 6, created.Id)
+
+			// only imported once
+			count, err = repo.Import(ctx, records)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, count)
+			return nil
+		})
+	})
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
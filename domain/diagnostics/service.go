@@ -0,0 +1,172 @@
+package diagnostics
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/onboarding"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+)
+
+// reachabilityTimeout is the maximum time an endpoint may take to respond to the reachability check.
+const reachabilityTimeout = 5 * time.Second
+
+type Service interface {
+	// Check checks the registration of the customer on the Nuts network, so misconfigurations are found before
+	// exchanging data with other care organizations fails.
+	Check(ctx context.Context, customer types.Customer) types.Diagnostics
+}
+
+type service struct {
+	vdr    client.VDRClient
+	didman client.DIDManClient
+	vcr    client.VCRClient
+	// endpoints are the URLs of this EHR the services must point to, empty if they're unknown
+	endpoints  onboarding.Endpoints
+	httpClient *http.Client
+}
+
+// NewService creates the diagnostics. The TLS config is used to check the reachability of the endpoints, like other
+// care organizations call them. It may be nil.
+func NewService(vdr client.VDRClient, didman client.DIDManClient, vcr client.VCRClient, endpoints onboarding.Endpoints, tlsConfig *tls.Config) Service {
+	return &service{
+		vdr:       vdr,
+		didman:    didman,
+		vcr:       vcr,
+		endpoints: endpoints,
+		httpClient: &http.Client{
+			Timeout:   reachabilityTimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+}
+
+type report struct {
+	types.Diagnostics
+}
+
+func (r *report) pass(name string) {
+	r.Checks = append(r.Checks, types.DiagnosticCheck{Name: name, Ok: true})
+}
+
+func (r *report) fail(name string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	r.Checks = append(r.Checks, types.DiagnosticCheck{Name: name, Message: &message})
+	r.Ok = false
+}
+
+func (s service) Check(ctx context.Context, customer types.Customer) types.Diagnostics {
+	r := &report{types.Diagnostics{Ok: true, Checks: []types.DiagnosticCheck{}}}
+	if customer.Did == nil {
+		r.fail("did", "The customer doesn't have a DID. Onboard the customer or set the DID of its registration on the Nuts network.")
+		return r.Diagnostics
+	}
+	did := *customer.Did
+
+	resolved, err := s.vdr.ResolveDID(ctx, did)
+	if err != nil {
+		r.fail("did", "Unable to resolve the DID document (did=%s): %s. Check whether the DID is known to the Nuts node.", did, err)
+		return r.Diagnostics
+	}
+	if resolved.DocumentMetadata.Deactivated {
+		r.fail("did", "The DID document is deactivated (did=%s). Onboard the customer with a new DID.", did)
+		return r.Diagnostics
+	}
+	r.pass("did")
+
+	s.checkServices(ctx, r, did)
+	s.checkOrganizationCredential(ctx, r, did)
+	return r.Diagnostics
+}
+
+func (s service) checkServices(ctx context.Context, r *report, did string) {
+	registered, err := s.didman.GetCompoundServices(ctx, did)
+	if err != nil {
+		r.fail("services", "Unable to get the services of the DID document: %s.", err)
+		return
+	}
+	serviceTypes := map[string]bool{}
+	for _, service := range registered {
+		serviceTypes[service.Type] = true
+	}
+
+	for _, service := range s.endpoints.Services() {
+		if !serviceTypes[service.Type] {
+			r.fail(service.Type, "The service isn't registered. Onboard the customer again to register it.")
+			continue
+		}
+		r.pass(service.Type)
+
+		// the oauth endpoint is a reference to the service of the Nuts node, so it doesn't point to this EHR
+		expected := map[string]string{"oauth": ""}
+		for name, url := range service.Endpoints {
+			expected[name] = url
+		}
+		names := make([]string, 0, len(expected))
+		for name := range expected {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s.checkEndpoint(ctx, r, did, service.Type, name, expected[name])
+		}
+	}
+}
+
+func (s service) checkEndpoint(ctx context.Context, r *report, did, serviceType, name, expected string) {
+	check := serviceType + "/" + name
+	url, err := s.didman.GetCompoundServiceEndpoint(ctx, did, serviceType, name)
+	if err != nil {
+		r.fail(check, "Unable to resolve the endpoint: %s. Add the %s endpoint to the service.", err, name)
+		return
+	}
+	if expected != "" && url != expected {
+		r.fail(check, "The endpoint points to %s instead of this EHR (%s). Update the service.", url, expected)
+		return
+	}
+	if err := s.reachable(ctx, url); err != nil {
+		r.fail(check, "The endpoint (%s) isn't reachable: %s. Check the public URL and the firewall.", url, err)
+		return
+	}
+	r.pass(check)
+}
+
+// reachable returns an error if the URL doesn't respond. Every response counts, since the endpoints require an access token.
+func (s service) reachable(ctx context.Context, url string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func (s service) checkOrganizationCredential(ctx context.Context, r *report, did string) {
+	const check = "organizationCredential"
+	organizations, err := s.vcr.GetOrganization(ctx, did)
+	if err != nil {
+		r.fail(check, "Unable to search the organization credential: %s.", err)
+		return
+	}
+	if len(organizations) > 0 {
+		r.pass(check)
+		return
+	}
+
+	organizations, err = s.vcr.GetUntrustedOrganization(ctx, did)
+	if err != nil {
+		r.fail(check, "Unable to search the organization credential: %s.", err)
+	} else if len(organizations) > 0 {
+		r.fail(check, "The organization credential is issued by an untrusted issuer. Trust the issuer on the Nuts node.")
+	} else {
+		r.fail(check, "The customer doesn't have an organization credential. Onboard the customer or issue a NutsOrganizationCredential.")
+	}
+}
@@ -0,0 +1,179 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/onboarding"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/didman"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vdr"
+)
+
+// stubNode is the registration of a single DID on the Nuts node
+type stubNode struct {
+	client.VDRClient
+	client.DIDManClient
+	client.VCRClient
+	deactivated bool
+	// endpoints by service type and endpoint name
+	endpoints     map[string]map[string]string
+	trusted       bool
+	untrustedOnly bool
+}
+
+func (s *stubNode) ResolveDID(_ context.Context, did string) (*vdr.DIDResolutionResult, error) {
+	if did != "did:nuts:care-home" {
+		return nil, errors.New("not found")
+	}
+	return &vdr.DIDResolutionResult{
+		Document:         vdr.DIDDocument{Id: did},
+		DocumentMetadata: vdr.DIDDocumentMetadata{Deactivated: s.deactivated},
+	}, nil
+}
+
+func (s *stubNode) GetCompoundServices(_ context.Context, _ string) ([]didman.CompoundService, error) {
+	result := []didman.CompoundService{}
+	for serviceType := range s.endpoints {
+		result = append(result, didman.CompoundService{Type: serviceType})
+	}
+	return result, nil
+}
+
+func (s *stubNode) GetCompoundServiceEndpoint(_ context.Context, _, serviceType string, field string) (string, error) {
+	if url, ok := s.endpoints[serviceType][field]; ok {
+		return url, nil
+	}
+	return "", errors.New("not found")
+}
+
+func (s *stubNode) GetOrganization(_ context.Context, _ string) ([]map[string]interface{}, error) {
+	if s.trusted {
+		return []map[string]interface{}{{}}, nil
+	}
+	return nil, nil
+}
+
+func (s *stubNode) GetUntrustedOrganization(_ context.Context, _ string) ([]map[string]interface{}, error) {
+	if s.trusted || s.untrustedOnly {
+		return []map[string]interface{}{{}}, nil
+	}
+	return nil, nil
+}
+
+func TestService_Check(t *testing.T) {
+	ehr := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ehr.Close()
+	endpoints := onboarding.Endpoints{
+		FHIR:                 ehr.URL + "/fhir",
+		TransferNotification: ehr.URL + "/web/external/transfer/notify",
+		EpisodeNotification:  ehr.URL + "/web/external/episode/notify",
+	}
+	oauth := ehr.URL + "/n2n/auth/v1/accesstoken"
+	newNode := func() *stubNode {
+		return &stubNode{
+			trusted: true,
+			endpoints: map[string]map[string]string{
+				"eOverdracht-sender":   {"oauth": oauth, "fhir": endpoints.FHIR},
+				"eOverdracht-receiver": {"oauth": oauth, "notification": endpoints.TransferNotification},
+				"zorginzage-demo":      {"oauth": oauth, "fhir": endpoints.FHIR, "notification": endpoints.EpisodeNotification},
+			},
+		}
+	}
+	did := "did:nuts:care-home"
+	customer := types.Customer{Id: 1, Name: "Care Home", Did: &did}
+	failed := func(diagnostics types.Diagnostics) map[string]string {
+		result := map[string]string{}
+		for _, check := range diagnostics.Checks {
+			if !check.Ok {
+				result[check.Name] = *check.Message
+			}
+		}
+		return result
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		node := newNode()
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), customer)
+
+		assert.True(t, result.Ok)
+		assert.Empty(t, failed(result))
+		// did, 3 services with 7 endpoints and the organization credential
+		assert.Len(t, result.Checks, 12)
+	})
+	t.Run("no DID", func(t *testing.T) {
+		node := newNode()
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), types.Customer{Id: 1})
+
+		assert.False(t, result.Ok)
+		assert.Contains(t, failed(result)["did"], "doesn't have a DID")
+	})
+	t.Run("unknown DID", func(t *testing.T) {
+		node := newNode()
+		other := "did:nuts:other"
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), types.Customer{Id: 1, Did: &other})
+
+		assert.Contains(t, failed(result)["did"], "Unable to resolve the DID document")
+	})
+	t.Run("deactivated DID", func(t *testing.T) {
+		node := newNode()
+		node.deactivated = true
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), customer)
+
+		assert.Contains(t, failed(result)["did"], "deactivated")
+	})
+	t.Run("service issues", func(t *testing.T) {
+		node := newNode()
+		delete(node.endpoints, "zorginzage-demo")
+		node.endpoints["eOverdracht-sender"]["fhir"] = "https://other.example.com/fhir"
+		delete(node.endpoints["eOverdracht-receiver"], "notification")
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), customer)
+
+		assert.False(t, result.Ok)
+		issues := failed(result)
+		assert.Len(t, issues, 3)
+		assert.Contains(t, issues["zorginzage-demo"], "isn't registered")
+		assert.Contains(t, issues["eOverdracht-sender/fhir"], "points to https://other.example.com/fhir instead of this EHR")
+		assert.Contains(t, issues["eOverdracht-receiver/notification"], "Unable to resolve the endpoint")
+	})
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		node := newNode()
+		unreachable := httptest.NewServer(http.NotFoundHandler())
+		unreachable.Close()
+		node.endpoints["eOverdracht-sender"]["oauth"] = unreachable.URL
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), customer)
+
+		assert.Contains(t, failed(result)["eOverdracht-sender/oauth"], "isn't reachable")
+	})
+	t.Run("untrusted organization credential", func(t *testing.T) {
+		node := newNode()
+		node.trusted = false
+		node.untrustedOnly = true
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), customer)
+
+		assert.Contains(t, failed(result)["organizationCredential"], "untrusted issuer")
+	})
+	t.Run("no organization credential", func(t *testing.T) {
+		node := newNode()
+		node.trusted = false
+
+		result := NewService(node, node, node, endpoints, nil).Check(context.Background(), customer)
+
+		assert.Contains(t, failed(result)["organizationCredential"], "doesn't have an organization credential")
+	})
+}
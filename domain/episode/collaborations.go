@@ -0,0 +1,84 @@
+package episode
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+// CollaborationRepository keeps the IDs of the authorization credentials customers issued to the organizations
+// collaborating in their episodes, so they can be revoked without searching for them.
+type CollaborationRepository interface {
+	// FindCredentialID returns the ID of the credential issued to the organization for the customer's episode,
+	// or an empty string if none was stored.
+	FindCredentialID(ctx context.Context, customerID int, episodeID, organizationDID string) (string, error)
+	// SaveCredentialID stores the ID of the credential issued to the organization for the customer's episode,
+	// replacing the ID of an earlier credential.
+	SaveCredentialID(ctx context.Context, customerID int, episodeID, organizationDID, credentialID string) error
+}
+
+type sqlCollaboration struct {
+	CustomerID      int    `db:"customer_id"`
+	EpisodeID       string `db:"episode_id"`
+	OrganizationDID string `db:"organization_did"`
+	CredentialID    string `db:"credential_id"`
+}
+
+const collaborationSchema = `
+	CREATE TABLE IF NOT EXISTS collaboration (
+		customer_id integer NOT NULL,
+		episode_id varchar(100) NOT NULL,
+		organization_did varchar(200) NOT NULL,
+		credential_id varchar(300) NOT NULL,
+		PRIMARY KEY (customer_id, episode_id, organization_did)
+	);
+`
+
+type SQLiteCollaborationRepository struct{}
+
+func NewSQLiteCollaborationRepository(db *sqlx.DB) *SQLiteCollaborationRepository {
+	if db == nil {
+		panic("missing db for CollaborationRepository")
+	}
+	tx, _ := db.Beginx()
+	tx.MustExec(collaborationSchema)
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	return &SQLiteCollaborationRepository{}
+}
+
+func (r SQLiteCollaborationRepository) FindCredentialID(ctx context.Context, customerID int, episodeID, organizationDID string) (string, error) {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return "", err
+	}
+	var credentialID string
+	const query = `SELECT credential_id FROM collaboration WHERE customer_id = ? AND episode_id = ? AND organization_did = ?`
+	err = tx.GetContext(ctx, &credentialID, query, customerID, episodeID, organizationDID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return credentialID, err
+}
+
+func (r SQLiteCollaborationRepository) SaveCredentialID(ctx context.Context, customerID int, episodeID, organizationDID, credentialID string) error {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `INSERT OR REPLACE INTO collaboration (customer_id, episode_id, organization_did, credential_id)
+		VALUES (:customer_id, :episode_id, :organization_did, :credential_id)`
+	_, err = tx.NamedExecContext(ctx, query, sqlCollaboration{
+		CustomerID:      customerID,
+		EpisodeID:       episodeID,
+		OrganizationDID: organizationDID,
+		CredentialID:    credentialID,
+	})
+	return err
+}
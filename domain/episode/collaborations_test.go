@@ -0,0 +1,35 @@
+package episode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+func TestSQLiteCollaborationRepository(t *testing.T) {
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	// every connection to :memory: has its own database
+	db.SetMaxOpenConns(1)
+	repo := NewSQLiteCollaborationRepository(db)
+
+	err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+		credentialID, err := repo.FindCredentialID(ctx, 1, "episode", "did:nuts:hospital")
+		assert.NoError(t, err)
+		assert.Empty(t, credentialID, "no credential stored yet")
+
+		assert.NoError(t, repo.SaveCredentialID(ctx, 1, "episode", "did:nuts:hospital", "did:nuts:care-home#1"))
+		assert.NoError(t, repo.SaveCredentialID(ctx, 1, "episode", "did:nuts:hospital", "did:nuts:care-home#2"))
+		assert.NoError(t, repo.SaveCredentialID(ctx, 2, "episode", "did:nuts:hospital", "did:nuts:other#1"))
+
+		credentialID, err = repo.FindCredentialID(ctx, 1, "episode", "did:nuts:hospital")
+		assert.NoError(t, err)
+		assert.Equal(t, "did:nuts:care-home#2", credentialID, "the later credential replaces the earlier one")
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,107 @@
+package episode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+)
+
+// NotificationEndpointType contains the type of the endpoint in the zorginzage compound-service which receives notifications
+// about updated episodes.
+const NotificationEndpointType = "notification"
+
+func reportCacheKey(customerDID, custodianDID, episodeID string) string {
+	return fmt.Sprintf("%s|%s|%s", customerDID, custodianDID, episodeID)
+}
+
+func (service *service) NotifyCollaborators(ctx context.Context, customerDID, episodeID, contributorDID string) error {
+	credentials, err := service.vcr.FindAuthorizationCredentials(
+		ctx,
+		&registry.VCRSearchParams{
+			PurposeOfUse: zorginzage.ServiceName,
+			Issuer:       customerDID,
+			ResourcePath: fmt.Sprintf("/EpisodeOfCare/%s", episodeID),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	notified := map[string]bool{}
+
+	for _, authCredential := range credentials {
+		subject, err := parseAuthCredentialSubject(authCredential)
+		if err != nil {
+			return err
+		}
+
+		// the customer and the contributor don't need to be notified of their own update
+		if subject.ID == customerDID || subject.ID == contributorDID || notified[subject.ID] {
+			continue
+		}
+		notified[subject.ID] = true
+
+		// a collaborator that can't be notified shouldn't keep the others from being notified, it can still poll
+		if err := service.notify(ctx, customerDID, subject.ID, episodeID); err != nil {
+			logrus.Warnf("Unable to notify collaborating care organization of updated episode (did=%s): %s", subject.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (service *service) notify(ctx context.Context, customerDID, collaboratorDID, episodeID string) error {
+	notificationEndpoint, err := service.registry.GetCompoundServiceEndpoint(ctx, collaboratorDID, zorginzage.ServiceName, NotificationEndpointType)
+	if err != nil {
+		return err
+	}
+
+	tokenResponse, err := service.auth.RequestAccessToken(ctx, customerDID, collaboratorDID, zorginzage.ServiceName, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	endpoint := notificationEndpoint
+
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+
+	endpoint += episodeID
+
+	return service.notifier.Notify(tokenResponse.AccessToken, endpoint)
+}
+
+func (service *service) RefreshReports(ctx context.Context, customerDID, custodianDID, episodeID string) error {
+	credentials, err := service.vcr.FindAuthorizationCredentials(
+		ctx,
+		&registry.VCRSearchParams{
+			PurposeOfUse: zorginzage.ServiceName,
+			Issuer:       custodianDID,
+			SubjectID:    customerDID,
+			ResourcePath: fmt.Sprintf("/EpisodeOfCare/%s", episodeID),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(credentials) == 0 {
+		return errors.New("no NutsAuthorizationCredential found to retrieve the episode")
+	}
+
+	results, err := service.fetchReports(ctx, customerDID, custodianDID, episodeID, credentials[0])
+	if err != nil {
+		return err
+	}
+
+	service.reports.Set(reportCacheKey(customerDID, custodianDID, episodeID), results)
+
+	return nil
+}
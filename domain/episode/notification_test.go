@@ -0,0 +1,136 @@
+package episode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nuts-foundation/go-did/vc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/http/auth"
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+)
+
+const customerDID = "did:nuts:hospital"
+
+type stubVCR struct {
+	registry.VerifiableCredentialRegistry
+	credentials []vc.VerifiableCredential
+	err         error
+}
+
+func (s stubVCR) FindAuthorizationCredentials(_ context.Context, params *registry.VCRSearchParams) ([]vc.VerifiableCredential, error) {
+	if params.Issuer != customerDID || params.PurposeOfUse != zorginzage.ServiceName || params.ResourcePath != "/EpisodeOfCare/episode" {
+		return nil, nil
+	}
+	return s.credentials, s.err
+}
+
+// stubOrganizations has a notification endpoint for every organization, except for did:nuts:unreachable
+type stubOrganizations struct {
+	registry.OrganizationRegistry
+}
+
+func (stubOrganizations) GetCompoundServiceEndpoint(_ context.Context, organizationDID, _ string, _ string) (string, error) {
+	if organizationDID == "did:nuts:unreachable" {
+		return "", errors.New("no endpoint")
+	}
+	return "https://" + organizationDID + "/notify", nil
+}
+
+type stubAuth struct {
+	auth.Service
+}
+
+func (stubAuth) RequestAccessToken(_ context.Context, _, authorizer, _ string, _ []vc.VerifiableCredential, _ *nutsAuthClient.VerifiablePresentation) (*nutsAuthClient.AccessTokenResponse, error) {
+	return &nutsAuthClient.AccessTokenResponse{AccessToken: "token-for-" + authorizer}, nil
+}
+
+type recordingNotifier struct {
+	endpoints []string
+}
+
+func (n *recordingNotifier) Notify(token, endpoint string) error {
+	n.endpoints = append(n.endpoints, token+" "+endpoint)
+	return nil
+}
+
+func collaboratorCredential(t *testing.T, collaboratorDID string) vc.VerifiableCredential {
+	data, _ := json.Marshal(map[string]interface{}{
+		"@context":     []string{"https://www.w3.org/2018/credentials/v1"},
+		"type":         []string{"VerifiableCredential", "NutsAuthorizationCredential"},
+		"issuer":       customerDID,
+		"issuanceDate": "2022-01-10T12:00:00Z",
+		"credentialSubject": map[string]interface{}{
+			"id":           collaboratorDID,
+			"purposeOfUse": zorginzage.ServiceName,
+			"resources":    []map[string]interface{}{{"path": "/EpisodeOfCare/episode", "operations": []string{"read"}}},
+		},
+	})
+	result := vc.VerifiableCredential{}
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestService_NotifyCollaborators(t *testing.T) {
+	notifyContribution := func(vcr stubVCR, contributorDID string) (*recordingNotifier, error) {
+		notifier := &recordingNotifier{}
+		svc := &service{auth: stubAuth{}, registry: stubOrganizations{}, vcr: vcr, notifier: notifier}
+		return notifier, svc.NotifyCollaborators(context.Background(), customerDID, "episode", contributorDID)
+	}
+	notify := func(vcr stubVCR) (*recordingNotifier, error) {
+		return notifyContribution(vcr, "")
+	}
+
+	t.Run("ok - every collaborator is notified once", func(t *testing.T) {
+		notifier, err := notify(stubVCR{credentials: []vc.VerifiableCredential{
+			collaboratorCredential(t, "did:nuts:care-home"),
+			collaboratorCredential(t, "did:nuts:general-practitioner"),
+			collaboratorCredential(t, "did:nuts:care-home"),
+		}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"token-for-did:nuts:care-home https://did:nuts:care-home/notify/episode",
+			"token-for-did:nuts:general-practitioner https://did:nuts:general-practitioner/notify/episode",
+		}, notifier.endpoints)
+	})
+	t.Run("ok - own organization is skipped", func(t *testing.T) {
+		notifier, err := notify(stubVCR{credentials: []vc.VerifiableCredential{
+			collaboratorCredential(t, customerDID),
+			collaboratorCredential(t, "did:nuts:care-home"),
+		}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"token-for-did:nuts:care-home https://did:nuts:care-home/notify/episode"}, notifier.endpoints)
+	})
+	t.Run("ok - contributor is skipped", func(t *testing.T) {
+		notifier, err := notifyContribution(stubVCR{credentials: []vc.VerifiableCredential{
+			collaboratorCredential(t, "did:nuts:general-practitioner"),
+			collaboratorCredential(t, "did:nuts:care-home"),
+		}}, "did:nuts:general-practitioner")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"token-for-did:nuts:care-home https://did:nuts:care-home/notify/episode"}, notifier.endpoints)
+	})
+	t.Run("ok - a collaborator that can't be notified doesn't keep the others from being notified", func(t *testing.T) {
+		notifier, err := notify(stubVCR{credentials: []vc.VerifiableCredential{
+			collaboratorCredential(t, "did:nuts:unreachable"),
+			collaboratorCredential(t, "did:nuts:care-home"),
+		}})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"token-for-did:nuts:care-home https://did:nuts:care-home/notify/episode"}, notifier.endpoints)
+	})
+	t.Run("error - collaborators can't be found", func(t *testing.T) {
+		notifier, err := notify(stubVCR{err: errors.New("vcr unavailable")})
+
+		assert.EqualError(t, err, "vcr unavailable")
+		assert.Empty(t, notifier.endpoints)
+	})
+}
@@ -5,12 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/monarko/fhirgo/STU3/resources"
 	"github.com/nuts-foundation/go-did/vc"
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
 	reports "github.com/nuts-foundation/nuts-demo-ehr/domain/reports"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
 	"github.com/nuts-foundation/nuts-demo-ehr/http/auth"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
@@ -21,8 +24,18 @@ type Service interface {
 	Create(ctx context.Context, customerID int, patientID string, request types.CreateEpisodeRequest) (*types.Episode, error)
 	Get(ctx context.Context, customerID int, dossierID string) (*types.Episode, error)
 	GetReports(ctx context.Context, customerDID, patientSSN string) ([]types.Report, error)
-	CreateCollaboration(ctx context.Context, customerDID, dossierID, patientSSN, senderDID string) error
+	// CreateCollaboration authorizes the collaborator to read the episode. If allowCreate is set, it may also
+	// contribute resources to the episode through the FHIR proxy. The authorization expires after the episode ends.
+	// It replaces the authorization the collaborator got earlier for the episode.
+	CreateCollaboration(ctx context.Context, customerID int, customerDID, dossierID, patientSSN, senderDID string, allowCreate bool) error
 	GetCollaborations(ctx context.Context, customerDID, dossierID, patientSSN string) ([]types.Collaboration, error)
+	// CreateContribution stores a resource created by a collaborator (authorDID) within the customer's episode.
+	CreateContribution(ctx context.Context, customerID int, episodeID, authorDID, resourceType string, data []byte) (interface{}, error)
+	// NotifyCollaborators notifies the organizations collaborating in the customer's episode that its reports changed.
+	// The contributor that changed them (if not empty) isn't notified of its own change.
+	NotifyCollaborators(ctx context.Context, customerDID, episodeID, contributorDID string) error
+	// RefreshReports retrieves the reports of the episode held by the custodian, replacing the cached reports.
+	RefreshReports(ctx context.Context, customerDID, custodianDID, episodeID string) error
 }
 
 func ssnURN(ssn string) string {
@@ -40,14 +53,33 @@ func parseAuthCredentialSubject(authCredential vc.VerifiableCredential) (*creden
 }
 
 type service struct {
-	factory  fhir.Factory
-	auth     auth.Service
-	registry registry.OrganizationRegistry
-	vcr      registry.VerifiableCredentialRegistry
+	factory fhir.Factory
+	// remoteFactory creates clients for the FHIR servers of other care organizations
+	remoteFactory fhir.Factory
+	auth          auth.Service
+	registry      registry.OrganizationRegistry
+	vcr           registry.VerifiableCredentialRegistry
+	// collaborations contains the IDs of the authorization credentials issued to collaborators
+	collaborations CollaborationRepository
+	notifier       transfer.Notifier
+	// reports contains the reports retrieved from other organizations, per customer and episode
+	reports *cache.Cache
+	// expiration determines when the authorization credentials of collaborators expire, relative to the episode end
+	expiration registry.ExpirationPolicy
 }
 
-func NewService(factory fhir.Factory, auth auth.Service, registry registry.OrganizationRegistry, vcr registry.VerifiableCredentialRegistry) Service {
-	return &service{factory: factory, auth: auth, registry: registry, vcr: vcr}
+func NewService(factory fhir.Factory, auth auth.Service, registry registry.OrganizationRegistry, vcr registry.VerifiableCredentialRegistry, collaborations CollaborationRepository, reportCache *cache.Cache, remoteFactory fhir.Factory, notifier transfer.Notifier, expiration registry.ExpirationPolicy) Service {
+	return &service{
+		factory:        factory,
+		remoteFactory:  remoteFactory,
+		auth:           auth,
+		registry:       registry,
+		vcr:            vcr,
+		collaborations: collaborations,
+		notifier:       notifier,
+		reports:        reportCache,
+		expiration:     expiration,
+	}
 }
 
 func parseEpisodeOfCareID(authCredential vc.VerifiableCredential) (string, error) {
@@ -87,10 +119,36 @@ func (service *service) Get(ctx context.Context, customerID int, dossierID strin
 	return zorginzage.ToEpisode(episode), nil
 }
 
-func (service *service) CreateCollaboration(ctx context.Context, customerDID, dossierID, patientSSN, senderDID string) error {
+func (service *service) CreateContribution(ctx context.Context, customerID int, episodeID, authorDID, resourceType string, data []byte) (interface{}, error) {
+	svc := zorginzage.NewService(service.factory(fhir.WithTenant(customerID)))
+
+	return svc.CreateContribution(ctx, episodeID, authorDID, resourceType, data)
+}
+
+func (service *service) CreateCollaboration(ctx context.Context, customerID int, customerDID, dossierID, patientSSN, senderDID string, allowCreate bool) error {
 	subject := ssnURN(patientSSN)
 
-	return service.vcr.CreateAuthorizationCredential(ctx, customerDID, &credential.NutsAuthorizationCredentialSubject{
+	episode, err := service.Get(ctx, customerID, dossierID)
+	if err != nil {
+		return fmt.Errorf("unable to read episode: %w", err)
+	}
+	var episodeEnd *time.Time
+	if episode.Period.End != nil {
+		episodeEnd = &episode.Period.End.Time
+	}
+
+	operations := []string{"read"}
+	if allowCreate {
+		operations = append(operations, "create")
+	}
+
+	previousID, err := service.collaborations.FindCredentialID(ctx, customerID, dossierID, senderDID)
+	if err != nil {
+		return err
+	}
+
+	resourcePath := fmt.Sprintf("/EpisodeOfCare/%s", dossierID)
+	credentialID, err := service.vcr.CreateAuthorizationCredential(ctx, customerDID, &credential.NutsAuthorizationCredentialSubject{
 		ID:      senderDID,
 		Subject: &subject,
 		LegalBase: credential.LegalBase{
@@ -99,11 +157,56 @@ func (service *service) CreateCollaboration(ctx context.Context, customerDID, do
 		PurposeOfUse: zorginzage.ServiceName,
 		Resources: []credential.Resource{
 			{
-				Path:       fmt.Sprintf("/EpisodeOfCare/%s", dossierID),
-				Operations: []string{"read"},
+				Path:       resourcePath,
+				Operations: operations,
 			},
 		},
+	}, service.expiration.ExpirationDate(episodeEnd, time.Now()))
+	if err != nil {
+		return err
+	}
+	if err := service.collaborations.SaveCredentialID(ctx, customerID, dossierID, senderDID, credentialID); err != nil {
+		return err
+	}
+
+	// The new credential is issued before the earlier one is revoked, so the access isn't interrupted.
+	if previousID != "" {
+		return service.vcr.RevokeCredential(ctx, previousID)
+	}
+	return service.revokeLegacyCollaboration(ctx, customerDID, senderDID, resourcePath, credentialID)
+}
+
+// revokeLegacyCollaboration revokes the credentials issued to the collaborator for the episode before their IDs were
+// stored. They're found by the exact path of the episode, skipping the credential that replaces them.
+func (service *service) revokeLegacyCollaboration(ctx context.Context, customerDID, senderDID, resourcePath, credentialID string) error {
+	credentials, err := service.vcr.FindIssuedAuthorizationCredentials(ctx, &registry.VCRSearchParams{
+		Issuer:       customerDID,
+		SubjectID:    senderDID,
+		PurposeOfUse: zorginzage.ServiceName,
+		ResourcePath: resourcePath,
 	})
+	if err != nil {
+		return err
+	}
+	for _, authCredential := range credentials {
+		if authCredential.ID == nil || authCredential.ID.String() == credentialID {
+			continue
+		}
+		subject, err := parseAuthCredentialSubject(authCredential)
+		if err != nil {
+			return err
+		}
+		for _, resource := range subject.Resources {
+			if resource.Path != resourcePath {
+				continue
+			}
+			if err := service.vcr.RevokeCredential(ctx, authCredential.ID.String()); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
 }
 
 func (service *service) GetCollaborations(ctx context.Context, customerDID, dossierID, patientSSN string) ([]types.Collaboration, error) {
@@ -175,6 +278,29 @@ func (service *service) GetReports(ctx context.Context, customerDID, patientSSN
 	// TODO: loop over all credentials
 	issuer := credentials[0].Issuer.String()
 
+	episodeOfCareID, err := parseEpisodeOfCareID(credentials[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// reports are refreshed when the organization holding the episode notifies us, see RefreshReports
+	key := reportCacheKey(customerDID, issuer, episodeOfCareID)
+	if cached, ok := service.reports.Get(key); ok {
+		return cached.([]types.Report), nil
+	}
+
+	results, err := service.fetchReports(ctx, customerDID, issuer, episodeOfCareID, credentials[0])
+	if err != nil {
+		return nil, err
+	}
+
+	service.reports.Set(key, results)
+
+	return results, nil
+}
+
+// fetchReports retrieves the reports of the episode from the FHIR server of the organization holding it (issuer).
+func (service *service) fetchReports(ctx context.Context, customerDID, issuer, episodeOfCareID string, authCredential vc.VerifiableCredential) ([]types.Report, error) {
 	fhirServer, err := service.registry.GetCompoundServiceEndpoint(ctx, issuer, zorginzage.ServiceName, "fhir")
 	if err != nil {
 		return nil, fmt.Errorf("error while looking up authorizer's FHIR server (did=%s): %w", issuer, err)
@@ -185,17 +311,12 @@ func (service *service) GetReports(ctx context.Context, customerDID, patientSSN
 		return nil, fmt.Errorf("error while searching organization :%w", err)
 	}
 
-	accessToken, err := service.auth.RequestAccessToken(ctx, customerDID, issuer, zorginzage.ServiceName, []vc.VerifiableCredential{credentials[0]}, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	episodeOfCareID, err := parseEpisodeOfCareID(credentials[0])
+	accessToken, err := service.auth.RequestAccessToken(ctx, customerDID, issuer, zorginzage.ServiceName, []vc.VerifiableCredential{authCredential}, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	fhirClient := fhir.NewFactory(fhir.WithURL(fhirServer), fhir.WithAuthToken(accessToken.AccessToken))()
+	fhirClient := service.remoteFactory(fhir.WithURL(fhirServer), fhir.WithAuthToken(accessToken.AccessToken))
 
 	fhirEpisode := &fhir.EpisodeOfCare{}
 	err = fhirClient.ReadOne(ctx, "/EpisodeOfCare/"+episodeOfCareID, fhirEpisode)
@@ -212,7 +333,7 @@ func (service *service) GetReports(ctx context.Context, customerDID, patientSSN
 		return nil, err
 	}
 
-	results := make([]types.Report, len(observations))
+	results := make([]types.Report, 0, len(observations))
 
 	for _, observation := range observations {
 		domainObservation := reports.ConvertToDomain(&observation, fhir.FromStringPtr(observation.Subject.ID))
@@ -2,11 +2,13 @@ package fhir
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"path"
-	"strconv"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/labstack/gommon/log"
@@ -14,6 +16,9 @@ import (
 	"github.com/tidwall/gjson"
 )
 
+// ErrNotFound is returned when the FHIR server doesn't have the requested resource, also when it was deleted.
+var ErrNotFound = errors.New("FHIR resource not found")
+
 type ClientOpt func(client *httpClient)
 
 type Factory func(opts ...ClientOpt) Client
@@ -24,9 +29,10 @@ func WithURL(serverURL string) ClientOpt {
 	}
 }
 
-func WithMultiTenancyEnabled(enabled bool) ClientOpt {
+// WithTenantStrategy makes the client separate the data of tenants using the given strategy, instead of using the URL as is.
+func WithTenantStrategy(strategy TenantStrategy) ClientOpt {
 	return func(client *httpClient) {
-		client.multiTenancyEnabled = enabled
+		client.tenancy = strategy
 	}
 }
 
@@ -42,6 +48,16 @@ func WithAuthToken(authToken string) ClientOpt {
 	}
 }
 
+// WithTLS makes the client use the given TLS configuration, e.g. to present a client certificate to FHIR servers
+// of other care organizations. A nil configuration is ignored.
+func WithTLS(config *tls.Config) ClientOpt {
+	return func(client *httpClient) {
+		if config != nil {
+			client.restClient.SetTLSClientConfig(config)
+		}
+	}
+}
+
 func NewFactory(defaultOpts ...ClientOpt) Factory {
 	return func(callerOpts ...ClientOpt) Client {
 		client := &httpClient{
@@ -61,10 +77,10 @@ type Client interface {
 }
 
 type httpClient struct {
-	restClient          *resty.Client
-	url                 string
-	tenant              int
-	multiTenancyEnabled bool
+	restClient *resty.Client
+	url        string
+	tenant     int
+	tenancy    TenantStrategy
 }
 
 func (h httpClient) CreateOrUpdate(ctx context.Context, resource interface{}) error {
@@ -72,8 +88,11 @@ func (h httpClient) CreateOrUpdate(ctx context.Context, resource interface{}) er
 	if err != nil {
 		return fmt.Errorf("unable to determine resource path: %w", err)
 	}
-	requestURI := h.buildRequestURI(resourcePath)
-	resp, err := h.restClient.R().SetBody(resource).SetContext(ctx).Put(requestURI)
+	requestURI, err := h.buildRequestURI(resourcePath)
+	if err != nil {
+		return err
+	}
+	resp, err := h.request(ctx).SetBody(resource).Put(requestURI)
 	if err != nil {
 		return fmt.Errorf("unable to write FHIR resource (path=%s): %w", requestURI, err)
 	}
@@ -115,13 +134,20 @@ func (h httpClient) ReadOne(ctx context.Context, path string, result interface{}
 }
 
 func (h httpClient) getResource(ctx context.Context, path string, params map[string]string) (gjson.Result, error) {
-	url := h.buildRequestURI(path)
+	url, err := h.buildRequestURI(path)
+	if err != nil {
+		return gjson.Result{}, err
+	}
 	logrus.Debugf("Performing FHIR request with url: %s", url)
-	resp, err := h.restClient.R().SetQueryParams(params).SetContext(ctx).SetHeader("Cache-Control", "no-cache").Get(url)
+	resp, err := h.request(ctx).SetQueryParams(params).SetHeader("Cache-Control", "no-cache").Get(url)
 	if err != nil {
 		return gjson.Result{}, err
 	}
 
+	// servers like HAPI reply with 410 Gone for deleted resources
+	if resp.StatusCode() == http.StatusNotFound || resp.StatusCode() == http.StatusGone {
+		return gjson.Result{}, fmt.Errorf("unable to read FHIR resource (path=%s): %w", path, ErrNotFound)
+	}
 	if !resp.IsSuccess() {
 		log.Warnf("FHIR server replied: %s", resp.String())
 		return gjson.Result{}, fmt.Errorf("unable to read FHIR resource (path=%s,http-status=%d)", path, resp.StatusCode())
@@ -132,12 +158,26 @@ func (h httpClient) getResource(ctx context.Context, path string, params map[str
 	return gjson.ParseBytes(body), nil
 }
 
-func (h httpClient) buildRequestURI(fhirResourcePath string) string {
-	if !h.multiTenancyEnabled {
-		return buildRequestURI(h.url, "", fhirResourcePath)
+func (h httpClient) request(ctx context.Context) *resty.Request {
+	request := h.restClient.R().SetContext(ctx)
+	if h.tenancy != nil {
+		request.SetHeaderMultiValues(h.tenancy.Header(h.tenant))
+	}
+
+	return request
+}
+
+func (h httpClient) buildRequestURI(fhirResourcePath string) (string, error) {
+	if h.tenancy == nil {
+		return buildRequestURI(h.url, "", fhirResourcePath), nil
+	}
+
+	requestURI, err := h.tenancy.URL(h.tenant, fhirResourcePath)
+	if err != nil {
+		return "", err
 	}
 
-	return buildRequestURI(h.url, strconv.Itoa(h.tenant), fhirResourcePath)
+	return requestURI.String(), nil
 }
 
 func resolveResourcePath(resource interface{}) (string, error) {
@@ -0,0 +1,44 @@
+package fhir
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/fhirtest"
+)
+
+func TestHttpClient_ReadOne(t *testing.T) {
+	server := httptest.NewServer(fhirtest.NewServer())
+	t.Cleanup(server.Close)
+	client := NewFactory(WithURL(server.URL))()
+	ctx := context.Background()
+	require.NoError(t, client.CreateOrUpdate(ctx, map[string]string{"resourceType": "EpisodeOfCare", "id": "1", "status": "active"}))
+
+	t.Run("ok", func(t *testing.T) {
+		result := map[string]interface{}{}
+
+		require.NoError(t, client.ReadOne(ctx, "EpisodeOfCare/1", &result))
+		assert.Equal(t, "active", result["status"])
+	})
+	t.Run("not found", func(t *testing.T) {
+		err := client.ReadOne(ctx, "EpisodeOfCare/unknown", &map[string]interface{}{})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("deleted resources are gone", func(t *testing.T) {
+		require.NoError(t, client.CreateOrUpdate(ctx, map[string]string{"resourceType": "EpisodeOfCare", "id": "2"}))
+		request, _ := http.NewRequest(http.MethodDelete, server.URL+"/EpisodeOfCare/2", nil)
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+
+		err = client.ReadOne(ctx, "EpisodeOfCare/2", &map[string]interface{}{})
+
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -74,7 +74,7 @@ func (s transferService) UpdateTask(ctx context.Context, fhirTaskID string, call
 		ID:          &domainTask.ID,
 		RequesterID: domainTask.SenderDID,
 		OwnerID:     domainTask.ReceiverDID,
-		Status:      transfer.RequestedState,
+		Status:      domainTask.Status,
 	})
 
 	if domainTask.AdvanceNoticeID != nil {
@@ -0,0 +1,116 @@
+// Package fhirtest provides an in-memory FHIR server for tests.
+package fhirtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Server is an in-memory FHIR server. Resources are created and updated with a PUT on their path (e.g. /1/Patient/123)
+// and read with a GET. Paths may have any prefix, e.g. the tenant, which separates the resources.
+// Searching (a GET on e.g. /1/Patient) returns all resources of the type with the same prefix: search parameters aren't supported.
+// Like HAPI, reading a resource that was deleted with a DELETE results in 410 Gone.
+type Server struct {
+	mux sync.Mutex
+	// resources by path
+	resources map[string]json.RawMessage
+	// deleted contains the paths of the deleted resources
+	deleted map[string]bool
+}
+
+// NewServer returns a Server without resources.
+func NewServer() *Server {
+	return &Server{resources: map[string]json.RawMessage{}, deleted: map[string]bool{}}
+}
+
+func (s *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	path := strings.TrimSuffix(request.URL.Path, "/")
+	switch request.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(request.Body)
+		if err != nil || !json.Valid(data) {
+			writeOperationOutcome(writer, http.StatusBadRequest, "invalid resource")
+			return
+		}
+		s.resources[path] = data
+		delete(s.deleted, path)
+		writeJSON(writer, http.StatusOK, json.RawMessage(data))
+	case http.MethodGet:
+		if !isResourcePath(path) {
+			writeJSON(writer, http.StatusOK, s.search(path))
+			return
+		}
+		resource, ok := s.resources[path]
+		if s.deleted[path] {
+			writeOperationOutcome(writer, http.StatusGone, "resource deleted: "+path)
+			return
+		} else if !ok {
+			writeOperationOutcome(writer, http.StatusNotFound, "resource not found: "+path)
+			return
+		}
+		writeJSON(writer, http.StatusOK, resource)
+	case http.MethodDelete:
+		if _, ok := s.resources[path]; ok {
+			delete(s.resources, path)
+			s.deleted[path] = true
+		}
+		writeOperationOutcome(writer, http.StatusOK, "resource deleted: "+path)
+	default:
+		writeOperationOutcome(writer, http.StatusMethodNotAllowed, "unsupported method: "+request.Method)
+	}
+}
+
+// search returns a searchset Bundle with all resources of the type of the path, ordered by path.
+func (s *Server) search(path string) map[string]interface{} {
+	var paths []string
+	for resourcePath := range s.resources {
+		if strings.HasPrefix(resourcePath, path+"/") && !strings.Contains(resourcePath[len(path)+1:], "/") {
+			paths = append(paths, resourcePath)
+		}
+	}
+	sort.Strings(paths)
+
+	entries := []map[string]interface{}{}
+	for _, resourcePath := range paths {
+		entries = append(entries, map[string]interface{}{"resource": s.resources[resourcePath]})
+	}
+	return map[string]interface{}{
+		"resourceType": "Bundle",
+		"type":         "searchset",
+		"total":        len(entries),
+		"entry":        entries,
+	}
+}
+
+// isResourcePath returns true if the path ends with a resource type and ID (e.g. Patient/123), otherwise it's a search.
+func isResourcePath(path string) bool {
+	segments := strings.Split(path, "/")
+	if len(segments) < 2 {
+		return false
+	}
+	resourceType := segments[len(segments)-2]
+	return resourceType != "" && unicode.IsUpper(rune(resourceType[0]))
+}
+
+func writeOperationOutcome(writer http.ResponseWriter, status int, message string) {
+	writeJSON(writer, status, map[string]interface{}{
+		"resourceType": "OperationOutcome",
+		"issue": []map[string]interface{}{
+			{"severity": "error", "code": "processing", "diagnostics": message},
+		},
+	})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, body interface{}) {
+	writer.Header().Set("Content-Type", "application/fhir+json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(body)
+}
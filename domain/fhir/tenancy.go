@@ -0,0 +1,116 @@
+package fhir
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// TenantStrategy defines how the data of the tenants (customers) is separated on the FHIR server.
+// It's used by both the FHIR client and the FHIR proxy.
+type TenantStrategy interface {
+	// URL returns the URL of the given resource path (e.g. Patient/123) on the FHIR server for the given tenant.
+	URL(tenant int, resourcePath string) (*url.URL, error)
+	// Header returns the HTTP headers that identify the tenant on the FHIR server, if any.
+	Header(tenant int) http.Header
+	// Initialize provisions the tenant on the FHIR server, if required. It's called for every customer before it's used.
+	Initialize(tenant int) error
+}
+
+// NoTenancy stores the data of all tenants in the same FHIR server, without separation.
+type NoTenancy struct {
+	BaseURL string
+}
+
+func (t NoTenancy) URL(_ int, resourcePath string) (*url.URL, error) {
+	return parseRequestURI(t.BaseURL, "", resourcePath)
+}
+
+func (t NoTenancy) Header(_ int) http.Header {
+	return nil
+}
+
+func (t NoTenancy) Initialize(_ int) error {
+	return nil
+}
+
+// PathPrefixTenancy separates tenants by prefixing the resource path with the tenant: {base}/{tenant}/{resourcePath}.
+type PathPrefixTenancy struct {
+	BaseURL string
+	// HAPIPartitions enables creating a HAPI FHIR server partition for every tenant on initialization.
+	HAPIPartitions bool
+}
+
+func (t PathPrefixTenancy) URL(tenant int, resourcePath string) (*url.URL, error) {
+	return parseRequestURI(t.BaseURL, strconv.Itoa(tenant), resourcePath)
+}
+
+func (t PathPrefixTenancy) Header(_ int) http.Header {
+	return nil
+}
+
+func (t PathPrefixTenancy) Initialize(tenant int) error {
+	if !t.HAPIPartitions {
+		return nil
+	}
+
+	return InitializeTenant(t.BaseURL, strconv.Itoa(tenant))
+}
+
+// BaseURLTenancy gives every tenant its own FHIR server (or base URL).
+type BaseURLTenancy struct {
+	// BaseURLs maps the tenant to the base URL of its FHIR server.
+	BaseURLs map[int]string
+}
+
+func (t BaseURLTenancy) URL(tenant int, resourcePath string) (*url.URL, error) {
+	baseURL, ok := t.BaseURLs[tenant]
+	if !ok {
+		return nil, fmt.Errorf("no FHIR server configured for tenant (tenant=%d)", tenant)
+	}
+
+	return parseRequestURI(baseURL, "", resourcePath)
+}
+
+func (t BaseURLTenancy) Header(_ int) http.Header {
+	return nil
+}
+
+func (t BaseURLTenancy) Initialize(tenant int) error {
+	if _, ok := t.BaseURLs[tenant]; !ok {
+		return fmt.Errorf("no FHIR server configured for tenant (tenant=%d)", tenant)
+	}
+
+	return nil
+}
+
+// HeaderTenancy identifies the tenant with an HTTP header on every request to the FHIR server.
+type HeaderTenancy struct {
+	BaseURL    string
+	HeaderName string
+}
+
+func (t HeaderTenancy) URL(_ int, resourcePath string) (*url.URL, error) {
+	return parseRequestURI(t.BaseURL, "", resourcePath)
+}
+
+func (t HeaderTenancy) Header(tenant int) http.Header {
+	header := http.Header{}
+	header.Set(t.HeaderName, strconv.Itoa(tenant))
+
+	return header
+}
+
+func (t HeaderTenancy) Initialize(_ int) error {
+	return nil
+}
+
+func parseRequestURI(baseURL string, tenant string, resourcePath string) (*url.URL, error) {
+	requestURI, err := url.Parse(buildRequestURI(baseURL, tenant, resourcePath))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FHIR server URL (url=%s): %w", baseURL, err)
+	}
+
+	return requestURI, nil
+}
@@ -0,0 +1,41 @@
+package fhir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantStrategy_URL(t *testing.T) {
+	t.Run("no tenancy", func(t *testing.T) {
+		requestURL, err := NoTenancy{BaseURL: "http://fhir/base"}.URL(1, "Patient/123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "http://fhir/base/Patient/123", requestURL.String())
+	})
+	t.Run("path prefix", func(t *testing.T) {
+		requestURL, err := PathPrefixTenancy{BaseURL: "http://fhir/base"}.URL(1, "Patient/123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "http://fhir/base/1/Patient/123", requestURL.String())
+	})
+	t.Run("base URL", func(t *testing.T) {
+		strategy := BaseURLTenancy{BaseURLs: map[int]string{1: "http://fhir-1/base"}}
+
+		requestURL, err := strategy.URL(1, "Patient/123")
+		assert.NoError(t, err)
+		assert.Equal(t, "http://fhir-1/base/Patient/123", requestURL.String())
+
+		_, err = strategy.URL(2, "Patient/123")
+		assert.EqualError(t, err, "no FHIR server configured for tenant (tenant=2)")
+	})
+	t.Run("header", func(t *testing.T) {
+		strategy := HeaderTenancy{BaseURL: "http://fhir/base", HeaderName: "X-Tenant"}
+
+		requestURL, err := strategy.URL(1, "Patient/123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "http://fhir/base/Patient/123", requestURL.String())
+		assert.Equal(t, "1", strategy.Header(1).Get("X-Tenant"))
+	})
+}
@@ -13,6 +13,9 @@ var (
 	UZICodingSystem    datatypes.URI = "http://fhir.nl/fhir/NamingSystem/uzi-nr-pers"
 )
 
+// MetaSourceExtension is the extension used by HAPI FHIR to store meta.source on STU3 resources
+var MetaSourceExtension datatypes.URI = "http://hapifhir.io/fhir/StructureDefinition/resource-meta-source"
+
 // Codes for the status of an EpisodeOfCare
 const (
 	EpisodeStatusPlanned        = datatypes.Code("planned")
@@ -81,3 +84,53 @@ type EpisodeOfCare struct {
 	Team                 []datatypes.Reference       `json:"team,omitempty"`
 	Account              []datatypes.Reference       `json:"account,omitempty"`
 }
+
+// DocumentReferenceContent defines the content of a FHIR STU3 DocumentReference.
+type DocumentReferenceContent struct {
+	Attachment datatypes.Attachment `json:"attachment"`
+	Format     *datatypes.Coding    `json:"format,omitempty"`
+}
+
+// DocumentReferenceContextRelated defines a resource related to a FHIR STU3 DocumentReference, e.g. an EpisodeOfCare.
+type DocumentReferenceContextRelated struct {
+	Identifier *datatypes.Identifier `json:"identifier,omitempty"`
+	Ref        *datatypes.Reference  `json:"ref,omitempty"`
+}
+
+// DocumentReferenceContext defines the clinical context of a FHIR STU3 DocumentReference.
+type DocumentReferenceContext struct {
+	Encounter *datatypes.Reference              `json:"encounter,omitempty"`
+	Period    *datatypes.Period                 `json:"period,omitempty"`
+	Related   []DocumentReferenceContextRelated `json:"related,omitempty"`
+}
+
+// DocumentReference defines a basic FHIR STU3 DocumentReference resource which is currently not included in the FHIR library.
+type DocumentReference struct {
+	resources.Domain
+	Identifier  []datatypes.Identifier     `json:"identifier,omitempty"`
+	Status      datatypes.Code             `json:"status"`
+	Type        datatypes.CodeableConcept  `json:"type"`
+	Subject     *datatypes.Reference       `json:"subject,omitempty"`
+	Created     *datatypes.DateTime        `json:"created,omitempty"`
+	Indexed     datatypes.Instant          `json:"indexed"`
+	Author      []datatypes.Reference      `json:"author,omitempty"`
+	Description *datatypes.String          `json:"description,omitempty"`
+	Content     []DocumentReferenceContent `json:"content"`
+	Context     *DocumentReferenceContext  `json:"context,omitempty"`
+}
+
+// ProvenanceAgent defines an actor taking part in the activity recorded by a FHIR STU3 Provenance.
+type ProvenanceAgent struct {
+	Role          []datatypes.CodeableConcept `json:"role,omitempty"`
+	WhoReference  *datatypes.Reference        `json:"whoReference,omitempty"`
+	OnBehalfOfURI *datatypes.URI              `json:"onBehalfOfUri,omitempty"`
+}
+
+// Provenance defines a basic FHIR STU3 Provenance resource which is currently not included in the FHIR library.
+type Provenance struct {
+	resources.Domain
+	Target   []datatypes.Reference `json:"target"`
+	Recorded datatypes.Instant     `json:"recorded"`
+	Activity *datatypes.Coding     `json:"activity,omitempty"`
+	Agent    []ProvenanceAgent     `json:"agent"`
+}
@@ -0,0 +1,211 @@
+package zorginzage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/monarko/fhirgo/STU3/datatypes"
+	"github.com/monarko/fhirgo/STU3/resources"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
+)
+
+// ErrInvalidContribution is returned when a resource contributed by a collaborator doesn't pass validation.
+var ErrInvalidContribution = errors.New("invalid contribution")
+
+// ContributableResourceTypes contains the FHIR resource types collaborators may create within a shared episode.
+var ContributableResourceTypes = []string{"Observation", "DocumentReference"}
+
+// IsContributableResourceType returns true if collaborators may create resources of the given type.
+func IsContributableResourceType(resourceType string) bool {
+	for _, curr := range ContributableResourceTypes {
+		if curr == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidContribution(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidContribution, fmt.Sprintf(format, args...))
+}
+
+// ContributionEpisodeID returns the ID of the EpisodeOfCare a resource contributed by a collaborator belongs to,
+// as referred to by Observation.context or DocumentReference.context.related.
+func ContributionEpisodeID(resourceType string, data []byte) (string, error) {
+	var contextRef *datatypes.Reference
+	switch resourceType {
+	case "Observation":
+		observation := resources.Observation{}
+		if err := json.Unmarshal(data, &observation); err != nil {
+			return "", invalidContribution("unable to parse Observation: %s", err)
+		}
+		contextRef = observation.Context
+	case "DocumentReference":
+		document := fhir.DocumentReference{}
+		if err := json.Unmarshal(data, &document); err != nil {
+			return "", invalidContribution("unable to parse DocumentReference: %s", err)
+		}
+		contextRef = documentContext(document)
+	default:
+		return "", invalidContribution("resource type %s can't be created by collaborators", resourceType)
+	}
+
+	reference := ""
+	if contextRef != nil {
+		reference = fhir.FromStringPtr(contextRef.Reference)
+	}
+	episodeID := strings.TrimPrefix(reference, "EpisodeOfCare/")
+	if episodeID == reference || episodeID == "" {
+		return "", invalidContribution("context must refer to the EpisodeOfCare the %s belongs to", resourceType)
+	}
+	return episodeID, nil
+}
+
+// documentContext returns the reference to the context of the DocumentReference: the last related resource.
+func documentContext(document fhir.DocumentReference) *datatypes.Reference {
+	var result *datatypes.Reference
+	if document.Context != nil {
+		for _, related := range document.Context.Related {
+			if related.Ref != nil {
+				result = related.Ref
+			}
+		}
+	}
+	return result
+}
+
+// CreateContribution validates and stores a resource created by a collaborating organization within the given episode.
+// Whatever the collaborator supplied, the resource gets a new ID, its source and author are set to the collaborator's DID
+// and it is linked to the episode and its patient. The creation is recorded in a Provenance resource.
+func (service *service) CreateContribution(ctx context.Context, episodeID, authorDID, resourceType string, data []byte) (interface{}, error) {
+	episode, err := service.GetEpisode(ctx, episodeID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read episode: %w", err)
+	}
+	if episode.Status != fhir.EpisodeStatusActive {
+		return nil, invalidContribution("episode is not active (status=%s)", episode.Status)
+	}
+
+	episodeRef := fmt.Sprintf("EpisodeOfCare/%s", episodeID)
+	patientRef := fhir.FromStringPtr(episode.Patient.Reference)
+	author := datatypes.Reference{
+		Identifier: &datatypes.Identifier{
+			System: &fhir.NutsCodingSystem,
+			Value:  fhir.ToStringPtr(authorDID),
+		},
+	}
+	meta := &datatypes.Meta{
+		Element: datatypes.Element{
+			Extension: []datatypes.Extension{{
+				URL:      &fhir.MetaSourceExtension,
+				ValueURI: fhir.ToUriPtr(authorDID),
+			}},
+		},
+	}
+	resourceID := uuid.NewString()
+
+	var resource interface{}
+
+	switch resourceType {
+	case "Observation":
+		observation := resources.Observation{}
+		if err := json.Unmarshal(data, &observation); err != nil {
+			return nil, invalidContribution("unable to parse Observation: %s", err)
+		}
+		if err := validateReferences(observation.ResourceType, resourceType, observation.Subject, patientRef, observation.Context, episodeRef); err != nil {
+			return nil, err
+		}
+		if observation.Code == nil || len(observation.Code.Coding) == 0 {
+			return nil, invalidContribution("Observation.code is required")
+		}
+		if observation.Status == nil {
+			observation.Status = fhir.ToCodePtr("final")
+		}
+
+		observation.ID = fhir.ToIDPtr(resourceID)
+		observation.Meta = meta
+		observation.Subject = &datatypes.Reference{Reference: fhir.ToStringPtr(patientRef)}
+		observation.Context = &datatypes.Reference{Reference: fhir.ToStringPtr(episodeRef)}
+		observation.Performer = []datatypes.Reference{author}
+		resource = observation
+	case "DocumentReference":
+		document := fhir.DocumentReference{}
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, invalidContribution("unable to parse DocumentReference: %s", err)
+		}
+		if err := validateReferences(document.ResourceType, resourceType, document.Subject, patientRef, documentContext(document), episodeRef); err != nil {
+			return nil, err
+		}
+		if len(document.Type.Coding) == 0 {
+			return nil, invalidContribution("DocumentReference.type is required")
+		}
+		if len(document.Content) == 0 {
+			return nil, invalidContribution("DocumentReference.content is required")
+		}
+		if document.Status == "" {
+			document.Status = "current"
+		}
+
+		document.ID = fhir.ToIDPtr(resourceID)
+		document.Meta = meta
+		document.Subject = &datatypes.Reference{Reference: fhir.ToStringPtr(patientRef)}
+		document.Author = []datatypes.Reference{author}
+		document.Indexed = datatypes.Instant(time.Now().Format(fhir.DateTimeLayout))
+		document.Context = &fhir.DocumentReferenceContext{
+			Related: []fhir.DocumentReferenceContextRelated{{
+				Ref: &datatypes.Reference{Reference: fhir.ToStringPtr(episodeRef)},
+			}},
+		}
+		resource = document
+	default:
+		return nil, invalidContribution("resource type %s can't be created by collaborators", resourceType)
+	}
+
+	if err := service.fhirClient.CreateOrUpdate(ctx, resource); err != nil {
+		return nil, err
+	}
+
+	provenance := fhir.Provenance{
+		Domain: resources.Domain{
+			Base: resources.Base{
+				ID:           fhir.ToIDPtr(uuid.NewString()),
+				ResourceType: "Provenance",
+			},
+		},
+		Target: []datatypes.Reference{
+			{Reference: fhir.ToStringPtr(fmt.Sprintf("%s/%s", resourceType, resourceID))},
+		},
+		Recorded: datatypes.Instant(time.Now().Format(fhir.DateTimeLayout)),
+		Activity: &datatypes.Coding{
+			System: fhir.ToUriPtr("http://hl7.org/fhir/v3/DataOperation"),
+			Code:   fhir.ToCodePtr("CREATE"),
+		},
+		Agent: []fhir.ProvenanceAgent{{WhoReference: &author}},
+	}
+
+	if err := service.fhirClient.CreateOrUpdate(ctx, provenance); err != nil {
+		return nil, fmt.Errorf("unable to record provenance: %w", err)
+	}
+
+	return resource, nil
+}
+
+// validateReferences checks the resource type, the episode the resource refers to and, when supplied, the patient.
+func validateReferences(actualType, expectedType string, subject *datatypes.Reference, patientRef string, context *datatypes.Reference, episodeRef string) error {
+	if actualType != expectedType {
+		return invalidContribution("resourceType must be %s", expectedType)
+	}
+	if subject != nil && subject.Reference != nil && fhir.FromStringPtr(subject.Reference) != patientRef {
+		return invalidContribution("subject must refer to the patient of the episode")
+	}
+	if context == nil || fhir.FromStringPtr(context.Reference) != episodeRef {
+		return invalidContribution("context must refer to %s", episodeRef)
+	}
+	return nil
+}
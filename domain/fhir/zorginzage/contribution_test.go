@@ -0,0 +1,125 @@
+package zorginzage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openapi_types "github.com/deepmap/oapi-codegen/pkg/types"
+	"github.com/monarko/fhirgo/STU3/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/fhirtest"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+)
+
+const collaboratorDID = "did:nuts:collaborator"
+
+func observation(episodeReference, patientReference string) []byte {
+	resource := map[string]interface{}{
+		"resourceType": "Observation",
+		"code":         map[string]interface{}{"coding": []map[string]string{{"system": "http://loinc.org", "code": "8867-4"}}},
+	}
+	if episodeReference != "" {
+		resource["context"] = map[string]string{"reference": episodeReference}
+	}
+	if patientReference != "" {
+		resource["subject"] = map[string]string{"reference": patientReference}
+	}
+	data, _ := json.Marshal(resource)
+	return data
+}
+
+func TestContributionEpisodeID(t *testing.T) {
+	t.Run("Observation", func(t *testing.T) {
+		episodeID, err := ContributionEpisodeID("Observation", observation("EpisodeOfCare/1", ""))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1", episodeID)
+	})
+	t.Run("DocumentReference", func(t *testing.T) {
+		episodeID, err := ContributionEpisodeID("DocumentReference", []byte(`{"resourceType": "DocumentReference", "context": {"related": [{"ref": {"reference": "EpisodeOfCare/1"}}]}}`))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1", episodeID)
+	})
+	t.Run("error - no context", func(t *testing.T) {
+		_, err := ContributionEpisodeID("Observation", observation("", ""))
+
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+	})
+	t.Run("error - context isn't an episode", func(t *testing.T) {
+		_, err := ContributionEpisodeID("Observation", observation("Encounter/1", ""))
+
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+	})
+	t.Run("error - resource type can't be contributed", func(t *testing.T) {
+		_, err := ContributionEpisodeID("Patient", []byte(`{"resourceType": "Patient"}`))
+
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+	})
+}
+
+func TestService_CreateContribution(t *testing.T) {
+	ctx := context.Background()
+	newService := func(t *testing.T) (fhir.Client, Service) {
+		server := httptest.NewServer(fhirtest.NewServer())
+		t.Cleanup(server.Close)
+		client := fhir.NewFactory(fhir.WithURL(server.URL))()
+		service := NewService(client)
+		_, err := service.CreateEpisode(ctx, "patient", types.CreateEpisodeRequest{
+			DossierID: "active",
+			Period:    types.Period{Start: &openapi_types.Date{Time: time.Now()}},
+		})
+		require.NoError(t, err)
+		return client, service
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		client, service := newService(t)
+
+		result, err := service.CreateContribution(ctx, "active", collaboratorDID, "Observation", observation("EpisodeOfCare/active", "Patient/patient"))
+
+		require.NoError(t, err)
+		stored := resources.Observation{}
+		require.NoError(t, client.ReadOne(ctx, "Observation/"+string(*result.(resources.Observation).ID), &stored))
+		assert.Equal(t, "EpisodeOfCare/active", fhir.FromStringPtr(stored.Context.Reference))
+		assert.Equal(t, "Patient/patient", fhir.FromStringPtr(stored.Subject.Reference))
+		assert.Equal(t, collaboratorDID, fhir.FromStringPtr(stored.Performer[0].Identifier.Value))
+		var provenances []fhir.Provenance
+		require.NoError(t, client.ReadMultiple(ctx, "Provenance", nil, &provenances))
+		assert.Len(t, provenances, 1)
+	})
+	t.Run("error - other episode", func(t *testing.T) {
+		_, service := newService(t)
+
+		_, err := service.CreateContribution(ctx, "active", collaboratorDID, "Observation", observation("EpisodeOfCare/other", ""))
+
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+	})
+	t.Run("error - no episode", func(t *testing.T) {
+		_, service := newService(t)
+
+		_, err := service.CreateContribution(ctx, "active", collaboratorDID, "Observation", observation("", ""))
+
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+	})
+	t.Run("error - other patient", func(t *testing.T) {
+		_, service := newService(t)
+
+		_, err := service.CreateContribution(ctx, "active", collaboratorDID, "Observation", observation("EpisodeOfCare/active", "Patient/other"))
+
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+	})
+	t.Run("error - resource type can't be contributed", func(t *testing.T) {
+		_, service := newService(t)
+
+		_, err := service.CreateContribution(ctx, "active", collaboratorDID, "Patient", []byte(`{"resourceType": "Patient"}`))
+
+		assert.ErrorIs(t, err, ErrInvalidContribution)
+	})
+}
@@ -11,10 +11,16 @@ import (
 func ToEpisode(episode *fhir.EpisodeOfCare) *types.Episode {
 	status := types.EpisodeStatus(episode.Status)
 	periodStart := time.Time{}
+	var periodEnd *types2.Date
 	if episode.Period != nil {
 		if episode.Period.Start != nil {
 			periodStart, _ = time.Parse(time.RFC3339, string(*episode.Period.Start))
 		}
+		if episode.Period.End != nil {
+			if end, err := time.Parse(time.RFC3339, string(*episode.Period.End)); err == nil {
+				periodEnd = &types2.Date{Time: end}
+			}
+		}
 	}
 
 	diagnosis := ""
@@ -25,7 +31,7 @@ func ToEpisode(episode *fhir.EpisodeOfCare) *types.Episode {
 	return &types.Episode{
 		Id:        types.ObjectID(fhir.FromIDPtr(episode.ID)),
 		Status:    &status,
-		Period:    types.Period{Start: &types2.Date{Time: periodStart}},
+		Period:    types.Period{Start: &types2.Date{Time: periodStart}, End: periodEnd},
 		Diagnosis: diagnosis,
 	}
 }
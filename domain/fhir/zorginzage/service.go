@@ -17,6 +17,8 @@ const ServiceName = "zorginzage-demo"
 type Service interface {
 	CreateEpisode(ctx context.Context, patientID string, request types.CreateEpisodeRequest) (*fhir.EpisodeOfCare, error)
 	GetEpisode(ctx context.Context, dossierID string) (*fhir.EpisodeOfCare, error)
+	// CreateContribution stores a resource created by a collaborating organization within the episode and returns the stored resource.
+	CreateContribution(ctx context.Context, episodeID, authorDID, resourceType string, data []byte) (interface{}, error)
 }
 
 type service struct {
@@ -47,6 +49,10 @@ func (service *service) CreateEpisode(ctx context.Context, patientID string, req
 		},
 		Status: fhir.EpisodeStatusActive,
 	}
+	if request.Period.End != nil {
+		periodEnd := datatypes.DateTime(request.Period.End.Format(time.RFC3339))
+		episode.Period.End = &periodEnd
+	}
 
 	if err := service.fhirClient.CreateOrUpdate(ctx, episode); err != nil {
 		return nil, err
@@ -0,0 +1,104 @@
+package geo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// places.csv contains the Dutch cities with a care organization, with the zipcode regions (the first two digits of the
+// zipcode) of which the city is the center.
+//
+//go:embed places.csv
+var placesCSV []byte
+
+const earthRadius = 6371.0
+
+// Point is a location on earth.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Distance returns the distance in kilometers to the other point, as the crow flies.
+func (p Point) Distance(other Point) float64 {
+	lat1, lat2 := radians(p.Latitude), radians(other.Latitude)
+	deltaLat, deltaLon := lat2-lat1, radians(other.Longitude-p.Longitude)
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// Places locates cities and zipcodes. It's an estimation: zipcodes are located at the center of their region.
+type Places struct {
+	cities map[string]Point
+	// zipcodeRegions maps the first two digits of zipcodes to the center of their region
+	zipcodeRegions map[string]Point
+}
+
+// NewPlaces returns the Places of the Netherlands.
+func NewPlaces() *Places {
+	places, err := parsePlaces(placesCSV)
+	if err != nil {
+		panic(err)
+	}
+	return places
+}
+
+func parsePlaces(data []byte) (*Places, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = ';'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read places: %w", err)
+	}
+
+	places := &Places{cities: map[string]Point{}, zipcodeRegions: map[string]Point{}}
+	// the first record is the header
+	for _, record := range records[1:] {
+		latitude, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latitude of %s: %w", record[0], err)
+		}
+		longitude, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid longitude of %s: %w", record[0], err)
+		}
+		point := Point{Latitude: latitude, Longitude: longitude}
+		places.cities[normalize(record[0])] = point
+		for _, region := range strings.Fields(record[3]) {
+			places.zipcodeRegions[region] = point
+		}
+	}
+	return places, nil
+}
+
+// City returns the location of the city. The second return value is false if the city is unknown.
+func (p Places) City(name string) (Point, bool) {
+	point, ok := p.cities[normalize(name)]
+	return point, ok
+}
+
+// Zipcode returns the location of the region of the (Dutch) zipcode. The second return value is false if the zipcode is invalid.
+func (p Places) Zipcode(zipcode string) (Point, bool) {
+	zipcode = strings.TrimSpace(zipcode)
+	if len(zipcode) < 4 {
+		return Point{}, false
+	}
+	if _, err := strconv.Atoi(zipcode[:4]); err != nil {
+		return Point{}, false
+	}
+	point, ok := p.zipcodeRegions[zipcode[:2]]
+	return point, ok
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,30 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaces(t *testing.T) {
+	places := NewPlaces()
+
+	t.Run("city", func(t *testing.T) {
+		utrecht, ok := places.City(" utrecht")
+		assert.True(t, ok)
+		denHaag, _ := places.City("'s-Gravenhage")
+		_, ok = places.City("Atlantis")
+		assert.False(t, ok)
+
+		assert.InDelta(t, 57, utrecht.Distance(denHaag), 1)
+	})
+	t.Run("zipcode", func(t *testing.T) {
+		point, ok := places.Zipcode("3511 AB")
+		assert.True(t, ok)
+		utrecht, _ := places.City("Utrecht")
+		assert.Equal(t, utrecht, point)
+
+		_, ok = places.Zipcode("AB")
+		assert.False(t, ok)
+	})
+}
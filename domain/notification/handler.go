@@ -2,17 +2,18 @@ package notification
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/monarko/fhirgo/STU3/resources"
 	"github.com/nuts-foundation/go-did/vc"
+	"github.com/sirupsen/logrus"
 
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer/receiver"
 	"github.com/nuts-foundation/nuts-demo-ehr/http/auth"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
 )
 
 type Notification struct {
@@ -29,9 +30,11 @@ type Handler interface {
 type handler struct {
 	auth                   auth.Service
 	localFHIRClientFactory fhir.Factory
-	transferService        receiver.TransferService
-	registry               registry.OrganizationRegistry
-	vcr                    registry.VerifiableCredentialRegistry
+	// remoteFHIRClientFactory creates clients for the FHIR servers of other care organizations
+	remoteFHIRClientFactory fhir.Factory
+	transferService         receiver.TransferService
+	registry                registry.OrganizationRegistry
+	vcr                     registry.VerifiableCredentialRegistry
 }
 
 func NewHandler(
@@ -40,18 +43,25 @@ func NewHandler(
 	transferReceiverService receiver.TransferService,
 	registry registry.OrganizationRegistry,
 	vcr registry.VerifiableCredentialRegistry,
+	remoteFHIRClientFactory fhir.Factory,
 ) Handler {
 	return &handler{
-		auth:                   auth,
-		localFHIRClientFactory: localFHIRClientFactory,
-		transferService:        transferReceiverService,
-		registry:               registry,
-		vcr:                    vcr,
+		auth:                    auth,
+		localFHIRClientFactory:  localFHIRClientFactory,
+		remoteFHIRClientFactory: remoteFHIRClientFactory,
+		transferService:         transferReceiverService,
+		registry:                registry,
+		vcr:                     vcr,
 	}
 }
 
 // Handle handles an incoming notification about an updated Task for one of its customers.
+// It returns registry.ErrOrganizationNotFound if the sender isn't a trusted care organization.
 func (service *handler) Handle(ctx context.Context, notification Notification) error {
+	if _, err := service.registry.Get(ctx, notification.SenderDID); err != nil {
+		return fmt.Errorf("sender isn't a trusted care organization (did=%s): %w", notification.SenderDID, err)
+	}
+
 	fhirServer, err := service.registry.GetCompoundServiceEndpoint(ctx, notification.SenderDID, transfer.SenderServiceName, "fhir")
 	if err != nil {
 		return fmt.Errorf("error while looking up custodian's FHIR server (did=%s): %w", notification.SenderDID, err)
@@ -73,7 +83,9 @@ func (service *handler) Handle(ctx context.Context, notification Notification) e
 	}
 
 	if len(credentials) == 0 {
-		return errors.New("no NutsAuthorizationCredential found to retrieve the Task resource")
+		// the sender revokes access to the Task when the transfer is completed or cancelled
+		logrus.Infof("No NutsAuthorizationCredential found to retrieve the Task resource, ignoring notification (sender=%s, task=%s)", notification.SenderDID, notification.TaskID)
+		return nil
 	}
 
 	accessToken, err := service.auth.RequestAccessToken(ctx, notification.CustomerDID, notification.SenderDID, "eOverdracht-sender", []vc.VerifiableCredential{credentials[0]}, nil)
@@ -81,11 +93,18 @@ func (service *handler) Handle(ctx context.Context, notification Notification) e
 		return err
 	}
 
+	// Commit here, otherwise reading the Task through the FHIR proxy of this server (when the sender is one of its
+	// customers) will deadlock on the uncommitted tx.
+	tm, _ := sqlUtil.GetTransactionManager(ctx)
+	if err := tm.Commit(); err != nil {
+		return err
+	}
+
 	task := &resources.Task{}
-	client := fhir.NewFactory(fhir.WithURL(fhirServer), fhir.WithAuthToken(accessToken.AccessToken))
+	client := service.remoteFHIRClientFactory(fhir.WithURL(fhirServer), fhir.WithAuthToken(accessToken.AccessToken))
 
 	// FIXME: add query params to filter on the owner so to only process the customer addressed in the notification
-	err = client().ReadOne(ctx, taskPath, &task)
+	err = client.ReadOne(ctx, taskPath, &task)
 	if err != nil {
 		return err
 	}
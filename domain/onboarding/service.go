@@ -0,0 +1,176 @@
+package onboarding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vdr"
+)
+
+// OrganizationCredentialType is the type of the credential that states the name and city of a care organization.
+const OrganizationCredentialType = "NutsOrganizationCredential"
+
+// ErrCityRequired is returned when onboarding a customer without city, which the organization credential requires.
+var ErrCityRequired = errors.New("customer must have a city to issue its organization credential")
+
+// Endpoints are the public URLs of the EHR that are registered as services of the customers.
+type Endpoints struct {
+	// FHIR is the URL of the FHIR proxy, through which other care organizations access the customer's data.
+	FHIR string
+	// TransferNotification receives the notifications of other care organizations about transfers (eOverdracht).
+	TransferNotification string
+	// EpisodeNotification receives the notifications of other care organizations about episodes (zorginzage).
+	EpisodeNotification string
+}
+
+// CompoundService is a service of the customers, with the URLs of its endpoints.
+type CompoundService struct {
+	Type      string
+	Endpoints map[string]string
+}
+
+// Services returns the services the customers need to exchange data with other care organizations. Besides the
+// returned endpoints, every service has an oauth endpoint, at which other care organizations request access tokens.
+func (e Endpoints) Services() []CompoundService {
+	return []CompoundService{
+		{Type: transfer.SenderServiceName, Endpoints: map[string]string{"fhir": e.FHIR}},
+		{Type: transfer.ReceiverServiceName, Endpoints: map[string]string{"notification": e.TransferNotification}},
+		{Type: zorginzage.ServiceName, Endpoints: map[string]string{"fhir": e.FHIR, "notification": e.EpisodeNotification}},
+	}
+}
+
+type Service interface {
+	// Onboard sets the customer up on the Nuts network: it creates its DID, registers its services and issues its
+	// organization credential. Steps that were already done are skipped, so a failed onboarding can be retried.
+	// The DID is stored on the customer as soon as it's created, the customer is also returned when a later step fails.
+	Onboard(ctx context.Context, customerID int) (*types.Customer, error)
+}
+
+type service struct {
+	vdr       client.VDRClient
+	didman    client.DIDManClient
+	vcr       client.VCRClient
+	customers customers.Repository
+	// vendorDID controls the DIDs of the customers and issues their organization credentials
+	vendorDID string
+	endpoints Endpoints
+}
+
+func NewService(vdr client.VDRClient, didman client.DIDManClient, vcr client.VCRClient, customers customers.Repository, vendorDID string, endpoints Endpoints) Service {
+	return &service{
+		vdr:       vdr,
+		didman:    didman,
+		vcr:       vcr,
+		customers: customers,
+		vendorDID: vendorDID,
+		endpoints: endpoints,
+	}
+}
+
+func (s service) Onboard(ctx context.Context, customerID int) (*types.Customer, error) {
+	customer, err := s.customers.FindByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, customers.ErrCustomerNotFound
+	}
+	if customer.City == nil {
+		return nil, ErrCityRequired
+	}
+
+	if customer.Did == nil {
+		if customer, err = s.createDID(ctx, *customer); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.registerServices(ctx, *customer.Did); err != nil {
+		return customer, fmt.Errorf("unable to register services (did=%s): %w", *customer.Did, err)
+	}
+	if err := s.issueOrganizationCredential(ctx, *customer); err != nil {
+		return customer, fmt.Errorf("unable to issue organization credential (did=%s): %w", *customer.Did, err)
+	}
+	return customer, nil
+}
+
+// createDID creates a DID controlled by the vendor, since the customer doesn't have a Nuts node of its own.
+func (s service) createDID(ctx context.Context, customer types.Customer) (*types.Customer, error) {
+	selfControl := false
+	capabilityInvocation := false
+	assertionMethod := true
+	keyAgreement := true
+	document, err := s.vdr.CreateDID(ctx, vdr.DIDCreateRequest{
+		Controllers:          &[]string{s.vendorDID},
+		SelfControl:          &selfControl,
+		CapabilityInvocation: &capabilityInvocation,
+		AssertionMethod:      &assertionMethod,
+		KeyAgreement:         &keyAgreement,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create DID: %w", err)
+	}
+	logrus.Infof("Created DID for customer (customer=%d, did=%s)", customer.Id, document.Id)
+
+	return s.customers.Update(ctx, customer.Id, types.CustomerProperties{
+		Did:          &document.Id,
+		Name:         customer.Name,
+		City:         customer.City,
+		Domain:       customer.Domain,
+		SigningMeans: customer.SigningMeans,
+		Branding:     customer.Branding,
+	})
+}
+
+func (s service) registerServices(ctx context.Context, did string) error {
+	existing, err := s.didman.GetCompoundServices(ctx, did)
+	if err != nil {
+		return err
+	}
+	registered := map[string]bool{}
+	for _, service := range existing {
+		registered[service.Type] = true
+	}
+
+	// access tokens are requested at the oauth endpoint of the vendor's Nuts node
+	oauth := s.vendorDID + "/serviceEndpoint?type=oauth"
+	for _, service := range s.endpoints.Services() {
+		if registered[service.Type] {
+			continue
+		}
+		endpoints := map[string]interface{}{"oauth": oauth}
+		for name, url := range service.Endpoints {
+			endpoints[name] = url
+		}
+		if err := s.didman.AddCompoundService(ctx, did, service.Type, endpoints); err != nil {
+			return fmt.Errorf("%s: %w", service.Type, err)
+		}
+	}
+	return nil
+}
+
+func (s service) issueOrganizationCredential(ctx context.Context, customer types.Customer) error {
+	// the vendor might not be trusted as issuer yet
+	existing, err := s.vcr.GetUntrustedOrganization(ctx, *customer.Did)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	_, err = s.vcr.CreateVC(ctx, OrganizationCredentialType, s.vendorDID, map[string]interface{}{
+		"id": *customer.Did,
+		"organization": map[string]interface{}{
+			"name": customer.Name,
+			"city": *customer.City,
+		},
+	}, nil)
+	return err
+}
@@ -0,0 +1,183 @@
+package onboarding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+const vendorDID = "did:nuts:vendor"
+
+// stubNode implements the VDR, DIDMan and VCR APIs of the Nuts node used for onboarding
+type stubNode struct {
+	mux          sync.Mutex
+	createdDIDs  []map[string]interface{}
+	services     map[string][]map[string]interface{}
+	credentials  []map[string]interface{}
+	failServices bool
+}
+
+func newStubNode(t *testing.T) (*stubNode, client.HTTPClient) {
+	node := &stubNode{services: map[string][]map[string]interface{}{}}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	return node, client.HTTPClient{NutsNodeAddress: server.URL}
+}
+
+func (n *stubNode) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	body := map[string]interface{}{}
+	_ = json.NewDecoder(request.Body).Decode(&body)
+	writeJSON := func(value interface{}) {
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(value)
+	}
+
+	switch {
+	case request.URL.Path == "/internal/vdr/v1/did":
+		n.createdDIDs = append(n.createdDIDs, body)
+		writeJSON(map[string]interface{}{"id": "did:nuts:care-home"})
+	case strings.HasPrefix(request.URL.Path, "/internal/didman/v1/did/") && strings.HasSuffix(request.URL.Path, "/compoundservice"):
+		did := strings.TrimSuffix(strings.TrimPrefix(request.URL.Path, "/internal/didman/v1/did/"), "/compoundservice")
+		if request.Method == http.MethodGet {
+			writeJSON(append([]map[string]interface{}{}, n.services[did]...))
+			return
+		}
+		if n.failServices {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		n.services[did] = append(n.services[did], body)
+		writeJSON(body)
+	case request.URL.Path == "/internal/vcr/v1/vc":
+		n.credentials = append(n.credentials, body)
+		writeJSON(map[string]interface{}{
+			"id":                fmt.Sprintf("%s#%d", body["issuer"], len(n.credentials)),
+			"type":              []interface{}{"VerifiableCredential", body["type"]},
+			"issuer":            body["issuer"],
+			"credentialSubject": body["credentialSubject"],
+		})
+	case request.URL.Path == "/internal/vcr/v1/organization":
+		result := []map[string]interface{}{}
+		for _, credential := range n.credentials {
+			result = append(result, credential["credentialSubject"].(map[string]interface{}))
+		}
+		writeJSON(result)
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestService_Onboard(t *testing.T) {
+	endpoints := Endpoints{
+		FHIR:                 "https://ehr.example.com/fhir",
+		TransferNotification: "https://ehr.example.com/web/external/transfer/notify",
+		EpisodeNotification:  "https://ehr.example.com/web/external/episode/notify",
+	}
+	city := "Utrecht"
+	setup := func(t *testing.T, properties types.CustomerProperties) (*stubNode, Service, *sqlx.DB) {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		db.SetMaxOpenConns(1)
+		repository := customers.NewSQLiteCustomerRepository(db)
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			_, err := repository.Create(ctx, properties)
+			return err
+		})
+		node, nodeClient := newStubNode(t)
+		return node, NewService(nodeClient, nodeClient, nodeClient, repository, vendorDID, endpoints), db
+	}
+	onboard := func(db *sqlx.DB, service Service) (customer *types.Customer, err error) {
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			customer, err = service.Onboard(ctx, 1)
+			return nil
+		})
+		return
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		node, service, db := setup(t, types.CustomerProperties{Name: "Care Home", City: &city})
+
+		customer, err := onboard(db, service)
+
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "did:nuts:care-home", *customer.Did)
+		// the DID is controlled by the vendor
+		if assert.Len(t, node.createdDIDs, 1) {
+			assert.Equal(t, []interface{}{vendorDID}, node.createdDIDs[0]["controllers"])
+			assert.Equal(t, false, node.createdDIDs[0]["selfControl"])
+		}
+		services := node.services["did:nuts:care-home"]
+		if assert.Len(t, services, 3) {
+			assert.Equal(t, "eOverdracht-sender", services[0]["type"])
+			assert.Equal(t, map[string]interface{}{
+				"oauth": "did:nuts:vendor/serviceEndpoint?type=oauth",
+				"fhir":  "https://ehr.example.com/fhir",
+			}, services[0]["serviceEndpoint"])
+			assert.Equal(t, "eOverdracht-receiver", services[1]["type"])
+			assert.Equal(t, "zorginzage-demo", services[2]["type"])
+			assert.Equal(t, endpoints.EpisodeNotification, services[2]["serviceEndpoint"].(map[string]interface{})["notification"])
+		}
+		if assert.Len(t, node.credentials, 1) {
+			assert.Equal(t, "NutsOrganizationCredential", node.credentials[0]["type"])
+			assert.Equal(t, vendorDID, node.credentials[0]["issuer"])
+			assert.Equal(t, map[string]interface{}{
+				"id":           "did:nuts:care-home",
+				"organization": map[string]interface{}{"name": "Care Home", "city": "Utrecht"},
+			}, node.credentials[0]["credentialSubject"])
+		}
+	})
+	t.Run("retry after failure", func(t *testing.T) {
+		node, service, db := setup(t, types.CustomerProperties{Name: "Care Home", City: &city})
+		node.failServices = true
+
+		customer, err := onboard(db, service)
+
+		assert.Error(t, err)
+		// the DID is kept, so it's reused when retrying
+		if assert.NotNil(t, customer) {
+			assert.Equal(t, "did:nuts:care-home", *customer.Did)
+		}
+		assert.Empty(t, node.credentials)
+
+		node.failServices = false
+		_, err = onboard(db, service)
+
+		assert.NoError(t, err)
+		assert.Len(t, node.createdDIDs, 1)
+		assert.Len(t, node.services["did:nuts:care-home"], 3)
+		assert.Len(t, node.credentials, 1)
+
+		// everything is done, so nothing changes
+		_, err = onboard(db, service)
+
+		assert.NoError(t, err)
+		assert.Len(t, node.services["did:nuts:care-home"], 3)
+		assert.Len(t, node.credentials, 1)
+	})
+	t.Run("city is required", func(t *testing.T) {
+		node, service, db := setup(t, types.CustomerProperties{Name: "Care Home"})
+
+		_, err := onboard(db, service)
+
+		assert.ErrorIs(t, err, ErrCityRequired)
+		assert.Empty(t, node.createdDIDs)
+	})
+}
@@ -0,0 +1,94 @@
+package organizations
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+// FavouriteRepository keeps the favourite partners of the customers: the care organizations they often exchange data with.
+type FavouriteRepository interface {
+	// All returns the favourite partners of the customer, ordered by name.
+	All(ctx context.Context, customerID int) ([]types.Organization, error)
+	// Add stores the organization as favourite partner of the customer. Adding it again updates its name and city.
+	Add(ctx context.Context, customerID int, organization types.Organization) error
+	// Remove removes the organization with the given DID from the favourite partners of the customer.
+	Remove(ctx context.Context, customerID int, did string) error
+}
+
+type sqlFavourite struct {
+	CustomerID int    `db:"customer_id"`
+	DID        string `db:"did"`
+	Name       string `db:"name"`
+	City       string `db:"city"`
+}
+
+const favouriteSchema = `
+	CREATE TABLE IF NOT EXISTS favourite_organization (
+		customer_id integer NOT NULL,
+		did varchar(200) NOT NULL,
+		name varchar(200) NOT NULL,
+		city varchar(200) NOT NULL,
+		PRIMARY KEY (customer_id, did)
+	);
+`
+
+type SQLiteFavouriteRepository struct{}
+
+func NewSQLiteFavouriteRepository(db *sqlx.DB) *SQLiteFavouriteRepository {
+	if db == nil {
+		panic("missing db for FavouriteRepository")
+	}
+	tx, _ := db.Beginx()
+	tx.MustExec(favouriteSchema)
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	return &SQLiteFavouriteRepository{}
+}
+
+func (r SQLiteFavouriteRepository) All(ctx context.Context, customerID int) ([]types.Organization, error) {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dbFavourites := []sqlFavourite{}
+	const query = `SELECT * FROM favourite_organization WHERE customer_id = ? ORDER BY name ASC`
+	if err := tx.SelectContext(ctx, &dbFavourites, query, customerID); err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Organization, len(dbFavourites))
+	for idx, dbFavourite := range dbFavourites {
+		result[idx] = types.Organization{Did: dbFavourite.DID, Name: dbFavourite.Name, City: dbFavourite.City}
+	}
+	return result, nil
+}
+
+func (r SQLiteFavouriteRepository) Add(ctx context.Context, customerID int, organization types.Organization) error {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	const query = `INSERT OR REPLACE INTO favourite_organization (customer_id, did, name, city) VALUES (:customer_id, :did, :name, :city)`
+	_, err = tx.NamedExecContext(ctx, query, sqlFavourite{
+		CustomerID: customerID,
+		DID:        organization.Did,
+		Name:       organization.Name,
+		City:       organization.City,
+	})
+	return err
+}
+
+func (r SQLiteFavouriteRepository) Remove(ctx context.Context, customerID int, did string) error {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM favourite_organization WHERE customer_id = ? AND did = ?`, customerID, did)
+	return err
+}
@@ -0,0 +1,115 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/geo"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+)
+
+// ErrUnknownZipcode is returned when searching by distance to a zipcode that can't be located.
+var ErrUnknownZipcode = errors.New("unknown zipcode")
+
+// ErrZipcodeRequired is returned when searching within a distance without zipcode.
+var ErrZipcodeRequired = errors.New("zipcode is required to search within a distance")
+
+type SearchParams struct {
+	Query string
+	// DIDServiceType only returns organizations with a service of the type, if set.
+	DIDServiceType *string
+	// Zipcode of the patient, if set the distance to the organizations is estimated.
+	Zipcode *string
+	// MaxDistance in kilometers to the zipcode. Organizations of which the distance can't be estimated are left out.
+	MaxDistance *int
+	// FavouritesOnly only returns the favourite partners of the customer.
+	FavouritesOnly bool
+}
+
+type SearchService interface {
+	// Search returns the organizations matching the params, except for the customer itself. Favourite partners are
+	// ranked first, then the organizations are ranked by distance (closest first, unknown last) and name.
+	Search(ctx context.Context, customer types.Customer, params SearchParams) ([]types.Organization, error)
+}
+
+type searchService struct {
+	registry   registry.OrganizationRegistry
+	favourites FavouriteRepository
+	places     *geo.Places
+}
+
+func NewSearchService(registry registry.OrganizationRegistry, favourites FavouriteRepository, places *geo.Places) SearchService {
+	return &searchService{
+		registry:   registry,
+		favourites: favourites,
+		places:     places,
+	}
+}
+
+func (s searchService) Search(ctx context.Context, customer types.Customer, params SearchParams) ([]types.Organization, error) {
+	var origin *geo.Point
+	if params.Zipcode != nil && *params.Zipcode != "" {
+		point, ok := s.places.Zipcode(*params.Zipcode)
+		if !ok {
+			return nil, ErrUnknownZipcode
+		}
+		origin = &point
+	} else if params.MaxDistance != nil {
+		return nil, ErrZipcodeRequired
+	}
+
+	favourites, err := s.favourites.All(ctx, customer.Id)
+	if err != nil {
+		return nil, err
+	}
+	favouriteDIDs := map[string]bool{}
+	for _, favourite := range favourites {
+		favouriteDIDs[favourite.Did] = true
+	}
+
+	organizations, err := s.registry.Search(ctx, params.Query, params.DIDServiceType)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []types.Organization{}
+	for _, organization := range organizations {
+		// Hide our own organization
+		if customer.Did != nil && organization.Did == *customer.Did {
+			continue
+		}
+		favourite := favouriteDIDs[organization.Did]
+		if params.FavouritesOnly && !favourite {
+			continue
+		}
+		organization.Favourite = &favourite
+		if origin != nil {
+			if point, ok := s.places.City(organization.City); ok {
+				distance := int(math.Round(origin.Distance(point)))
+				organization.Distance = &distance
+			}
+			if params.MaxDistance != nil && (organization.Distance == nil || *organization.Distance > *params.MaxDistance) {
+				continue
+			}
+		}
+		results = append(results, organization)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if *a.Favourite != *b.Favourite {
+			return *a.Favourite
+		}
+		if (a.Distance == nil) != (b.Distance == nil) {
+			return a.Distance != nil
+		}
+		if a.Distance != nil && *a.Distance != *b.Distance {
+			return *a.Distance < *b.Distance
+		}
+		return a.Name < b.Name
+	})
+	return results, nil
+}
@@ -0,0 +1,107 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/geo"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+// stubRegistry returns all its organizations on every search
+type stubRegistry struct {
+	registry.OrganizationRegistry
+	organizations []types.Organization
+}
+
+func (s stubRegistry) Search(_ context.Context, _ string, _ *string) ([]types.Organization, error) {
+	return s.organizations, nil
+}
+
+func TestSearchService_Search(t *testing.T) {
+	customerDID := "did:nuts:care-home"
+	customer := types.Customer{Id: 1, Did: &customerDID}
+	organizations := []types.Organization{
+		{Did: customerDID, Name: "Care Home", City: "Utrecht"},
+		{Did: "did:nuts:groningen", Name: "Hospital Groningen", City: "Groningen"},
+		{Did: "did:nuts:amersfoort", Name: "Hospital Amersfoort", City: "Amersfoort"},
+		{Did: "did:nuts:atlantis", Name: "Atlantis Care", City: "Atlantis"},
+		{Did: "did:nuts:utrecht", Name: "Hospital Utrecht", City: "Utrecht"},
+	}
+	zipcode := "3511 AB"
+	search := func(params SearchParams, favourites ...string) ([]string, error) {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		db.SetMaxOpenConns(1)
+		repository := NewSQLiteFavouriteRepository(db)
+		service := NewSearchService(stubRegistry{organizations: organizations}, repository, geo.NewPlaces())
+
+		var results []types.Organization
+		err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			for _, organization := range organizations {
+				for _, favourite := range favourites {
+					if organization.Did == favourite {
+						_ = repository.Add(ctx, customer.Id, organization)
+					}
+				}
+			}
+			var err error
+			results, err = service.Search(ctx, customer, params)
+			return err
+		})
+		names := []string{}
+		for _, result := range results {
+			names = append(names, result.Name)
+		}
+		return names, err
+	}
+
+	t.Run("ranked by name", func(t *testing.T) {
+		names, err := search(SearchParams{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Atlantis Care", "Hospital Amersfoort", "Hospital Groningen", "Hospital Utrecht"}, names)
+	})
+	t.Run("ranked by distance", func(t *testing.T) {
+		names, err := search(SearchParams{Zipcode: &zipcode})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Hospital Utrecht", "Hospital Amersfoort", "Hospital Groningen", "Atlantis Care"}, names)
+	})
+	t.Run("favourites first", func(t *testing.T) {
+		names, err := search(SearchParams{Zipcode: &zipcode}, "did:nuts:groningen")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Hospital Groningen", "Hospital Utrecht", "Hospital Amersfoort", "Atlantis Care"}, names)
+	})
+	t.Run("favourites only", func(t *testing.T) {
+		names, err := search(SearchParams{FavouritesOnly: true}, "did:nuts:groningen", "did:nuts:atlantis")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Atlantis Care", "Hospital Groningen"}, names)
+	})
+	t.Run("within distance", func(t *testing.T) {
+		maxDistance := 50
+		names, err := search(SearchParams{Zipcode: &zipcode, MaxDistance: &maxDistance})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Hospital Utrecht", "Hospital Amersfoort"}, names)
+	})
+	t.Run("within distance without zipcode", func(t *testing.T) {
+		maxDistance := 50
+		_, err := search(SearchParams{MaxDistance: &maxDistance})
+
+		assert.ErrorIs(t, err, ErrZipcodeRequired)
+	})
+	t.Run("unknown zipcode", func(t *testing.T) {
+		unknown := "AB"
+		_, err := search(SearchParams{Zipcode: &unknown})
+
+		assert.ErrorIs(t, err, ErrUnknownZipcode)
+	})
+}
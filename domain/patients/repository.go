@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
 )
 
@@ -81,8 +83,11 @@ func (f Factory) NewPatientWithAvatar(properties types.PatientProperties) (*type
 	url.RawQuery = q.Encode()
 	resp, err := client.Get(url.String())
 	if err != nil {
-		return patient, err
+		// the avatar is optional, so the patient is created without one when FakeFace isn't reachable
+		logrus.Warnf("Unable to get avatar for patient: %v", err)
+		return patient, nil
 	}
+	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return patient, err
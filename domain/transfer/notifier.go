@@ -1,13 +1,14 @@
 package transfer
 
 import (
+	"crypto/tls"
 	"fmt"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/labstack/gommon/log"
 )
 
-// Notifier defines the API for notifying a remote care organization that an eOverdracht FHIR task has been updated,
+// Notifier defines the API for notifying a remote care organization that a FHIR resource (e.g. an eOverdracht Task) has been updated.
 type Notifier interface {
 	// Notify sends a notification to the given endpoint.
 	Notify(token, endpoint string) error
@@ -16,21 +17,27 @@ type Notifier interface {
 // FireAndForgetNotifier is a notifier that is optimistic about the receiver's availability.
 // It just sends the notification and assumes the receiver is available.
 type FireAndForgetNotifier struct {
+	// TLSConfig is used to connect to the receiver, e.g. to present the client certificate. If nil, the defaults are used.
+	TLSConfig *tls.Config
 }
 
 func (f FireAndForgetNotifier) Notify(token, endpoint string) error {
-	response, err := resty.New().
+	client := resty.New()
+	if f.TLSConfig != nil {
+		client.SetTLSClientConfig(f.TLSConfig)
+	}
+	response, err := client.
 		R().
 		SetBody([]byte{}).
 		SetAuthToken(token).
 		Post(endpoint)
 	if err != nil {
-		return fmt.Errorf("unable to send eOverdracht notification (url=%s): %w", endpoint, err)
+		return fmt.Errorf("unable to send notification (url=%s): %w", endpoint, err)
 	}
 
 	if !response.IsSuccess() {
 		log.Warnf("Server response: %s", response.String())
-		return fmt.Errorf("eOverdracht notification endpoint returned non-OK error code (url=%s,status-code=%d)", endpoint, response.StatusCode())
+		return fmt.Errorf("notification endpoint returned non-OK error code (url=%s,status-code=%d)", endpoint, response.StatusCode())
 	}
 
 	return nil
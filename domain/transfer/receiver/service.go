@@ -15,6 +15,7 @@ import (
 	"github.com/nuts-foundation/nuts-demo-ehr/http/auth"
 	auth2 "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
 )
 
 type TransferService interface {
@@ -29,20 +30,23 @@ type service struct {
 	notifier               transfer.Notifier
 	auth                   auth.Service
 	localFHIRClientFactory fhir.Factory // client for interacting with the local FHIR server
-	customerRepo           customers.Repository
-	registry               registry.OrganizationRegistry
-	vcr                    registry.VerifiableCredentialRegistry
+	// remoteFHIRClientFactory creates clients for the FHIR servers of other care organizations
+	remoteFHIRClientFactory fhir.Factory
+	customerRepo            customers.Repository
+	registry                registry.OrganizationRegistry
+	vcr                     registry.VerifiableCredentialRegistry
 }
 
-func NewTransferService(authService auth.Service, localFHIRClientFactory fhir.Factory, transferRepository TransferRepository, customerRepository customers.Repository, organizationRegistry registry.OrganizationRegistry, vcr registry.VerifiableCredentialRegistry) TransferService {
+func NewTransferService(authService auth.Service, localFHIRClientFactory fhir.Factory, transferRepository TransferRepository, customerRepository customers.Repository, organizationRegistry registry.OrganizationRegistry, vcr registry.VerifiableCredentialRegistry, remoteFHIRClientFactory fhir.Factory, notifier transfer.Notifier) TransferService {
 	return &service{
-		auth:                   authService,
-		localFHIRClientFactory: localFHIRClientFactory,
-		transferRepo:           transferRepository,
-		customerRepo:           customerRepository,
-		registry:               organizationRegistry,
-		vcr:                    vcr,
-		notifier:               transfer.FireAndForgetNotifier{},
+		auth:                    authService,
+		localFHIRClientFactory:  localFHIRClientFactory,
+		remoteFHIRClientFactory: remoteFHIRClientFactory,
+		transferRepo:            transferRepository,
+		customerRepo:            customerRepository,
+		registry:                organizationRegistry,
+		vcr:                     vcr,
+		notifier:                notifier,
 	}
 }
 
@@ -52,7 +56,7 @@ func (s service) CreateOrUpdate(ctx context.Context, status string, customerID i
 }
 
 func (s service) UpdateTransferRequestState(ctx context.Context, customerID int, requesterDID, fhirTaskID string, newState string) error {
-	customer, err := s.customerRepo.FindByID(customerID)
+	customer, err := s.customerRepo.FindByID(ctx, customerID)
 	if err != nil || customer.Did == nil {
 		return err
 	}
@@ -76,12 +80,9 @@ func (s service) UpdateTransferRequestState(ctx context.Context, customerID int,
 		if err != nil {
 			return err
 		}
-		// update was a success. Get the remote task again and update the local transfer_request
-		task, err = fhirService.GetTask(ctx, fhirTaskID)
-		if err != nil {
-			return err
-		}
-		_, err = s.transferRepo.CreateOrUpdate(ctx, task.Status, fhirTaskID, customerID, requesterDID)
+		// update was a success, so update the local transfer_request. The remote task isn't read again, since the
+		// sender revokes access to it when the transfer is completed.
+		_, err = s.transferRepo.CreateOrUpdate(ctx, newState, fhirTaskID, customerID, requesterDID)
 		if err != nil {
 			return fmt.Errorf("could update incomming transfers with new state")
 		}
@@ -94,7 +95,7 @@ func (s service) UpdateTransferRequestState(ctx context.Context, customerID int,
 func (s service) GetTransferRequest(ctx context.Context, customerID int, requesterDID string, identity auth2.VerifiablePresentation, fhirTaskID string) (*types.TransferRequest, error) {
 	const getTransferRequestErr = "unable to get transferRequest: %w"
 
-	customer, err := s.customerRepo.FindByID(customerID)
+	customer, err := s.customerRepo.FindByID(ctx, customerID)
 	if err != nil || customer.Did == nil {
 		return nil, fmt.Errorf("unable to find customer: %w", err)
 	}
@@ -153,6 +154,18 @@ func (s service) GetTransferRequest(ctx context.Context, customerID int, request
 }
 
 func (s service) getRemoteFHIRClient(ctx context.Context, authorizerDID string, localRequesterDID string, resource string, identity *auth2.VerifiablePresentation) (fhir.Client, error) {
+	// Commit here, otherwise requests to the FHIR proxy of this server (when the sender is one of its customers)
+	// will deadlock on the uncommitted tx.
+	tm, _ := sqlUtil.GetTransactionManager(ctx)
+	if err := tm.Commit(); err != nil {
+		return nil, err
+	}
+
+	// data is only exchanged with trusted care organizations
+	if _, err := s.registry.Get(ctx, authorizerDID); err != nil {
+		return nil, fmt.Errorf("authorizer isn't a trusted care organization (did=%s): %w", authorizerDID, err)
+	}
+
 	fhirServer, err := s.registry.GetCompoundServiceEndpoint(ctx, authorizerDID, transfer.SenderServiceName, "fhir")
 	if err != nil {
 		return nil, fmt.Errorf("error while looking up authorizer's FHIR server (did=%s): %w", authorizerDID, err)
@@ -160,6 +173,7 @@ func (s service) getRemoteFHIRClient(ctx context.Context, authorizerDID string,
 
 	searchParams := registry.VCRSearchParams{
 		PurposeOfUse: transfer.SenderServiceName,
+		Issuer:       authorizerDID,
 		SubjectID:    localRequesterDID,
 		ResourcePath: resource,
 	}
@@ -187,5 +201,5 @@ func (s service) getRemoteFHIRClient(ctx context.Context, authorizerDID string,
 		return nil, err
 	}
 
-	return fhir.NewFactory(fhir.WithURL(fhirServer), fhir.WithAuthToken(accessToken.AccessToken))(), nil
+	return s.remoteFHIRClientFactory(fhir.WithURL(fhirServer), fhir.WithAuthToken(accessToken.AccessToken)), nil
 }
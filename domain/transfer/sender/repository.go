@@ -27,7 +27,11 @@ type TransferRepository interface {
 	// The status will be set to REQUESTED_STATE.
 	// It fails when their exists another domain.TransferNegotiation for this transfer with the same organisationDID and
 	// a status other than CANCELLED_STATE.
-	CreateNegotiation(ctx context.Context, customerID int, transferID, organizationDID string, transferDate time.Time, taskID string) (*types.TransferNegotiation, error)
+	// The credentialID is the ID of the NutsAuthorizationCredential issued to the organisation for the negotiation.
+	CreateNegotiation(ctx context.Context, customerID int, transferID, organizationDID string, transferDate time.Time, taskID, credentialID string) (*types.TransferNegotiation, error)
+
+	// UpdateNegotiationCredential stores the ID of the NutsAuthorizationCredential that replaces the one issued for the negotiation.
+	UpdateNegotiationCredential(ctx context.Context, customerID int, negotiationID, credentialID string) (*types.TransferNegotiation, error)
 
 	// ProposeAlternateDate updates the date on the domain.TransferNegotiation indicated by the negotiationID.
 	// It updates the status to ON_HOLD_STATE
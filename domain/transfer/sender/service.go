@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/avast/retry-go/v4"
+	openapi_types "github.com/deepmap/oapi-codegen/pkg/types"
 	"strings"
 	"time"
 
@@ -43,6 +44,10 @@ type TransferService interface {
 
 	// UpdateTaskState updates the Task resource. It updates the local DB, checks the statemachine, updates the FHIR record and sends a notification.
 	UpdateTaskState(ctx context.Context, customer types.Customer, taskID string, newState string) error
+
+	// UpdateTransferDate changes the date of the transfer. The authorization credentials of its open negotiations are
+	// re-issued, so they expire relative to the new date.
+	UpdateTransferDate(ctx context.Context, customerID int, transferID string, date time.Time) error
 }
 
 type service struct {
@@ -55,9 +60,11 @@ type service struct {
 	registry               registry.OrganizationRegistry
 	vcr                    registry.VerifiableCredentialRegistry
 	notifier               transfer.Notifier
+	// expiration determines when the authorization credentials expire, relative to the transfer date
+	expiration registry.ExpirationPolicy
 }
 
-func NewTransferService(authService auth.Service, localFHIRClientFactory fhir.Factory, transferRepository TransferRepository, customerRepository customers.Repository, dossierRepo dossier.Repository, patientRepo patients.Repository, organizationRegistry registry.OrganizationRegistry, vcr registry.VerifiableCredentialRegistry) TransferService {
+func NewTransferService(authService auth.Service, localFHIRClientFactory fhir.Factory, transferRepository TransferRepository, customerRepository customers.Repository, dossierRepo dossier.Repository, patientRepo patients.Repository, organizationRegistry registry.OrganizationRegistry, vcr registry.VerifiableCredentialRegistry, notifier transfer.Notifier, expiration registry.ExpirationPolicy) TransferService {
 	return &service{
 		auth:                   authService,
 		localFHIRClientFactory: localFHIRClientFactory,
@@ -67,7 +74,8 @@ func NewTransferService(authService auth.Service, localFHIRClientFactory fhir.Fa
 		patientRepo:            patientRepo,
 		registry:               organizationRegistry,
 		vcr:                    vcr,
-		notifier:               transfer.FireAndForgetNotifier{},
+		notifier:               notifier,
+		expiration:             expiration,
 	}
 }
 
@@ -100,7 +108,7 @@ func (s service) GetTransferByID(ctx context.Context, customerID int, transferID
 		return types.Transfer{}, err
 	}
 
-	customer, err := s.customerRepo.FindByID(customerID)
+	customer, err := s.customerRepo.FindByID(ctx, customerID)
 	if err != nil || customer.Did == nil {
 		return types.Transfer{}, err
 	}
@@ -129,7 +137,7 @@ func (s service) GetTransferByID(ctx context.Context, customerID int, transferID
 
 // CreateNegotiation creates a new negotiation(FHIR Task) for a specific transfer and sends the other party a notification.
 func (s service) CreateNegotiation(ctx context.Context, customerID int, transferID, organizationDID string) (*types.TransferNegotiation, error) {
-	customer, err := s.customerRepo.FindByID(customerID)
+	customer, err := s.customerRepo.FindByID(ctx, customerID)
 	if err != nil {
 		return nil, err
 	}
@@ -201,18 +209,19 @@ func (s service) CreateNegotiation(ctx context.Context, customerID int, transfer
 			})
 		}
 
-		if err := s.vcr.CreateAuthorizationCredential(ctx, *customer.Did, &credential.NutsAuthorizationCredentialSubject{
+		credentialID, err := s.vcr.CreateAuthorizationCredential(ctx, *customer.Did, &credential.NutsAuthorizationCredentialSubject{
 			ID: organizationDID,
 			LegalBase: credential.LegalBase{
 				ConsentType: "implied",
 			},
 			PurposeOfUse: transfer.SenderServiceName,
 			Resources:    authorizedResources,
-		}); err != nil {
+		}, s.expirationDate(dbTransfer.TransferDate.Time))
+		if err != nil {
 			return nil, err
 		}
 
-		negotiation, err = s.transferRepo.CreateNegotiation(ctx, customerID, transferID, organizationDID, dbTransfer.TransferDate.Time, transferTask.ID)
+		negotiation, err = s.transferRepo.CreateNegotiation(ctx, customerID, transferID, organizationDID, dbTransfer.TransferDate.Time, transferTask.ID, credentialID)
 		if err != nil {
 			return nil, err
 		}
@@ -292,7 +301,7 @@ func (s service) ConfirmNegotiation(ctx context.Context, customerID int, transfe
 		}
 
 		// retrieve customer
-		if customer, err = s.customerRepo.FindByID(customerID); err != nil {
+		if customer, err = s.customerRepo.FindByID(ctx, customerID); err != nil {
 			return nil, err
 		}
 
@@ -331,7 +340,7 @@ func (s service) ConfirmNegotiation(ctx context.Context, customerID int, transfe
 		}
 
 		// Revoke the old AuthorizationCredential for the Task and AdvanceNotice
-		if err = s.vcr.RevokeAuthorizationCredential(ctx, transfer.SenderServiceName, negotiation.OrganizationDID, advanceNoticePath); err != nil {
+		if err = s.revokeAuthorizationCredential(ctx, *negotiation, advanceNoticePath); err != nil {
 			return nil, fmt.Errorf("unable to confirm negotiation: could not revoke advance notice authorization credential: %w", err)
 		}
 
@@ -364,16 +373,20 @@ func (s service) ConfirmNegotiation(ctx context.Context, customerID int, transfe
 		}
 
 		// Create a new AuthorizationCredential for the Task, AdvanceNotice and NursingHandoff
-		if err = s.vcr.CreateAuthorizationCredential(ctx, *customer.Did, &credential.NutsAuthorizationCredentialSubject{
+		credentialID, err := s.vcr.CreateAuthorizationCredential(ctx, *customer.Did, &credential.NutsAuthorizationCredentialSubject{
 			ID: negotiation.OrganizationDID,
 			LegalBase: credential.LegalBase{
 				ConsentType: "implied",
 			},
 			PurposeOfUse: transfer.SenderServiceName,
 			Resources:    authorizedResources,
-		}); err != nil {
+		}, s.expirationDate(dbTransfer.TransferDate.Time))
+		if err != nil {
 			return nil, fmt.Errorf("unable to confirm negotiation: could not create authorization credential: %w", err)
 		}
+		if negotiation, err = s.transferRepo.UpdateNegotiationCredential(ctx, customerID, negotiationID, credentialID); err != nil {
+			return nil, err
+		}
 
 		notifications = append(notifications, &notification{
 			customer:        customer,
@@ -409,7 +422,8 @@ func (s service) CancelNegotiation(ctx context.Context, customerID int, transfer
 	}
 
 	// update DB, Task and credential state
-	negotiation, notification, err := s.cancelNegotiation(ctx, customerID, negotiationID, dbTransfer.FhirAdvanceNoticeComposition)
+	advanceNoticePath := fmt.Sprintf("/Composition/%s", dbTransfer.FhirAdvanceNoticeComposition)
+	negotiation, notification, err := s.cancelNegotiation(ctx, customerID, negotiationID, advanceNoticePath)
 	if err != nil {
 		return nil, err
 	}
@@ -496,7 +510,7 @@ func (s service) completeTask(ctx context.Context, customer types.Customer, nego
 		// reconstruct composition path
 		compositionPath := fmt.Sprintf("/Composition/%s", *transferRecord.FhirNursingHandoffComposition)
 		// revoke authorization credential
-		if err = s.vcr.RevokeAuthorizationCredential(ctx, transfer.SenderServiceName, negotiation.OrganizationDID, compositionPath); err != nil {
+		if err = s.revokeAuthorizationCredential(ctx, *negotiation, compositionPath); err != nil {
 			return nil, err
 		}
 
@@ -521,6 +535,103 @@ func (s service) completeTask(ctx context.Context, customer types.Customer, nego
 	return err
 }
 
+func (s service) UpdateTransferDate(ctx context.Context, customerID int, transferID string, date time.Time) error {
+	customer, err := s.customerRepo.FindByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.transferRepo.Update(ctx, customerID, transferID, func(dbTransfer *types.Transfer) (*types.Transfer, error) {
+		if dbTransfer.TransferDate.Time.Equal(date) {
+			return dbTransfer, nil
+		}
+		dbTransfer.TransferDate = openapi_types.Date{Time: date}
+		// without a DID the customer can't have issued credentials
+		if customer.Did == nil {
+			return dbTransfer, nil
+		}
+
+		negotiations, err := s.transferRepo.ListNegotiations(ctx, customerID, transferID)
+		if err != nil {
+			return nil, err
+		}
+		for _, negotiation := range negotiations {
+			if negotiation.Status == transfer.CancelledState || negotiation.Status == transfer.CompletedState {
+				continue
+			}
+			if err := s.reissueAuthorizationCredentials(ctx, customerID, *customer.Did, negotiation, date); err != nil {
+				return nil, fmt.Errorf("unable to re-issue authorization credential (negotiation=%s): %w", negotiation.Id, err)
+			}
+		}
+		return dbTransfer, nil
+	})
+	return err
+}
+
+// reissueAuthorizationCredentials replaces the authorization credentials of the negotiation by ones that expire relative
+// to the transfer date. The new credential is issued before the old one is revoked, so the access isn't interrupted.
+func (s service) reissueAuthorizationCredentials(ctx context.Context, customerID int, customerDID string, negotiation types.TransferNegotiation, transferDate time.Time) error {
+	credentials, err := s.vcr.FindIssuedAuthorizationCredentials(ctx, &registry.VCRSearchParams{
+		Issuer:       customerDID,
+		SubjectID:    negotiation.OrganizationDID,
+		PurposeOfUse: transfer.SenderServiceName,
+		ResourcePath: fmt.Sprintf("/Task/%s", negotiation.TaskID),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, authCredential := range credentials {
+		var subjects []credential.NutsAuthorizationCredentialSubject
+		if err := authCredential.UnmarshalCredentialSubject(&subjects); err != nil {
+			return fmt.Errorf("invalid content for NutsAuthorizationCredential credentialSubject: %w", err)
+		}
+		if len(subjects) == 0 || authCredential.ID == nil || !issuedFor(negotiation, authCredential.ID.String(), subjects[0]) {
+			continue
+		}
+		credentialID, err := s.vcr.CreateAuthorizationCredential(ctx, customerDID, &subjects[0], s.expirationDate(transferDate))
+		if err != nil {
+			return err
+		}
+		if _, err := s.transferRepo.UpdateNegotiationCredential(ctx, customerID, string(negotiation.Id), credentialID); err != nil {
+			return err
+		}
+		if err := s.vcr.RevokeCredential(ctx, authCredential.ID.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// issuedFor returns whether the credential was issued for the negotiation. Credentials of negotiations stored before
+// their credential ID was kept are recognized by the exact path of the negotiation's Task.
+func issuedFor(negotiation types.TransferNegotiation, credentialID string, subject credential.NutsAuthorizationCredentialSubject) bool {
+	if negotiation.CredentialID != nil {
+		return credentialID == *negotiation.CredentialID
+	}
+	taskPath := fmt.Sprintf("/Task/%s", negotiation.TaskID)
+	for _, resource := range subject.Resources {
+		if resource.Path == taskPath {
+			return true
+		}
+	}
+	return false
+}
+
+// revokeAuthorizationCredential revokes the authorization credential issued for the negotiation. Negotiations stored
+// before their credential ID was kept fall back to searching the credentials that contain the resourcePath.
+func (s service) revokeAuthorizationCredential(ctx context.Context, negotiation types.TransferNegotiation, resourcePath string) error {
+	if negotiation.CredentialID != nil {
+		return s.vcr.RevokeCredential(ctx, *negotiation.CredentialID)
+	}
+	return s.vcr.RevokeAuthorizationCredential(ctx, transfer.SenderServiceName, negotiation.OrganizationDID, resourcePath)
+}
+
+// expirationDate returns when an authorization credential for a transfer on the date expires.
+func (s service) expirationDate(transferDate time.Time) *time.Time {
+	return s.expiration.ExpirationDate(&transferDate, time.Now())
+}
+
 type notification struct {
 	customer        *types.Customer
 	organizationDID string
@@ -542,13 +653,13 @@ func (s service) cancelNegotiation(ctx context.Context, customerID int, negotiat
 		return nil, nil, err
 	}
 
-	// revoke credential, find by AdvanceNotice
-	if err = s.vcr.RevokeAuthorizationCredential(ctx, transfer.SenderServiceName, negotiation.OrganizationDID, advanceNoticePath); err != nil {
+	// revoke credential, legacy negotiations are found by AdvanceNotice
+	if err = s.revokeAuthorizationCredential(ctx, *negotiation, advanceNoticePath); err != nil {
 		return nil, nil, err
 	}
 
 	// create notification
-	customer, err := s.customerRepo.FindByID(customerID)
+	customer, err := s.customerRepo.FindByID(ctx, customerID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -28,13 +28,14 @@ type sqlTransfer struct {
 }
 
 type sqlNegotiation struct {
-	ID              string    `db:"id"`
-	TransferID      string    `db:"transfer_id"`
-	OrganizationDID string    `db:"organization_did"`
-	CustomerID      int       `db:"customer_id"`
-	Date            time.Time `db:"date"`
-	Status          string    `db:"status"`
-	TaskID          string    `db:"task_id"`
+	ID              string         `db:"id"`
+	TransferID      string         `db:"transfer_id"`
+	OrganizationDID string         `db:"organization_did"`
+	CustomerID      int            `db:"customer_id"`
+	Date            time.Time      `db:"date"`
+	Status          string         `db:"status"`
+	TaskID          string         `db:"task_id"`
+	CredentialID    sql.NullString `db:"credential_id"`
 }
 
 func (dbNegotiation sqlNegotiation) MarshalToDomainNegotiation() (*types.TransferNegotiation, error) {
@@ -45,6 +46,7 @@ func (dbNegotiation sqlNegotiation) MarshalToDomainNegotiation() (*types.Transfe
 		TransferDate:              openapi_types.Date{Time: dbNegotiation.Date},
 		TransferID:                types.ObjectID(dbNegotiation.TransferID),
 		TaskID:                    dbNegotiation.TaskID,
+		CredentialID:              fromNullString(dbNegotiation.CredentialID),
 	}, nil
 }
 
@@ -57,6 +59,7 @@ func (dbNegotiation *sqlNegotiation) UnmarshalFromDomainNegotiation(customerID i
 		Date:            negotiation.TransferDate.Time,
 		Status:          string(negotiation.Status),
 		TaskID:          negotiation.TaskID,
+		CredentialID:    toNullString(negotiation.CredentialID),
 	}
 	return nil
 }
@@ -148,6 +151,7 @@ const negotiationSchema = `
 		date DATETIME DEFAULT NULL,
 		status char(10) NOT NULL DEFAULT 'requested',
 		task_id char(36) NOT NULL,
+		credential_id varchar(300) NULL,
 		PRIMARY KEY (id),
 		FOREIGN KEY (transfer_id) REFERENCES transfer(id)
 	);
@@ -164,6 +168,10 @@ func NewTransferRepository(db *sqlx.DB) *SQLiteTransferRepository {
 	tx, _ := db.Beginx()
 	tx.MustExec(transferSchema)
 	tx.MustExec(negotiationSchema)
+	// Negotiations stored before the credential ID was kept don't have the column yet
+	if err := sqlUtil.AddColumn(tx, "transfer_negotiation", "credential_id", "varchar(300) NULL"); err != nil {
+		panic(err)
+	}
 	if err := tx.Commit(); err != nil {
 		panic(err)
 	}
@@ -422,7 +430,8 @@ func (r SQLiteTransferRepository) updateNegotiation(ctx context.Context, tx *sql
 	const query = `
 	UPDATE transfer_negotiation SET
 		date = :date,
-		status = :status
+		status = :status,
+		credential_id = :credential_id
 	WHERE customer_id = :customer_id AND id = :id
 `
 
@@ -438,7 +447,26 @@ func (r SQLiteTransferRepository) updateNegotiation(ctx context.Context, tx *sql
 	return nil
 }
 
-func (r SQLiteTransferRepository) CreateNegotiation(ctx context.Context, customerID int, transferID, organizationDID string, transferDate time.Time, taskID string) (*types.TransferNegotiation, error) {
+func (r SQLiteTransferRepository) UpdateNegotiationCredential(ctx context.Context, customerID int, negotiationID, credentialID string) (*types.TransferNegotiation, error) {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	negotiation, err := r.findNegotiationByID(ctx, tx, customerID, negotiationID)
+	if err != nil {
+		return nil, err
+	}
+	if negotiation == nil {
+		return nil, fmt.Errorf("negotiation not found (id=%s)", negotiationID)
+	}
+	negotiation.CredentialID = &credentialID
+	if err := r.updateNegotiation(ctx, tx, customerID, *negotiation); err != nil {
+		return nil, err
+	}
+	return negotiation, nil
+}
+
+func (r SQLiteTransferRepository) CreateNegotiation(ctx context.Context, customerID int, transferID, organizationDID string, transferDate time.Time, taskID, credentialID string) (*types.TransferNegotiation, error) {
 	tx, err := sqlUtil.GetTransaction(ctx)
 	if err != nil {
 		return nil, err
@@ -452,11 +480,12 @@ func (r SQLiteTransferRepository) CreateNegotiation(ctx context.Context, custome
 		Date:            transferDate,
 		Status:          transfer.RequestedState,
 		TaskID:          taskID,
+		CredentialID:    sql.NullString{String: credentialID, Valid: credentialID != ""},
 	}
 
 	const query = `INSERT INTO transfer_negotiation 
-		(id, transfer_id, organization_did, customer_id, date, status, task_id)
-		values(:id, :transfer_id, :organization_did, :customer_id, :date, :status, :task_id)`
+		(id, transfer_id, organization_did, customer_id, date, status, task_id, credential_id)
+		values(:id, :transfer_id, :organization_did, :customer_id, :date, :status, :task_id, :credential_id)`
 
 	if _, err := tx.NamedExecContext(ctx, query, negotiation); err != nil {
 		return nil, err
@@ -0,0 +1,96 @@
+package trust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+)
+
+// ErrUnsupportedCredentialType is returned for credential types of which the trust can't be managed.
+var ErrUnsupportedCredentialType = errors.New("unsupported credential type")
+
+// CredentialTypes are the types of credentials of which the trust can be managed.
+var CredentialTypes = []string{credential.NutsOrganizationCredentialType, credential.NutsAuthorizationCredentialType}
+
+// Service manages which issuers of credentials the Nuts node trusts.
+type Service interface {
+	// Issuers returns the issuers of credentials of the type the Nuts node trusts, and the issuers of received
+	// credentials it doesn't trust.
+	Issuers(ctx context.Context, credentialType string) (*types.CredentialIssuers, error)
+	// Trust makes the Nuts node trust the issuer of credentials of the type. Trusting a trusted issuer has no effect.
+	Trust(ctx context.Context, credentialType, issuer string) error
+	// Untrust makes the Nuts node no longer trust the issuer of credentials of the type.
+	Untrust(ctx context.Context, credentialType, issuer string) error
+}
+
+type service struct {
+	vcr client.VCRClient
+	// caches contain organizations and credentials that were checked with the previous trust, so are cleared when it changes
+	caches []*cache.Cache
+}
+
+// NewService creates the trust management. The caches are cleared when the trust changes.
+func NewService(vcr client.VCRClient, caches ...*cache.Cache) Service {
+	return &service{vcr: vcr, caches: caches}
+}
+
+func (s service) Issuers(ctx context.Context, credentialType string) (*types.CredentialIssuers, error) {
+	if err := validateCredentialType(credentialType); err != nil {
+		return nil, err
+	}
+	trusted, err := s.vcr.ListTrustedIssuers(ctx, credentialType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list trusted issuers: %w", err)
+	}
+	untrusted, err := s.vcr.ListUntrustedIssuers(ctx, credentialType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list untrusted issuers: %w", err)
+	}
+	result := &types.CredentialIssuers{CredentialType: credentialType, Trusted: []string{}, Untrusted: []string{}}
+	result.Trusted = append(result.Trusted, trusted...)
+	result.Untrusted = append(result.Untrusted, untrusted...)
+	return result, nil
+}
+
+func (s service) Trust(ctx context.Context, credentialType, issuer string) error {
+	if err := validateCredentialType(credentialType); err != nil {
+		return err
+	}
+	if err := s.vcr.TrustIssuer(ctx, credentialType, issuer); err != nil {
+		return fmt.Errorf("unable to trust issuer (type=%s, issuer=%s): %w", credentialType, issuer, err)
+	}
+	s.clearCaches()
+	return nil
+}
+
+func (s service) Untrust(ctx context.Context, credentialType, issuer string) error {
+	if err := validateCredentialType(credentialType); err != nil {
+		return err
+	}
+	if err := s.vcr.UntrustIssuer(ctx, credentialType, issuer); err != nil {
+		return fmt.Errorf("unable to untrust issuer (type=%s, issuer=%s): %w", credentialType, issuer, err)
+	}
+	s.clearCaches()
+	return nil
+}
+
+func (s service) clearCaches() {
+	for _, c := range s.caches {
+		c.DeletePrefix("")
+	}
+}
+
+func validateCredentialType(credentialType string) error {
+	for _, curr := range CredentialTypes {
+		if curr == credentialType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedCredentialType, credentialType)
+}
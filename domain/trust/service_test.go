@@ -0,0 +1,72 @@
+package trust
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+)
+
+// stubNode keeps the trusted issuers of all credential types
+type stubNode struct {
+	client.VCRClient
+	trusted map[string]bool
+}
+
+func (s *stubNode) ListTrustedIssuers(_ context.Context, _ string) ([]string, error) {
+	var result []string
+	for issuer := range s.trusted {
+		result = append(result, issuer)
+	}
+	return result, nil
+}
+
+func (s *stubNode) ListUntrustedIssuers(_ context.Context, _ string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *stubNode) TrustIssuer(_ context.Context, _, issuer string) error {
+	s.trusted[issuer] = true
+	return nil
+}
+
+func (s *stubNode) UntrustIssuer(_ context.Context, _, issuer string) error {
+	delete(s.trusted, issuer)
+	return nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("trust and untrust", func(t *testing.T) {
+		organizations := cache.New(time.Minute, 10)
+		service := NewService(&stubNode{trusted: map[string]bool{}}, organizations)
+		organizations.Set("did:nuts:care-home|organization", "cached")
+
+		require.NoError(t, service.Trust(ctx, credential.NutsOrganizationCredentialType, "did:nuts:vendor"))
+
+		issuers, err := service.Issuers(ctx, credential.NutsOrganizationCredentialType)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"did:nuts:vendor"}, issuers.Trusted)
+		assert.Equal(t, []string{}, issuers.Untrusted)
+		assert.Equal(t, 0, organizations.Stats().Entries, "organizations checked with the previous trust must be removed")
+
+		require.NoError(t, service.Untrust(ctx, credential.NutsOrganizationCredentialType, "did:nuts:vendor"))
+
+		issuers, _ = service.Issuers(ctx, credential.NutsOrganizationCredentialType)
+		assert.Empty(t, issuers.Trusted)
+	})
+	t.Run("unsupported credential type", func(t *testing.T) {
+		service := NewService(&stubNode{trusted: map[string]bool{}})
+
+		_, err := service.Issuers(ctx, "UziCredential")
+		assert.ErrorIs(t, err, ErrUnsupportedCredentialType)
+		assert.ErrorIs(t, service.Trust(ctx, "UziCredential", "did:nuts:vendor"), ErrUnsupportedCredentialType)
+	})
+}
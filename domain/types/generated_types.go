@@ -4,6 +4,8 @@
 package types
 
 import (
+	"time"
+
 	openapi_types "github.com/deepmap/oapi-codegen/pkg/types"
 )
 
@@ -33,6 +35,13 @@ const (
 	InboxEntryTypeTransferRequest InboxEntryType = "transferRequest"
 )
 
+// Defines values for IssuedCredentialStatus.
+const (
+	IssuedCredentialStatusActive IssuedCredentialStatus = "active"
+
+	IssuedCredentialStatusRevoked IssuedCredentialStatus = "revoked"
+)
+
 // Defines values for PatientPropertiesGender.
 const (
 	PatientPropertiesGenderFemale PatientPropertiesGender = "female"
@@ -51,6 +60,19 @@ const (
 	ProblemStatusInactive ProblemStatus = "inactive"
 )
 
+// Defines values for SignSessionStatusState.
+const (
+	SignSessionStatusStateCancelled SignSessionStatusState = "cancelled"
+
+	SignSessionStatusStateCompleted SignSessionStatusState = "completed"
+
+	SignSessionStatusStateExpired SignSessionStatusState = "expired"
+
+	SignSessionStatusStateFailed SignSessionStatusState = "failed"
+
+	SignSessionStatusStatePending SignSessionStatusState = "pending"
+)
+
 // Defines values for TransferStatus.
 const (
 	TransferStatusAssigned TransferStatus = "assigned"
@@ -79,6 +101,27 @@ const (
 	TransferNegotiationStatusStatusRequested TransferNegotiationStatusStatus = "requested"
 )
 
+// Defines values for UserRole.
+const (
+	UserRoleAdmin UserRole = "admin"
+
+	UserRoleNurse UserRole = "nurse"
+
+	UserRolePlanner UserRole = "planner"
+
+	UserRoleVendor UserRole = "vendor"
+)
+
+// Usage statistics of a cache.
+type CacheStats struct {
+	Entries int `json:"entries"`
+
+	// The fraction of lookups that were hits.
+	HitRate float32 `json:"hitRate"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+}
+
 // CarePlan as defined by https://decor.nictiz.nl/pub/eoverdracht/e-overdracht-html-20210510T093529/tr-2.16.840.1.113883.2.4.3.11.60.30.4.63-2021-01-27T000000.html#_2.16.840.1.113883.2.4.3.11.60.30.22.4.529_20210126000000
 type CarePlan struct {
 	PatientProblems []PatientProblem `json:"patientProblems"`
@@ -101,10 +144,20 @@ type Collaboration struct {
 
 // Request to create a collaboration.
 type CreateCollaborationRequest struct {
+	// If true, the collaborator may also create Observations and DocumentReferences within the episode through the FHIR proxy.
+	AllowCreate *bool `json:"allowCreate,omitempty"`
+
 	// A care organization available through the Nuts Network to exchange information.
 	Sender Organization `json:"sender"`
 }
 
+// CreateCustomerRequest defines model for CreateCustomerRequest.
+type CreateCustomerRequest struct {
+	// The password of the admin user that's created for the customer.
+	AdminPassword string             `json:"adminPassword"`
+	Properties    CustomerProperties `json:"properties"`
+}
+
 // API request to create a dossier for a patient.
 type CreateDossierRequest struct {
 	Name string `json:"name"`
@@ -122,6 +175,12 @@ type CreateEpisodeRequest struct {
 	Period    Period   `json:"period"`
 }
 
+// CreateSignSessionRequest defines model for CreateSignSessionRequest.
+type CreateSignSessionRequest struct {
+	// Parameters for the signing means, e.g. the identity of the employee for employeeid. They're added to the configured parameters, but can't override them.
+	Params *map[string]interface{} `json:"params,omitempty"`
+}
+
 // An request object to create a new transfer negotiation.
 type CreateTransferNegotiationRequest struct {
 	// Decentralized Identifier of the organization to which transfer of a patient is requested.
@@ -140,11 +199,32 @@ type CreateTransferRequest struct {
 	DossierID ObjectID `json:"dossierID"`
 }
 
+// CreateUserRequest defines model for CreateUserRequest.
+type CreateUserRequest struct {
+	Password string     `json:"password"`
+	Roles    []UserRole `json:"roles"`
+	Username string     `json:"username"`
+}
+
+// CredentialIssuers defines model for CredentialIssuers.
+type CredentialIssuers struct {
+	CredentialType string `json:"credentialType"`
+
+	// DIDs of the issuers that are trusted.
+	Trusted []string `json:"trusted"`
+
+	// DIDs of the issuers of received credentials that aren't trusted.
+	Untrusted []string `json:"untrusted"`
+}
+
 // A customer object.
 type Customer struct {
-	// If a VC has been issued for this customer.
+	// False if the customer has been deactivated, its users can't log in.
 	Active bool `json:"active"`
 
+	// How the EHR is displayed to the users of a customer.
+	Branding *CustomerBranding `json:"branding,omitempty"`
+
 	// Locality for this customer.
 	City *string `json:"city,omitempty"`
 
@@ -159,6 +239,50 @@ type Customer struct {
 
 	// Internal name for this customer.
 	Name string `json:"name"`
+
+	// The signing means the users of this customer may use. If not set, all configured means are allowed.
+	SigningMeans *[]string `json:"signingMeans,omitempty"`
+}
+
+// How the EHR is displayed to the users of a customer.
+type CustomerBranding struct {
+	// URL of the logo of the customer.
+	Logo *string `json:"logo,omitempty"`
+}
+
+// CustomerProperties defines model for CustomerProperties.
+type CustomerProperties struct {
+	// How the EHR is displayed to the users of a customer.
+	Branding *CustomerBranding `json:"branding,omitempty"`
+	City     *string           `json:"city,omitempty"`
+
+	// The customer DID.
+	Did *string `json:"did,omitempty"`
+
+	// The email domain of the care providers employees.
+	Domain *string `json:"domain,omitempty"`
+	Name   string  `json:"name"`
+
+	// The signing means the users of this customer may use. If not set, all configured means are allowed.
+	SigningMeans *[]string `json:"signingMeans,omitempty"`
+}
+
+// DiagnosticCheck defines model for DiagnosticCheck.
+type DiagnosticCheck struct {
+	// Describes the issue and how to resolve it, if the check failed.
+	Message *string `json:"message,omitempty"`
+
+	// What was checked, e.g. "eOverdracht-sender/fhir".
+	Name string `json:"name"`
+	Ok   bool   `json:"ok"`
+}
+
+// Diagnostics defines model for Diagnostics.
+type Diagnostics struct {
+	Checks []DiagnosticCheck `json:"checks"`
+
+	// True if all checks passed.
+	Ok bool `json:"ok"`
 }
 
 // Dossier defines model for Dossier.
@@ -222,6 +346,63 @@ type Intervention struct {
 	Comment string `json:"comment"`
 }
 
+// A NutsAuthorizationCredential issued by the customer.
+type IssuedCredential struct {
+	// The ID of the episode of care the credential gives access to.
+	EpisodeID      *string    `json:"episodeID,omitempty"`
+	ExpirationDate *time.Time `json:"expirationDate,omitempty"`
+	Id             string     `json:"id"`
+	IssuanceDate   time.Time  `json:"issuanceDate"`
+
+	// An internal object UUID which can be used as unique identifier for entities.
+	NegotiationID *ObjectID `json:"negotiationID,omitempty"`
+
+	// The DID of the care organization the credential was issued to.
+	OrganizationDID string `json:"organizationDID"`
+
+	// The name of the care organization, if it's known.
+	OrganizationName *string `json:"organizationName,omitempty"`
+
+	// Why the credential is orphaned.
+	OrphanReason *string `json:"orphanReason,omitempty"`
+
+	// True if the transfer or episode the credential gives access to no longer needs it.
+	Orphaned bool `json:"orphaned"`
+
+	// The patient the credential is about, if any.
+	Patient      *string `json:"patient,omitempty"`
+	PurposeOfUse string  `json:"purposeOfUse"`
+
+	// The paths of the FHIR resources the credential gives access to.
+	Resources []string               `json:"resources"`
+	Status    IssuedCredentialStatus `json:"status"`
+
+	// An internal object UUID which can be used as unique identifier for entities.
+	TransferID *ObjectID `json:"transferID,omitempty"`
+}
+
+// IssuedCredentialStatus defines model for IssuedCredential.Status.
+type IssuedCredentialStatus string
+
+// Access token for the FHIR proxy, scoped to a single patient.
+type LaunchToken struct {
+	AccessToken string `json:"access_token"`
+
+	// Number of seconds the token is valid.
+	ExpiresIn int `json:"expires_in"`
+
+	// The patient id the token is scoped to.
+	Patient   string `json:"patient"`
+	Scope     string `json:"scope"`
+	TokenType string `json:"token_type"`
+}
+
+// OIDCStatus defines model for OIDCStatus.
+type OIDCStatus struct {
+	// Whether users can log in with an OpenID Connect provider.
+	Enabled bool `json:"enabled"`
+}
+
 // An internal object UUID which can be used as unique identifier for entities.
 type ObjectID string
 
@@ -233,6 +414,12 @@ type Organization struct {
 	// Decentralized Identifier which uniquely identifies the care organization on the Nuts Network.
 	Did string `json:"did"`
 
+	// Estimated distance in kilometers to the zipcode of the search. Only set in search results.
+	Distance *int `json:"distance,omitempty"`
+
+	// Whether the care organization is a favourite partner of the customer. Only set in search results.
+	Favourite *bool `json:"favourite,omitempty"`
+
 	// Name of the care organization.
 	Name string `json:"name"`
 }
@@ -242,6 +429,7 @@ type PasswordAuthenticateRequest struct {
 	// Internal ID of the customer for which is being logged in
 	CustomerID int    `json:"customerID"`
 	Password   string `json:"password"`
+	Username   string `json:"username"`
 }
 
 // Patient defines model for Patient.
@@ -324,6 +512,18 @@ type SessionToken struct {
 	Token string `json:"token"`
 }
 
+// SignSessionStatus defines model for SignSessionStatus.
+type SignSessionStatus struct {
+	Error *string                `json:"error,omitempty"`
+	State SignSessionStatusState `json:"state"`
+
+	// The status as reported by the signing means, e.g. CONNECTED for IRMA.
+	Status *string `json:"status,omitempty"`
+}
+
+// SignSessionStatusState defines model for SignSessionStatus.State.
+type SignSessionStatusState string
+
 // Transfer defines model for Transfer.
 type Transfer struct {
 	// Embedded struct due to allOf(#/components/schemas/TransferProperties)
@@ -353,6 +553,9 @@ type TransferNegotiation struct {
 	// Embedded struct due to allOf(#/components/schemas/TransferNegotiationStatus)
 	TransferNegotiationStatus `yaml:",inline"`
 	// Embedded fields due to inline allOf schema
+	// The ID of the authorization credential issued to the organization. It's unknown for negotiations started by earlier versions of the EHR.
+	CredentialID *string `json:"credentialID,omitempty"`
+
 	// An internal object UUID which can be used as unique identifier for entities.
 	Id ObjectID `json:"id"`
 
@@ -409,12 +612,58 @@ type TransferRequest struct {
 	TransferDate *openapi_types.Date `json:"transferDate,omitempty"`
 }
 
+// UpdateUserRequest defines model for UpdateUserRequest.
+type UpdateUserRequest struct {
+	// The new password of the user. If not set, the password is left unchanged.
+	Password *string    `json:"password,omitempty"`
+	Roles    []UserRole `json:"roles"`
+}
+
+// An account of a user of the customer.
+type User struct {
+	// An internal object UUID which can be used as unique identifier for entities.
+	Id       ObjectID   `json:"id"`
+	Roles    []UserRole `json:"roles"`
+	Username string     `json:"username"`
+}
+
+// Role of a user, which determines the operations the user may perform. Nurses record care (patients, dossiers, episodes and reports), planners arrange transfers and collaborations and admins manage the users of the customer. Vendors operate the EHR for all customers: they manage the customers, the trust of the Nuts node and the caches.
+type UserRole string
+
 // SetCustomerJSONBody defines parameters for SetCustomer.
 type SetCustomerJSONBody Customer
 
+// HandleOIDCCallbackParams defines parameters for HandleOIDCCallback.
+type HandleOIDCCallbackParams struct {
+	Code  *string `json:"code,omitempty"`
+	State *string `json:"state,omitempty"`
+	Error *string `json:"error,omitempty"`
+}
+
+// StartOIDCLoginParams defines parameters for StartOIDCLogin.
+type StartOIDCLoginParams struct {
+	// Path in the application the user is redirected to after logging in.
+	Redirect *string `json:"redirect,omitempty"`
+}
+
 // AuthenticateWithPasswordJSONBody defines parameters for AuthenticateWithPassword.
 type AuthenticateWithPasswordJSONBody PasswordAuthenticateRequest
 
+// CreateSignSessionJSONBody defines parameters for CreateSignSession.
+type CreateSignSessionJSONBody CreateSignSessionRequest
+
+// CreateCustomerJSONBody defines parameters for CreateCustomer.
+type CreateCustomerJSONBody CreateCustomerRequest
+
+// UpdateCustomerJSONBody defines parameters for UpdateCustomer.
+type UpdateCustomerJSONBody CustomerProperties
+
+// CreateUserJSONBody defines parameters for CreateUser.
+type CreateUserJSONBody CreateUserRequest
+
+// UpdateUserJSONBody defines parameters for UpdateUser.
+type UpdateUserJSONBody UpdateUserRequest
+
 // CreateDossierJSONBody defines parameters for CreateDossier.
 type CreateDossierJSONBody CreateDossierRequest
 
@@ -431,8 +680,23 @@ type SearchOrganizationsParams struct {
 
 	// Filters other care organizations on the Nuts Network on service, only returning care organizations have a service in their DID Document which' type matches the given didServiceType and not including your own. If not supplied, care organizations aren't filtered on service.
 	DidServiceType *string `json:"didServiceType,omitempty"`
+
+	// Only returns care organizations that can receive data of the given Bolt, e.g. receive patient transfers for eOverdracht. Can't be combined with didServiceType.
+	Bolt *SearchOrganizationsParamsBolt `json:"bolt,omitempty"`
+
+	// Zipcode of the patient. When supplied, the distance of the care organizations to the zipcode is estimated and closer care organizations are ranked higher.
+	Zipcode *string `json:"zipcode,omitempty"`
+
+	// Only returns care organizations within the given distance in kilometers of the zipcode. Requires the zipcode.
+	MaxDistance *int `json:"maxDistance,omitempty"`
+
+	// Only returns the favourite partners of the customer.
+	FavouritesOnly *bool `json:"favouritesOnly,omitempty"`
 }
 
+// SearchOrganizationsParamsBolt defines parameters for SearchOrganizations.
+type SearchOrganizationsParamsBolt string
+
 // UpdatePatientJSONBody defines parameters for UpdatePatient.
 type UpdatePatientJSONBody PatientProperties
 
@@ -484,6 +748,21 @@ type SetCustomerJSONRequestBody SetCustomerJSONBody
 // AuthenticateWithPasswordJSONRequestBody defines body for AuthenticateWithPassword for application/json ContentType.
 type AuthenticateWithPasswordJSONRequestBody AuthenticateWithPasswordJSONBody
 
+// CreateSignSessionJSONRequestBody defines body for CreateSignSession for application/json ContentType.
+type CreateSignSessionJSONRequestBody CreateSignSessionJSONBody
+
+// CreateCustomerJSONRequestBody defines body for CreateCustomer for application/json ContentType.
+type CreateCustomerJSONRequestBody CreateCustomerJSONBody
+
+// UpdateCustomerJSONRequestBody defines body for UpdateCustomer for application/json ContentType.
+type UpdateCustomerJSONRequestBody UpdateCustomerJSONBody
+
+// CreateUserJSONRequestBody defines body for CreateUser for application/json ContentType.
+type CreateUserJSONRequestBody CreateUserJSONBody
+
+// UpdateUserJSONRequestBody defines body for UpdateUser for application/json ContentType.
+type UpdateUserJSONRequestBody UpdateUserJSONBody
+
 // CreateDossierJSONRequestBody defines body for CreateDossier for application/json ContentType.
 type CreateDossierJSONRequestBody CreateDossierJSONBody
 
@@ -0,0 +1,48 @@
+package users
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+)
+
+// ErrUsernameTaken is returned when a user is created with a username that's already used within the customer.
+var ErrUsernameTaken = errors.New("username already taken")
+
+// ErrUserNotFound is returned when a user is updated or deleted that doesn't exist.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrUnknownRole is returned when a user is given a role that doesn't exist.
+var ErrUnknownRole = errors.New("unknown role")
+
+// AllRoles contains every role a user can have.
+var AllRoles = []types.UserRole{types.UserRoleNurse, types.UserRolePlanner, types.UserRoleAdmin, types.UserRoleVendor}
+
+// CustomerRoles contains the roles of the staff of a customer, which only apply to the customer itself.
+// The vendor role isn't one of them, since it applies to all customers.
+var CustomerRoles = []types.UserRole{types.UserRoleNurse, types.UserRolePlanner, types.UserRoleAdmin}
+
+// Repository stores the users of the customers. Usernames are unique within a customer.
+type Repository interface {
+	FindByID(ctx context.Context, customerID int, id string) (*types.User, error)
+	All(ctx context.Context, customerID int) ([]types.User, error)
+	// Authenticate returns the user with the given username if the password matches, nil otherwise.
+	Authenticate(ctx context.Context, customerID int, username, password string) (*types.User, error)
+	Create(ctx context.Context, customerID int, username, password string, roles []types.UserRole) (*types.User, error)
+	// Update replaces the roles of the user and, if password is not nil, its password.
+	Update(ctx context.Context, customerID int, id string, password *string, roles []types.UserRole) (*types.User, error)
+	Delete(ctx context.Context, customerID int, id string) error
+}
+
+// HasRole returns true if the user has any of the given roles.
+func HasRole(userRoles []types.UserRole, roles ...types.UserRole) bool {
+	for _, userRole := range userRoles {
+		for _, role := range roles {
+			if userRole == role {
+				return true
+			}
+		}
+	}
+	return false
+}
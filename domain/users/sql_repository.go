@@ -0,0 +1,223 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	sqlUtil "github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+type sqlUser struct {
+	ID           string `db:"id"`
+	CustomerID   int    `db:"customer_id"`
+	Username     string `db:"username"`
+	PasswordHash string `db:"password_hash"`
+	Roles        string `db:"roles"`
+}
+
+func (dbUser sqlUser) MarshalToDomainUser() types.User {
+	roles := []types.UserRole{}
+	for _, role := range strings.Split(dbUser.Roles, ",") {
+		if role != "" {
+			roles = append(roles, types.UserRole(role))
+		}
+	}
+	return types.User{
+		Id:       types.ObjectID(dbUser.ID),
+		Username: dbUser.Username,
+		Roles:    roles,
+	}
+}
+
+func joinRoles(roles []types.UserRole) (string, error) {
+	values := make([]string, len(roles))
+	for i, role := range roles {
+		if !HasRole(AllRoles, role) {
+			return "", fmt.Errorf("%w: %s", ErrUnknownRole, role)
+		}
+		values[i] = string(role)
+	}
+	return strings.Join(values, ","), nil
+}
+
+const schema = `
+	CREATE TABLE IF NOT EXISTS user (
+		id char(36) NOT NULL,
+		customer_id integer(11) NOT NULL,
+		username varchar(100) NOT NULL,
+		password_hash varchar(100) NOT NULL,
+		roles varchar(100) NOT NULL,
+		PRIMARY KEY (id),
+		UNIQUE(customer_id, username)
+	);
+`
+
+type SQLiteUserRepository struct{}
+
+func NewSQLiteUserRepository(db *sqlx.DB) *SQLiteUserRepository {
+	if db == nil {
+		panic("missing db for UserRepository")
+	}
+	tx, _ := db.Beginx()
+	tx.MustExec(schema)
+	if err := tx.Commit(); err != nil {
+		panic(err)
+	}
+
+	return &SQLiteUserRepository{}
+}
+
+func (r SQLiteUserRepository) FindByID(ctx context.Context, customerID int, id string) (*types.User, error) {
+	dbUser, err := r.find(ctx, `SELECT * FROM user WHERE customer_id = ? AND id = ?`, customerID, id)
+	if err != nil || dbUser == nil {
+		return nil, err
+	}
+	user := dbUser.MarshalToDomainUser()
+	return &user, nil
+}
+
+func (r SQLiteUserRepository) All(ctx context.Context, customerID int) ([]types.User, error) {
+	const query = `SELECT * FROM user WHERE customer_id = ? ORDER BY username ASC`
+
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dbUsers := []sqlUser{}
+	if err := tx.SelectContext(ctx, &dbUsers, query, customerID); err != nil {
+		return nil, err
+	}
+
+	result := make([]types.User, len(dbUsers))
+	for idx, dbUser := range dbUsers {
+		result[idx] = dbUser.MarshalToDomainUser()
+	}
+	return result, nil
+}
+
+func (r SQLiteUserRepository) Authenticate(ctx context.Context, customerID int, username, password string) (*types.User, error) {
+	dbUser, err := r.find(ctx, `SELECT * FROM user WHERE customer_id = ? AND username = ?`, customerID, username)
+	if err != nil || dbUser == nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(dbUser.PasswordHash), []byte(password)) != nil {
+		return nil, nil
+	}
+	user := dbUser.MarshalToDomainUser()
+	return &user, nil
+}
+
+func (r SQLiteUserRepository) Create(ctx context.Context, customerID int, username, password string, roles []types.UserRole) (*types.User, error) {
+	existing, err := r.find(ctx, `SELECT * FROM user WHERE customer_id = ? AND username = ?`, customerID, username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrUsernameTaken
+	}
+
+	joinedRoles, err := joinRoles(roles)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	dbUser := sqlUser{
+		ID:           uuid.NewString(),
+		CustomerID:   customerID,
+		Username:     username,
+		PasswordHash: string(hash),
+		Roles:        joinedRoles,
+	}
+
+	const query = `INSERT INTO user
+		(id, customer_id, username, password_hash, roles)
+		VALUES (:id, :customer_id, :username, :password_hash, :roles)`
+
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.NamedExec(query, dbUser); err != nil {
+		return nil, err
+	}
+
+	user := dbUser.MarshalToDomainUser()
+	return &user, nil
+}
+
+func (r SQLiteUserRepository) Update(ctx context.Context, customerID int, id string, password *string, roles []types.UserRole) (*types.User, error) {
+	dbUser, err := r.find(ctx, `SELECT * FROM user WHERE customer_id = ? AND id = ?`, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if dbUser == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if dbUser.Roles, err = joinRoles(roles); err != nil {
+		return nil, err
+	}
+	if password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		dbUser.PasswordHash = string(hash)
+	}
+
+	const query = `UPDATE user SET password_hash = :password_hash, roles = :roles WHERE customer_id = :customer_id AND id = :id`
+
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.NamedExec(query, dbUser); err != nil {
+		return nil, err
+	}
+
+	user := dbUser.MarshalToDomainUser()
+	return &user, nil
+}
+
+func (r SQLiteUserRepository) Delete(ctx context.Context, customerID int, id string) error {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM user WHERE customer_id = ? AND id = ?`, customerID, id)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r SQLiteUserRepository) find(ctx context.Context, query string, args ...interface{}) (*sqlUser, error) {
+	tx, err := sqlUtil.GetTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dbUser := sqlUser{}
+	err = tx.GetContext(ctx, &dbUser, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &dbUser, nil
+}
@@ -0,0 +1,112 @@
+package users
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLiteUserRepository(t *testing.T) {
+	newRepo := func() (*sqlx.DB, *SQLiteUserRepository) {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		// every connection to :memory: has its own database
+		db.SetMaxOpenConns(1)
+		return db, NewSQLiteUserRepository(db)
+	}
+	roles := []types.UserRole{types.UserRoleNurse, types.UserRoleAdmin}
+
+	t.Run("create and authenticate", func(t *testing.T) {
+		db, repo := newRepo()
+
+		err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			created, err := repo.Create(ctx, 1, "alice", "secret", roles)
+			if !assert.NoError(t, err) {
+				return err
+			}
+			assert.NotEmpty(t, created.Id)
+			assert.Equal(t, roles, created.Roles)
+
+			user, err := repo.Authenticate(ctx, 1, "alice", "secret")
+			assert.NoError(t, err)
+			if assert.NotNil(t, user) {
+				assert.Equal(t, created.Id, user.Id)
+				assert.Equal(t, roles, user.Roles)
+			}
+
+			user, err = repo.Authenticate(ctx, 1, "alice", "wrong")
+			assert.NoError(t, err)
+			assert.Nil(t, user)
+
+			// users belong to a customer
+			user, err = repo.Authenticate(ctx, 2, "alice", "secret")
+			assert.NoError(t, err)
+			assert.Nil(t, user)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+	t.Run("password is hashed", func(t *testing.T) {
+		db, repo := newRepo()
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, 1, "alice", "secret", roles)
+			return err
+		})
+
+		var hash string
+		assert.NoError(t, db.Get(&hash, "SELECT password_hash FROM user WHERE username = ?", "alice"))
+		assert.NotEqual(t, "secret", hash)
+	})
+	t.Run("duplicate username", func(t *testing.T) {
+		db, repo := newRepo()
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, 1, "alice", "secret", roles)
+			assert.NoError(t, err)
+			_, err = repo.Create(ctx, 1, "alice", "other", roles)
+			assert.ErrorIs(t, err, ErrUsernameTaken)
+			_, err = repo.Create(ctx, 2, "alice", "other", roles)
+			assert.NoError(t, err)
+			return nil
+		})
+	})
+	t.Run("unknown role", func(t *testing.T) {
+		db, repo := newRepo()
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, 1, "alice", "secret", []types.UserRole{"doctor"})
+			assert.ErrorIs(t, err, ErrUnknownRole)
+			return nil
+		})
+	})
+	t.Run("update and delete", func(t *testing.T) {
+		db, repo := newRepo()
+
+		_ = sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			created, _ := repo.Create(ctx, 1, "alice", "secret", roles)
+			password := "changed"
+
+			updated, err := repo.Update(ctx, 1, string(created.Id), &password, []types.UserRole{types.UserRolePlanner})
+			assert.NoError(t, err)
+			assert.Equal(t, []types.UserRole{types.UserRolePlanner}, updated.Roles)
+			user, _ := repo.Authenticate(ctx, 1, "alice", "changed")
+			assert.NotNil(t, user)
+
+			_, err = repo.Update(ctx, 2, string(created.Id), nil, roles)
+			assert.ErrorIs(t, err, ErrUserNotFound)
+
+			assert.ErrorIs(t, repo.Delete(ctx, 2, string(created.Id)), ErrUserNotFound)
+			assert.NoError(t, repo.Delete(ctx, 1, string(created.Id)))
+			all, err := repo.All(ctx, 1)
+			assert.NoError(t, err)
+			assert.Empty(t, all)
+			return nil
+		})
+	})
+}
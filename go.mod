@@ -21,4 +21,5 @@ require (
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.7.0
 	github.com/tidwall/gjson v1.14.0
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
 )
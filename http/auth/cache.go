@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// cachingService is a Service that caches the results of token introspection, so the Nuts node doesn't have to be
+// consulted on every request that carries the same access token.
+type cachingService struct {
+	Service
+	tokens *cache.Cache
+}
+
+// NewCachingService wraps the given Service so that active introspected access tokens are cached until they expire,
+// or until the TTL of the given cache passes. Inactive tokens are never cached.
+func NewCachingService(service Service, tokenCache *cache.Cache) Service {
+	return &cachingService{
+		Service: service,
+		tokens:  tokenCache,
+	}
+}
+
+func (s *cachingService) IntrospectAccessToken(ctx context.Context, accessToken string) (*nutsAuthClient.TokenIntrospectionResponse, error) {
+	key := tokenCacheKey(accessToken)
+
+	if cached, ok := s.tokens.Get(key); ok {
+		token := cached.(nutsAuthClient.TokenIntrospectionResponse)
+		return &token, nil
+	}
+
+	token, err := s.Service.IntrospectAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Active {
+		var expiry time.Time
+		if token.Exp != nil {
+			expiry = time.Unix(int64(*token.Exp), 0)
+		}
+		s.tokens.SetWithExpiry(key, *token, expiry)
+	}
+
+	return token, nil
+}
+
+// tokenCacheKey hashes the access token, so the cache doesn't hold usable tokens.
+func tokenCacheKey(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(hash[:])
+}
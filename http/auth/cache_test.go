@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// countingService introspects the tokens as configured and counts how often the Nuts node is consulted
+type countingService struct {
+	stubService
+	tokens map[string]nutsAuthClient.TokenIntrospectionResponse
+	calls  int
+}
+
+func (s *countingService) IntrospectAccessToken(_ context.Context, accessToken string) (*nutsAuthClient.TokenIntrospectionResponse, error) {
+	s.calls++
+	token, ok := s.tokens[accessToken]
+	if !ok {
+		return nil, errors.New("introspection failed")
+	}
+	return &token, nil
+}
+
+func TestCachingService_IntrospectAccessToken(t *testing.T) {
+	ctx := context.Background()
+	exp := func(at time.Time) *int {
+		value := int(at.Unix())
+		return &value
+	}
+	newService := func() (*countingService, Service, *cache.Cache) {
+		node := &countingService{tokens: map[string]nutsAuthClient.TokenIntrospectionResponse{
+			"active":   {Active: true, Exp: exp(time.Now().Add(time.Hour))},
+			"inactive": {Active: false},
+			"expired":  {Active: true, Exp: exp(time.Now().Add(-time.Second))},
+		}}
+		tokenCache := cache.New(time.Minute, 10)
+		return node, NewCachingService(node, tokenCache), tokenCache
+	}
+
+	t.Run("active token is cached", func(t *testing.T) {
+		node, service, tokenCache := newService()
+
+		for i := 0; i < 2; i++ {
+			token, err := service.IntrospectAccessToken(ctx, "active")
+			require.NoError(t, err)
+			assert.True(t, token.Active)
+		}
+
+		assert.Equal(t, 1, node.calls)
+		_, ok := tokenCache.Get("active")
+		assert.False(t, ok, "the cache must not hold usable tokens")
+	})
+	t.Run("inactive token is not cached", func(t *testing.T) {
+		node, service, _ := newService()
+
+		for i := 0; i < 2; i++ {
+			token, err := service.IntrospectAccessToken(ctx, "inactive")
+			require.NoError(t, err)
+			assert.False(t, token.Active)
+		}
+
+		assert.Equal(t, 2, node.calls)
+	})
+	t.Run("token expires from the cache", func(t *testing.T) {
+		node, service, _ := newService()
+
+		_, _ = service.IntrospectAccessToken(ctx, "expired")
+		_, _ = service.IntrospectAccessToken(ctx, "expired")
+
+		assert.Equal(t, 2, node.calls)
+	})
+	t.Run("errors are not cached", func(t *testing.T) {
+		node, service, tokenCache := newService()
+
+		_, err := service.IntrospectAccessToken(ctx, "unknown")
+		assert.Error(t, err)
+		_, err = service.IntrospectAccessToken(ctx, "unknown")
+		assert.Error(t, err)
+
+		assert.Equal(t, 2, node.calls)
+		assert.Equal(t, 0, tokenCache.Stats().Entries)
+	})
+}
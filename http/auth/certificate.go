@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// RequesterCertificateBinding returns an AccessFunc that checks whether the TLS client certificate belongs to the requester
+// of the access token: the certificate must contain the DID of the requester as URI in its subject alternative names,
+// or its subject serial number must be the one configured for the DID in serialNumbers (e.g. the URA of the organization).
+// Names of organizations aren't used, since they aren't unique. This prevents an access token that leaked from being
+// used by another party that has a trusted certificate.
+func RequesterCertificateBinding(serialNumbers map[string]string) AccessFunc {
+	return func(_ echo.Context, request *http.Request, token *nutsAuthClient.TokenIntrospectionResponse) error {
+		if request.TLS == nil || len(request.TLS.PeerCertificates) == 0 {
+			return errors.New("no client certificate")
+		}
+		if token.Sub == nil {
+			return errors.New("access-token doesn't contain requester")
+		}
+		requester := *token.Sub
+
+		certificate := request.TLS.PeerCertificates[0]
+		for _, uri := range certificate.URIs {
+			if uri.String() == requester {
+				return nil
+			}
+		}
+		if serialNumber, ok := serialNumbers[requester]; ok && serialNumber != "" && certificate.Subject.SerialNumber == serialNumber {
+			return nil
+		}
+		return fmt.Errorf("client certificate doesn't belong to requester (did=%s, subject=%s)", requester, certificate.Subject)
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+func TestRequesterCertificateBinding(t *testing.T) {
+	binding := RequesterCertificateBinding(map[string]string{"did:nuts:hospital": "90000001"})
+	token := func(requester string) *nutsAuthClient.TokenIntrospectionResponse {
+		return &nutsAuthClient.TokenIntrospectionResponse{Active: true, Sub: &requester}
+	}
+	requestWithCertificate := func(certificate x509.Certificate) *http.Request {
+		request := httptest.NewRequest(http.MethodPost, "/web/external/transfer/notify/123", nil)
+		request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{&certificate}}
+		return request
+	}
+	withDID := func(organization, did string) x509.Certificate {
+		uri, _ := url.Parse(did)
+		return x509.Certificate{Subject: pkix.Name{CommonName: "vendor.example.com", Organization: []string{organization}}, URIs: []*url.URL{uri}}
+	}
+	withSerialNumber := func(organization, serialNumber string) x509.Certificate {
+		return x509.Certificate{Subject: pkix.Name{CommonName: "vendor.example.com", Organization: []string{organization}, SerialNumber: serialNumber}}
+	}
+
+	t.Run("certificate with DID of requester", func(t *testing.T) {
+		assert.NoError(t, binding(nil, requestWithCertificate(withDID("Verpleeghuis De Nootjes", "did:nuts:care-home")), token("did:nuts:care-home")))
+	})
+	t.Run("certificate with configured serial number of requester", func(t *testing.T) {
+		assert.NoError(t, binding(nil, requestWithCertificate(withSerialNumber("Ziekenhuis Amersfoort", "90000001")), token("did:nuts:hospital")))
+	})
+	t.Run("certificate of other organization with the same name", func(t *testing.T) {
+		err := binding(nil, requestWithCertificate(withDID("Verpleeghuis De Nootjes", "did:nuts:other")), token("did:nuts:care-home"))
+
+		assert.EqualError(t, err, "client certificate doesn't belong to requester (did=did:nuts:care-home, subject=CN=vendor.example.com,O=Verpleeghuis De Nootjes)")
+	})
+	t.Run("certificate with serial number of other organization", func(t *testing.T) {
+		err := binding(nil, requestWithCertificate(withSerialNumber("Ziekenhuis Amersfoort", "90000001")), token("did:nuts:care-home"))
+
+		assert.Error(t, err)
+	})
+	t.Run("certificate without identifier", func(t *testing.T) {
+		err := binding(nil, requestWithCertificate(withSerialNumber("Ziekenhuis Amersfoort", "")), token("did:nuts:care-home"))
+
+		assert.Error(t, err)
+	})
+	t.Run("no client certificate", func(t *testing.T) {
+		err := binding(nil, httptest.NewRequest(http.MethodPost, "/web/external/transfer/notify/123", nil), token("did:nuts:care-home"))
+
+		assert.EqualError(t, err, "no client certificate")
+	})
+	t.Run("no requester", func(t *testing.T) {
+		err := binding(nil, requestWithCertificate(withDID("Verpleeghuis De Nootjes", "did:nuts:care-home")), &nutsAuthClient.TokenIntrospectionResponse{Active: true})
+
+		assert.EqualError(t, err, "access-token doesn't contain requester")
+	})
+}
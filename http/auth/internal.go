@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// InternalPathPrefix is the path of the endpoints the FHIR proxy dispatches requests to, after it validated them.
+// They must never be called directly.
+const InternalPathPrefix = "/web/internal/"
+
+type internalDispatchContextKey struct{}
+
+// WithInternalDispatch marks the request as dispatched by the EHR itself.
+// The mark is a request context value with an unexported key, so it can't be set by callers of the EHR.
+func WithInternalDispatch(request *http.Request) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), internalDispatchContextKey{}, true))
+}
+
+// IsInternalDispatch returns true if the request was dispatched by the EHR itself (see WithInternalDispatch).
+func IsInternalDispatch(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalDispatchContextKey{}).(bool)
+	return internal
+}
+
+// InternalOnly only allows requests on the internal endpoints that were dispatched by the EHR itself.
+// Other requests get a 404, so the internal endpoints can't be discovered from outside.
+func InternalOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		if strings.HasPrefix(ctx.Request().URL.Path, InternalPathPrefix) && !IsInternalDispatch(ctx.Request().Context()) {
+			return echo.NewHTTPError(http.StatusNotFound, "Not Found")
+		}
+		return next(ctx)
+	}
+}
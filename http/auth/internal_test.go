@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/go-did/vc"
+	"github.com/stretchr/testify/assert"
+
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// stubService is a Nuts node that doesn't accept any access token
+type stubService struct{}
+
+func (stubService) RequestAccessToken(_ context.Context, _, _, _ string, _ []vc.VerifiableCredential, _ *nutsAuthClient.VerifiablePresentation) (*nutsAuthClient.AccessTokenResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (stubService) IntrospectAccessToken(_ context.Context, _ string) (*nutsAuthClient.TokenIntrospectionResponse, error) {
+	return &nutsAuthClient.TokenIntrospectionResponse{Active: false}, nil
+}
+
+func (stubService) ParseBearerToken(_ *http.Request) (string, error) {
+	return "", ErrEmptyBearerToken
+}
+
+func TestInternalOnly(t *testing.T) {
+	server := echo.New()
+	server.Use(InternalOnly)
+	handler := func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusAccepted)
+	}
+	server.PUT("/web/internal/customer/:customerID/task/:taskID", handler)
+	server.PUT("/web/private/task/:taskID", handler)
+	// dispatches the request internally, like the FHIR proxy does after validating it
+	server.PUT("/fhir/Task/:taskID", func(ctx echo.Context) error {
+		request := WithInternalDispatch(ctx.Request())
+		request.URL.Path = "/web/internal/customer/1/task/" + ctx.Param("taskID")
+		request.RequestURI = request.URL.Path
+		server.ServeHTTP(ctx.Response(), request)
+		return nil
+	})
+
+	call := func(request *http.Request) int {
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	t.Run("external call on internal endpoint", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, call(httptest.NewRequest(http.MethodPut, "/web/internal/customer/1/task/123", nil)))
+	})
+	t.Run("external call with internal header or escaped path", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPut, "/web/%69nternal/customer/1/task/123", nil)
+		request.Header.Set("internal", "true")
+
+		assert.Equal(t, http.StatusNotFound, call(request))
+	})
+	t.Run("internal dispatch", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, call(httptest.NewRequest(http.MethodPut, "/fhir/Task/123", nil)))
+	})
+	t.Run("other endpoints", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, call(httptest.NewRequest(http.MethodPut, "/web/private/task/123", nil)))
+	})
+}
+
+func TestSecurityFilter_internalDispatch(t *testing.T) {
+	filter := SecurityFilter{Auth: stubService{}}
+	middleware := filter.AuthWithConfig(Config{
+		ErrorF: func(c echo.Context, err error) error {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		},
+	})
+	handler := middleware(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusAccepted)
+	})
+
+	t.Run("external call without access token", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPut, "/web/internal/customer/1/task/123", nil)
+		ctx := echo.New().NewContext(request, httptest.NewRecorder())
+		// the echo context value that used to skip the filter has no effect
+		ctx.Set("internal", true)
+
+		err := handler(ctx)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusForbidden, err.(*echo.HTTPError).Code)
+		}
+	})
+	t.Run("internal dispatch", func(t *testing.T) {
+		request := WithInternalDispatch(httptest.NewRequest(http.MethodPut, "/web/internal/customer/1/task/123", nil))
+		recorder := httptest.NewRecorder()
+
+		assert.NoError(t, handler(echo.New().NewContext(request, recorder)))
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+	})
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,6 +13,20 @@ import (
 
 const AccessToken = "accessToken"
 
+type accessTokenContextKey struct{}
+
+// AccessTokenFromContext returns the introspected access token the SecurityFilter stored in the request context.
+// Unlike the echo context, the request context survives when a request is dispatched internally.
+func AccessTokenFromContext(ctx context.Context) (nutsAuthClient.TokenIntrospectionResponse, bool) {
+	token, ok := ctx.Value(accessTokenContextKey{}).(nutsAuthClient.TokenIntrospectionResponse)
+	return token, ok
+}
+
+// WithAccessToken returns a copy of the context that holds the introspected access token.
+func WithAccessToken(ctx context.Context, token nutsAuthClient.TokenIntrospectionResponse) context.Context {
+	return context.WithValue(ctx, accessTokenContextKey{}, token)
+}
+
 type ErrorFunc func(c echo.Context, err error) error
 
 func DefaultErrorFunc(c echo.Context, err error) error {
@@ -32,6 +47,8 @@ type Config struct {
 
 type SecurityFilter struct {
 	Auth Service
+	// CertificateBinding checks the TLS client certificate of the request against the access token, if set.
+	CertificateBinding AccessFunc
 }
 
 func (filter SecurityFilter) AuthWithConfig(config Config) echo.MiddlewareFunc {
@@ -51,11 +68,9 @@ func (filter SecurityFilter) AuthWithConfig(config Config) echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			if internalValue := c.Get("internal"); internalValue != nil {
-				if value, ok := internalValue.(bool); ok && value {
-					// this call has already been validated, it must be a Task update
-					return next(c)
-				}
+			if IsInternalDispatch(c.Request().Context()) {
+				// this call has already been validated by the FHIR proxy
+				return next(c)
 			}
 
 			c.Logger().Debugf("Checking access token on %s %s", c.Request().Method, c.Request().RequestURI)
@@ -65,6 +80,13 @@ func (filter SecurityFilter) AuthWithConfig(config Config) echo.MiddlewareFunc {
 			}
 
 			c.Set(AccessToken, *token)
+			c.SetRequest(c.Request().WithContext(WithAccessToken(c.Request().Context(), *token)))
+
+			if filter.CertificateBinding != nil {
+				if err := filter.CertificateBinding(c, c.Request(), token); err != nil {
+					return config.ErrorF(c, fmt.Errorf("not authorized: %w", err))
+				}
+			}
 
 			if err := config.AccessF(c, c.Request(), token); err != nil {
 				return config.ErrorF(c, fmt.Errorf("not authorized: %w", err))
@@ -0,0 +1,90 @@
+package listener
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Listener serves a part of the endpoints of the EHR on its own address, so the endpoints called by other care
+// organizations can be exposed (e.g. in a DMZ) without exposing the UI and private API.
+type Listener struct {
+	// Name is used for logging, e.g. internal or public.
+	Name    string
+	Address string
+	// Allowed returns true if the path may be requested on this listener, other paths get a 404.
+	Allowed func(path string) bool
+	// TLSConfig makes the listener serve HTTPS, e.g. verifying client certificates. If nil, plain HTTP is served.
+	TLSConfig *tls.Config
+	// CertificateRequired returns true if the path may only be requested with a verified client certificate, other
+	// requests get a 403. If nil, no client certificate is required.
+	CertificateRequired func(path string) bool
+}
+
+// Handler returns the handler of the listener, which only passes the allowed requests to next.
+func (l Listener) Handler(next http.Handler) http.Handler {
+	if l.Allowed == nil && l.CertificateRequired == nil {
+		return next
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// dot segments are resolved, so they can't be used to reach other paths
+		cleanPath := path.Clean(request.URL.Path)
+		if l.Allowed != nil && !l.Allowed(cleanPath) {
+			http.NotFound(writer, request)
+			return
+		}
+		// the TLS configuration verified the certificate chain, if a certificate was given
+		if l.CertificateRequired != nil && l.CertificateRequired(cleanPath) && (request.TLS == nil || len(request.TLS.VerifiedChains) == 0) {
+			http.Error(writer, "client certificate required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// Start serves the handler on all listeners. It blocks until one of the listeners fails, and returns its error.
+func Start(handler http.Handler, listeners ...Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		server := &http.Server{
+			Addr:      l.Address,
+			Handler:   l.Handler(handler),
+			TLSConfig: l.TLSConfig,
+		}
+		name := l.Name
+		go func() {
+			logrus.Infof("Starting %s listener (address=%s, tls=%t)", name, server.Addr, server.TLSConfig != nil)
+			if server.TLSConfig != nil {
+				// the certificate is part of the TLS configuration
+				errs <- fmt.Errorf("%s listener: %w", name, server.ListenAndServeTLS("", ""))
+				return
+			}
+			errs <- fmt.Errorf("%s listener: %w", name, server.ListenAndServe())
+		}()
+	}
+	return <-errs
+}
+
+// WithPrefix returns a function that allows the paths starting with one of the prefixes.
+func WithPrefix(prefixes ...string) func(path string) bool {
+	return func(path string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WithoutPrefix returns a function that allows all paths, except those starting with one of the prefixes.
+func WithoutPrefix(prefixes ...string) func(path string) bool {
+	allowed := WithPrefix(prefixes...)
+	return func(path string) bool {
+		return !allowed(path)
+	}
+}
@@ -0,0 +1,61 @@
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListener_Handler(t *testing.T) {
+	next := http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusNoContent)
+	})
+	call := func(l Listener, path string) int {
+		recorder := httptest.NewRecorder()
+		l.Handler(next).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder.Code
+	}
+
+	t.Run("public", func(t *testing.T) {
+		public := Listener{Allowed: WithPrefix("/web/external/", "/fhir/")}
+
+		assert.Equal(t, http.StatusNoContent, call(public, "/web/external/transfer/notify/123"))
+		assert.Equal(t, http.StatusNoContent, call(public, "/fhir/Task/123"))
+		assert.Equal(t, http.StatusNotFound, call(public, "/web/private/customer"))
+		assert.Equal(t, http.StatusNotFound, call(public, "/web/internal/customer/1/task/123"))
+		assert.Equal(t, http.StatusNotFound, call(public, "/"))
+		assert.Equal(t, http.StatusNotFound, call(public, "/web/%65xternal/../private/customer"))
+	})
+	t.Run("internal", func(t *testing.T) {
+		internal := Listener{Allowed: WithoutPrefix("/web/external/")}
+
+		assert.Equal(t, http.StatusNoContent, call(internal, "/web/private/customer"))
+		assert.Equal(t, http.StatusNoContent, call(internal, "/"))
+		assert.Equal(t, http.StatusNotFound, call(internal, "/web/external/transfer/notify/123"))
+	})
+	t.Run("client certificate required", func(t *testing.T) {
+		public := Listener{
+			Allowed:             WithPrefix("/status", "/web/external/"),
+			CertificateRequired: func(path string) bool { return path != "/status" },
+		}
+		withCertificate := func(path string) int {
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+			recorder := httptest.NewRecorder()
+			public.Handler(next).ServeHTTP(recorder, request)
+			return recorder.Code
+		}
+
+		assert.Equal(t, http.StatusNoContent, call(public, "/status"))
+		assert.Equal(t, http.StatusForbidden, call(public, "/web/external/transfer/notify/123"))
+		assert.Equal(t, http.StatusForbidden, call(public, "/status/../web/external/transfer/notify/123"))
+		assert.Equal(t, http.StatusNoContent, withCertificate("/web/external/transfer/notify/123"))
+	})
+	t.Run("all endpoints", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, call(Listener{}, "/web/external/transfer/notify/123"))
+	})
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const launchContextKey = "launchContext"
+
+// LaunchContext contains the context of a launch token the EHR issued to a local app for a logged-in user.
+// The app may only read the resources of the patient the token was issued for.
+type LaunchContext struct {
+	CustomerID int
+	SessionID  string
+	PatientID  string
+}
+
+// LaunchTokenValidator validates launch tokens issued by the EHR itself.
+type LaunchTokenValidator interface {
+	// ValidateLaunchToken returns the launch context of the given token or an error if it isn't a valid launch token.
+	ValidateLaunchToken(ctx context.Context, token string) (*LaunchContext, error)
+}
+
+// parseLaunchToken returns the launch context if the request carries a valid launch token.
+func (server *Server) parseLaunchToken(ctx echo.Context) (*LaunchContext, bool) {
+	if server.launchTokens == nil {
+		return nil, false
+	}
+
+	bearerToken, err := server.auth.ParseBearerToken(ctx.Request())
+	if err != nil {
+		return nil, false
+	}
+
+	launch, err := server.launchTokens.ValidateLaunchToken(ctx.Request().Context(), bearerToken)
+	if err != nil {
+		return nil, false
+	}
+
+	return launch, true
+}
+
+// crossResourceParams are the search parameters that return or match resources other than those searched for,
+// which might belong to other patients.
+var crossResourceParams = []string{"_include", "_revinclude", "_has"}
+
+// verifyLaunchAccess only allows reading the patient of the launch context and searching resources by that patient.
+func (server *Server) verifyLaunchAccess(launch LaunchContext, route fhirRoute) error {
+	if route.operation != "read" {
+		return fmt.Errorf("incorrect operation %s on: %s, must be read", route.operation, route.path())
+	}
+
+	// the FHIR server doesn't restrict included or chained resources to the patient, so they aren't allowed
+	for param := range route.url.Query() {
+		name := strings.SplitN(param, ":", 2)[0]
+		for _, crossResourceParam := range crossResourceParams {
+			if name == crossResourceParam {
+				return fmt.Errorf("access denied for %s in route: %s, only resources of the patient can be searched", param, route.path())
+			}
+		}
+		if strings.Contains(param, ".") {
+			return fmt.Errorf("access denied for chained parameter %s in route: %s", param, route.path())
+		}
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(route.path(), server.path), "/"), "/")
+
+	switch len(segments) {
+	case 1:
+		// search, must be restricted to the patient
+		query := route.url.Query()
+		found := false
+
+		for _, param := range []string{"patient", "subject"} {
+			for _, value := range query[param] {
+				if value != launch.PatientID && value != "Patient/"+launch.PatientID {
+					return fmt.Errorf("access denied for %s=%s in route: %s", param, value, route.path())
+				}
+				found = true
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("access denied for route: %s, search must be restricted to the patient", route.path())
+		}
+
+		return nil
+	case 2:
+		if segments[0] == "Patient" && segments[1] == launch.PatientID {
+			return nil
+		}
+	}
+
+	return errors.New("access denied for route: " + route.path())
+}
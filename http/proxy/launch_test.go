@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_verifyLaunchAccess(t *testing.T) {
+	server := &Server{path: "/fhir"}
+	launch := LaunchContext{CustomerID: 1, SessionID: "session", PatientID: "123"}
+
+	verify := func(method, target string) error {
+		return server.verifyLaunchAccess(launch, *parseRoute(httptest.NewRequest(method, target, nil)))
+	}
+
+	t.Run("ok - read patient", func(t *testing.T) {
+		assert.NoError(t, verify(http.MethodGet, "/fhir/Patient/123"))
+	})
+	t.Run("ok - search by subject", func(t *testing.T) {
+		assert.NoError(t, verify(http.MethodGet, "/fhir/Observation?subject=Patient/123"))
+	})
+	t.Run("ok - search by patient", func(t *testing.T) {
+		assert.NoError(t, verify(http.MethodGet, "/fhir/EpisodeOfCare?patient=123"))
+	})
+	t.Run("error - other patient", func(t *testing.T) {
+		assert.Error(t, verify(http.MethodGet, "/fhir/Patient/456"))
+	})
+	t.Run("error - search for other patient", func(t *testing.T) {
+		assert.Error(t, verify(http.MethodGet, "/fhir/Observation?subject=Patient/123&subject=Patient/456"))
+	})
+	t.Run("error - unrestricted search", func(t *testing.T) {
+		assert.Error(t, verify(http.MethodGet, "/fhir/Observation"))
+	})
+	t.Run("error - include resources of other patients", func(t *testing.T) {
+		assert.Error(t, verify(http.MethodGet, "/fhir/Observation?subject=Patient/123&_include=Observation:performer"))
+		assert.Error(t, verify(http.MethodGet, "/fhir/Patient?patient=123&_revinclude=Observation:subject"))
+		assert.Error(t, verify(http.MethodGet, "/fhir/Observation?subject=Patient/123&_include:iterate=Observation:subject"))
+	})
+	t.Run("error - chained search", func(t *testing.T) {
+		assert.Error(t, verify(http.MethodGet, "/fhir/Observation?subject=Patient/123&subject.name=Henk"))
+		assert.Error(t, verify(http.MethodGet, "/fhir/Observation?subject=Patient/123&performer:Organization.name=Amersfoort"))
+		assert.Error(t, verify(http.MethodGet, "/fhir/Patient?patient=123&_has:Observation:patient:code=1234"))
+	})
+	t.Run("error - read other resource", func(t *testing.T) {
+		assert.Error(t, verify(http.MethodGet, "/fhir/Observation/1"))
+	})
+	t.Run("error - write", func(t *testing.T) {
+		assert.Error(t, verify(http.MethodPost, "/fhir/Observation?subject=Patient/123"))
+	})
+}
@@ -1,13 +1,15 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"io"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
 	"strings"
 
 	"github.com/nuts-foundation/go-did/vc"
@@ -23,46 +25,50 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-var fhirServerTenant = struct{}{}
+type fhirServerTenantKey struct{}
+
+var fhirServerTenant = fhirServerTenantKey{}
 
 type Server struct {
-	proxy               *httputil.ReverseProxy
-	auth                auth.Service
-	path                string
-	customerRepository  customers.Repository
-	vcRegistry          registry.VerifiableCredentialRegistry
-	multiTenancyEnabled bool
+	proxy              *httputil.ReverseProxy
+	auth               auth.Service
+	path               string
+	customerRepository customers.Repository
+	vcRegistry         registry.VerifiableCredentialRegistry
+	launchTokens       LaunchTokenValidator
+	tenancy            fhir.TenantStrategy
+	// certificateBinding checks the client certificate of other care organizations against their access token, if set
+	certificateBinding auth.AccessFunc
 }
 
-func NewServer(authService auth.Service, customerRepository customers.Repository, vcRegistry registry.VerifiableCredentialRegistry, targetURL url.URL, path string, multiTenancyEnabled bool) *Server {
+func NewServer(authService auth.Service, customerRepository customers.Repository, vcRegistry registry.VerifiableCredentialRegistry, launchTokens LaunchTokenValidator, tenancy fhir.TenantStrategy, path string, certificateBinding auth.AccessFunc) *Server {
 	server := &Server{
-		path:                path,
-		auth:                authService,
-		customerRepository:  customerRepository,
-		vcRegistry:          vcRegistry,
-		multiTenancyEnabled: multiTenancyEnabled,
+		path:               path,
+		auth:               authService,
+		customerRepository: customerRepository,
+		vcRegistry:         vcRegistry,
+		launchTokens:       launchTokens,
+		tenancy:            tenancy,
+		certificateBinding: certificateBinding,
 	}
 
 	server.proxy = &httputil.ReverseProxy{
-		// Does not support query parameters in targetURL
 		Director: func(req *http.Request) {
-			requestURL := &url.URL{}
-			*requestURL = *req.URL
-			requestURL.Scheme = targetURL.Scheme
-			requestURL.Host = targetURL.Host
-			requestURL.RawPath = "" // Not required?
-
-			if server.multiTenancyEnabled {
-				tenant := req.Context().Value(fhirServerTenant).(int) // this shouldn't/can't fail, because the middleware handler should've set it.
-				requestURL.Path = fmt.Sprintf("%s/%d%s", targetURL.Path, tenant, req.URL.Path[len(path):])
-			} else {
-				requestURL.Path = targetURL.Path + req.URL.Path[len(path):]
-			}
+			tenant := req.Context().Value(fhirServerTenant).(int) // this shouldn't/can't fail, because the handler should've set it.
+
+			// the handler already checked the URL can be resolved for the tenant
+			requestURL, _ := server.tenancy.URL(tenant, req.URL.Path[len(path):])
+			requestURL.RawQuery = req.URL.RawQuery
 
 			req.URL = requestURL
 			req.Host = requestURL.Host
 
-			logrus.Debugf("Rewritten to: %s", req.URL.Path)
+			// tenant headers always replace the ones set by the requester
+			for name, values := range server.tenancy.Header(tenant) {
+				req.Header[name] = values
+			}
+
+			logrus.Debugf("Rewritten to: %s", req.URL.String())
 
 			if _, ok := req.Header["User-Agent"]; !ok {
 				// explicitly disable User-Agent so it's not set to default value
@@ -81,7 +87,31 @@ func (server *Server) AuthMiddleware() echo.MiddlewareFunc {
 		AccessF: server.verifyAccess,
 	}
 
-	return auth.SecurityFilter{Auth: server.auth}.AuthWithConfig(config)
+	nutsFilter := auth.SecurityFilter{Auth: server.auth, CertificateBinding: server.certificateBinding}.AuthWithConfig(config)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		nutsHandler := nutsFilter(next)
+
+		return func(c echo.Context) error {
+			if server.skipper(c) {
+				return next(c)
+			}
+
+			// local apps use a launch token issued by the EHR instead of a Nuts access token
+			launch, ok := server.parseLaunchToken(c)
+			if !ok {
+				return nutsHandler(c)
+			}
+
+			if err := server.verifyLaunchAccess(*launch, *parseRoute(c.Request())); err != nil {
+				return errorFunc(c, fmt.Errorf("not authorized: %w", err))
+			}
+
+			c.Set(launchContextKey, *launch)
+
+			return next(c)
+		}
+	}
 }
 
 func (server *Server) skipper(ctx echo.Context) bool {
@@ -96,33 +126,49 @@ func errorFunc(ctx echo.Context, err error) error {
 
 func (server *Server) Handler(other echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// Task update
-		if intervalValue := c.Get("internal"); intervalValue != nil {
-			if value, ok := intervalValue.(bool); ok && value {
-				c.Logger().Debugf("routing internally to %s", c.Request().URL.Path)
+		// Task updates and contributions
+		if auth.IsInternalDispatch(c.Request().Context()) {
+			c.Logger().Debugf("routing internally to %s", c.Request().URL.Path)
 
-				return other(c)
-			}
+			return other(c)
 		}
 
 		// non task FHIR resources
-		c.Logger().Debugf("FHIR Proxy: proxying %s %s", c.Request().Method, c.Request().RequestURI)
-		accessToken := c.Get(auth.AccessToken).(nutsAuthClient.TokenIntrospectionResponse)
+		var (
+			requester string
+			tenant    int
+			err       error
+		)
+
+		if launch, ok := c.Get(launchContextKey).(LaunchContext); ok {
+			requester = fmt.Sprintf("customer %d (session=%s, patient=%s)", launch.CustomerID, launch.SessionID, launch.PatientID)
+			tenant = launch.CustomerID
+		} else {
+			accessToken := c.Get(auth.AccessToken).(nutsAuthClient.TokenIntrospectionResponse)
+			if accessToken.Sub != nil {
+				requester = *accessToken.Sub
+			}
 
-		if server.multiTenancyEnabled {
-			// Enrich request with resource owner's FHIR server tenant, which is the customer's ID
-			tenant, err := server.getTenant(*accessToken.Iss)
+			// resource owner's FHIR server tenant, which is the customer's ID
+			tenant, err = server.getTenant(c.Request().Context(), *accessToken.Iss)
 			if err != nil {
 				return c.JSON(http.StatusBadRequest, NewOperationOutcome(err, err.Error(), CodeSecurity, SeverityError))
 			}
+		}
 
-			c.SetRequest(c.Request().WithContext(context.WithValue(
-				c.Request().Context(),
-				fhirServerTenant,
-				tenant,
-			)))
+		if _, err := server.tenancy.URL(tenant, c.Request().URL.Path[len(server.path):]); err != nil {
+			return c.JSON(http.StatusInternalServerError, NewOperationOutcome(err, err.Error(), CodeProcessing, SeverityError))
 		}
 
+		c.Logger().Debugf("FHIR Proxy: proxying %s %s for %s", c.Request().Method, c.Request().RequestURI, requester)
+
+		// Enrich request with the tenant, so the request is routed to the tenant's data
+		c.SetRequest(c.Request().WithContext(context.WithValue(
+			c.Request().Context(),
+			fhirServerTenant,
+			tenant,
+		)))
+
 		// proxy handling
 		server.proxy.ServeHTTP(c.Response(), c.Request())
 
@@ -147,30 +193,26 @@ func (server *Server) verifyAccess(ctx echo.Context, request *http.Request, toke
 			return err
 		}
 
+		// resources created by collaborators are validated and stored by the EHR, so must be routed internally
+		if route.operation == "create" {
+			return server.routeContribution(ctx, token, subjects, *route)
+		}
+
+		if route.operation != "read" {
+			return fmt.Errorf("incorrect operation %s on: %s, must be read or create", route.operation, route.path())
+		}
+
 		// observation specific access
 		observationPath := server.path + "/Observation"
 
 		if route.path() == observationPath {
-			if route.operation != "read" {
-				return fmt.Errorf("incorrect operation %s on: %s, must be read", route.operation, observationPath)
-			}
-
-			var episodeOfCareID string
-
-			for _, subject := range subjects {
-				for _, resource := range subject.Resources {
-					if strings.HasPrefix(resource.Path, "/EpisodeOfCare/") {
-						episodeOfCareID = resource.Path[len("/EpisodeOfCare/"):]
-						break
-					}
-				}
-			}
-
-			if episodeOfCareID == "" {
+			contexts := route.url.Query()["context"]
+			if len(contexts) != 1 || !strings.HasPrefix(contexts[0], "EpisodeOfCare/") {
 				return fmt.Errorf("unable to find context for route: %s", route.path())
 			}
 
-			if route.url.Query().Get("context") != fmt.Sprintf("EpisodeOfCare/%s", episodeOfCareID) {
+			episodeOfCareID := strings.TrimPrefix(contexts[0], "EpisodeOfCare/")
+			if !grantsEpisodeOfCare(subjects, episodeOfCareID, "read") {
 				return fmt.Errorf("access denied for episode %s in route: %s", episodeOfCareID, route.path())
 			}
 
@@ -199,20 +241,20 @@ func (server *Server) verifyAccess(ctx echo.Context, request *http.Request, toke
 
 		// Task updates must be routed internally
 		if route.operation == "update" && strings.HasPrefix(route.path(), serverTaskPath) {
-			tenant, err := server.getTenant(*token.Iss)
+			tenant, err := server.getTenant(request.Context(), *token.Iss)
 			if err != nil {
 				return fmt.Errorf("access denied for %s on %s, tenant %s: %w", route.operation, route.path(), *token.Iss, err)
 			}
 
 			// task handling
-			req := ctx.Request()
-			path := fmt.Sprintf("/web/internal/customer/%d/task/%s", tenant, route.resourceID)
-			req.URL.Path = path
-			req.URL.RawPath = path
-			req.RequestURI = path
-			ctx.SetRequest(req)
-
-			ctx.Set("internal", true)
+			routeInternally(ctx, fmt.Sprintf("/web/internal/customer/%d/task/%s", tenant, route.resourceID))
+
+			return nil
+		}
+
+		// other resources may only be read, they're never written through the proxy
+		if route.operation != "read" {
+			return fmt.Errorf("incorrect operation %s on: %s, only Task updates are allowed", route.operation, route.path())
 		}
 	default:
 		return fmt.Errorf("access-token contains incorrect 'service' claim: %s, must be %s", *service, transfer.SenderServiceName)
@@ -221,6 +263,68 @@ func (server *Server) verifyAccess(ctx echo.Context, request *http.Request, toke
 	return nil
 }
 
+// routeContribution checks whether the requester may create the resource in the episode the resource belongs to,
+// and routes the request to the EHR which validates and stores the resource.
+func (server *Server) routeContribution(ctx echo.Context, token *nutsAuthClient.TokenIntrospectionResponse, subjects []credential.NutsAuthorizationCredentialSubject, route fhirRoute) error {
+	resourceType := strings.TrimPrefix(route.path(), server.path+"/")
+	if !zorginzage.IsContributableResourceType(resourceType) {
+		return fmt.Errorf("incorrect operation %s on: %s, must be one of %s", route.operation, route.path(), strings.Join(zorginzage.ContributableResourceTypes, ", "))
+	}
+
+	data, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", resourceType, err)
+	}
+	// the EHR reads the resource again when it handles the request
+	ctx.Request().Body = io.NopCloser(bytes.NewReader(data))
+
+	episodeOfCareID, err := zorginzage.ContributionEpisodeID(resourceType, data)
+	if err != nil {
+		return fmt.Errorf("access denied for %s on %s: %w", route.operation, route.path(), err)
+	}
+	if !grantsEpisodeOfCare(subjects, episodeOfCareID, "create") {
+		return fmt.Errorf("access denied for %s on %s: no NutsAuthorizationCredential grants create on EpisodeOfCare/%s", route.operation, route.path(), episodeOfCareID)
+	}
+
+	tenant, err := server.getTenant(ctx.Request().Context(), *token.Iss)
+	if err != nil {
+		return fmt.Errorf("access denied for %s on %s, tenant %s: %w", route.operation, route.path(), *token.Iss, err)
+	}
+
+	routeInternally(ctx, fmt.Sprintf("/web/internal/customer/%d/episode/%s/%s", tenant, episodeOfCareID, resourceType))
+
+	return nil
+}
+
+// routeInternally alters the request so it's handled by the EHR itself instead of being proxied to the FHIR server.
+func routeInternally(ctx echo.Context, path string) {
+	req := ctx.Request()
+	req.URL.Path = path
+	req.URL.RawPath = path
+	req.URL.RawQuery = ""
+	req.RequestURI = path
+	ctx.SetRequest(auth.WithInternalDispatch(req))
+}
+
+// grantsEpisodeOfCare returns true if one of the subjects grants the operation on the EpisodeOfCare.
+func grantsEpisodeOfCare(subjects []credential.NutsAuthorizationCredentialSubject, episodeOfCareID, operation string) bool {
+	path := "/EpisodeOfCare/" + episodeOfCareID
+	for _, subject := range subjects {
+		for _, resource := range subject.Resources {
+			if resource.Path != path {
+				continue
+			}
+			for _, curr := range resource.Operations {
+				if curr == operation {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 func (server *Server) parseNutsAuthorizationCredentials(ctx context.Context, token *nutsAuthClient.TokenIntrospectionResponse) ([]credential.NutsAuthorizationCredentialSubject, error) {
 	var subjects []credential.NutsAuthorizationCredentialSubject
 
@@ -229,7 +333,7 @@ func (server *Server) parseNutsAuthorizationCredentials(ctx context.Context, tok
 	}
 
 	for _, credentialID := range *token.Vcs {
-		// resolve credential. NutsAuthCredential must be resolved with the untrusted flag
+		// the registry only resolves credentials of issuers that are trusted according to the trust policy
 		authCredential, err := server.vcRegistry.ResolveVerifiableCredential(ctx, credentialID)
 		if err != nil {
 			return nil, fmt.Errorf("invalid credential: %w", err)
@@ -239,12 +343,23 @@ func (server *Server) parseNutsAuthorizationCredentials(ctx context.Context, tok
 			continue
 		}
 
+		// only the custodian of the data can grant access to it
+		if token.Iss == nil || authCredential.Issuer.String() != *token.Iss {
+			return nil, fmt.Errorf("invalid credential: %s isn't issued by the authorizer", credentialID)
+		}
+
 		subject := make([]credential.NutsAuthorizationCredentialSubject, 0)
 
 		if err := authCredential.UnmarshalCredentialSubject(&subject); err != nil {
 			return nil, fmt.Errorf("invalid content for NutsAuthorizationCredential credentialSubject: %w", err)
 		}
 
+		for _, curr := range subject {
+			if token.Sub == nil || curr.ID != *token.Sub {
+				return nil, fmt.Errorf("invalid credential: %s isn't issued to the requester", credentialID)
+			}
+		}
+
 		subjects = append(subjects, subject...)
 	}
 
@@ -283,12 +398,12 @@ func (server *Server) validateWithNutsAuthorizationCredential(token *nutsAuthCli
 	return errors.New("no NutsAuthorizationCredential in access-token")
 }
 
-func (server *Server) getTenant(requesterDID string) (int, error) {
-	customer, err := server.customerRepository.FindByDID(requesterDID)
+func (server *Server) getTenant(ctx context.Context, requesterDID string) (int, error) {
+	customer, err := server.customerRepository.FindByDID(ctx, requesterDID)
 	if err != nil {
 		return 0, err
 	}
-	if customer == nil {
+	if customer == nil || !customer.Active {
 		return 0, errors.New("unknown tenant")
 	}
 	return customer.Id, nil
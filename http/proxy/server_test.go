@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/go-did/vc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/http/auth"
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/registry"
+)
+
+func TestRouteInternally(t *testing.T) {
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodPut, "/fhir/Task/123?_format=json", nil), httptest.NewRecorder())
+	assert.False(t, auth.IsInternalDispatch(ctx.Request().Context()))
+
+	routeInternally(ctx, "/web/internal/customer/1/task/123")
+
+	assert.True(t, auth.IsInternalDispatch(ctx.Request().Context()))
+	assert.Equal(t, "/web/internal/customer/1/task/123", ctx.Request().RequestURI)
+	assert.Empty(t, ctx.Request().URL.RawQuery)
+}
+
+// stubCredentials resolves the credentials by their ID
+type stubCredentials struct {
+	registry.VerifiableCredentialRegistry
+	credentials map[string]vc.VerifiableCredential
+}
+
+func (s stubCredentials) ResolveVerifiableCredential(_ context.Context, credentialID string) (*vc.VerifiableCredential, error) {
+	result, ok := s.credentials[credentialID]
+	if !ok {
+		return nil, errors.New("credential not found")
+	}
+	return &result, nil
+}
+
+// stubCustomers contains a single customer, the custodian of the episodes
+type stubCustomers struct {
+	customers.Repository
+}
+
+func (stubCustomers) FindByDID(_ context.Context, did string) (*types.Customer, error) {
+	if did != custodianDID {
+		return nil, nil
+	}
+	return &types.Customer{Id: 1, Did: &did, Active: true}, nil
+}
+
+const custodianDID = "did:nuts:custodian"
+const collaboratorDID = "did:nuts:collaborator"
+
+// authorizationCredential grants the collaborator the operations on the episode
+func authorizationCredential(t *testing.T, id, purposeOfUse, episodeID string, operations ...string) vc.VerifiableCredential {
+	data, _ := json.Marshal(map[string]interface{}{
+		"@context":     []string{"https://www.w3.org/2018/credentials/v1"},
+		"id":           id,
+		"type":         []string{"VerifiableCredential", "NutsAuthorizationCredential"},
+		"issuer":       custodianDID,
+		"issuanceDate": "2022-01-10T12:00:00Z",
+		"credentialSubject": map[string]interface{}{
+			"id":           collaboratorDID,
+			"purposeOfUse": purposeOfUse,
+			"resources":    []map[string]interface{}{{"path": "/EpisodeOfCare/" + episodeID, "operations": operations}},
+		},
+	})
+	result := vc.VerifiableCredential{}
+	require.NoError(t, json.Unmarshal(data, &result))
+	return result
+}
+
+func TestServer_verifyAccess_contributions(t *testing.T) {
+	server := &Server{
+		path:               "/fhir",
+		customerRepository: stubCustomers{},
+		vcRegistry: stubCredentials{credentials: map[string]vc.VerifiableCredential{
+			custodianDID + "#create": authorizationCredential(t, custodianDID+"#create", zorginzage.ServiceName, "granted", "read", "create"),
+			custodianDID + "#read":   authorizationCredential(t, custodianDID+"#read", zorginzage.ServiceName, "readonly", "read"),
+		}},
+	}
+	observation := func(episodeReference string) string {
+		data, _ := json.Marshal(map[string]interface{}{
+			"resourceType": "Observation",
+			"code":         map[string]interface{}{"coding": []map[string]string{{"system": "http://loinc.org", "code": "8867-4"}}},
+			"context":      map[string]string{"reference": episodeReference},
+		})
+		return string(data)
+	}
+
+	testCases := []struct {
+		name    string
+		service string
+		path    string
+		body    string
+		// routedTo is the path of the EHR the request must be routed to, empty if access must be denied
+		routedTo string
+	}{
+		{
+			name:     "allowed",
+			service:  zorginzage.ServiceName,
+			path:     "/fhir/Observation",
+			body:     observation("EpisodeOfCare/granted"),
+			routedTo: "/web/internal/customer/1/episode/granted/Observation",
+		},
+		{
+			name:    "foreign episode",
+			service: zorginzage.ServiceName,
+			path:    "/fhir/Observation",
+			body:    observation("EpisodeOfCare/foreign"),
+		},
+		{
+			name:    "episode only granted to read",
+			service: zorginzage.ServiceName,
+			path:    "/fhir/Observation",
+			body:    observation("EpisodeOfCare/readonly"),
+		},
+		{
+			name:    "no episode",
+			service: zorginzage.ServiceName,
+			path:    "/fhir/Observation",
+			body:    `{"resourceType": "Observation"}`,
+		},
+		{
+			name:    "resource type that can't be contributed",
+			service: zorginzage.ServiceName,
+			path:    "/fhir/Patient",
+			body:    `{"resourceType": "Patient"}`,
+		},
+		{
+			name:    "create in another service",
+			service: transfer.SenderServiceName,
+			path:    "/fhir/Observation",
+			body:    observation("EpisodeOfCare/granted"),
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, testCase.path, strings.NewReader(testCase.body))
+			ctx := echo.New().NewContext(request, httptest.NewRecorder())
+			iss, sub := custodianDID, collaboratorDID
+			token := &nutsAuthClient.TokenIntrospectionResponse{
+				Active:  true,
+				Iss:     &iss,
+				Sub:     &sub,
+				Service: &testCase.service,
+				Vcs:     &[]string{custodianDID + "#create", custodianDID + "#read"},
+			}
+
+			err := server.verifyAccess(ctx, ctx.Request(), token)
+
+			if testCase.routedTo == "" {
+				assert.Error(t, err)
+				assert.False(t, auth.IsInternalDispatch(ctx.Request().Context()))
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, auth.IsInternalDispatch(ctx.Request().Context()))
+			assert.Equal(t, testCase.routedTo, ctx.Request().URL.Path)
+			body, _ := io.ReadAll(ctx.Request().Body)
+			assert.JSONEq(t, testCase.body, string(body), "the EHR must receive the resource")
+		})
+	}
+}
+
+func TestServer_verifyAccess_observations(t *testing.T) {
+	server := &Server{
+		path:               "/fhir",
+		customerRepository: stubCustomers{},
+		vcRegistry: stubCredentials{credentials: map[string]vc.VerifiableCredential{
+			custodianDID + "#1": authorizationCredential(t, custodianDID+"#1", zorginzage.ServiceName, "first", "read"),
+			custodianDID + "#2": authorizationCredential(t, custodianDID+"#2", zorginzage.ServiceName, "second", "read"),
+		}},
+	}
+	verify := func(target string) error {
+		request := httptest.NewRequest(http.MethodGet, target, nil)
+		ctx := echo.New().NewContext(request, httptest.NewRecorder())
+		iss, sub, service := custodianDID, collaboratorDID, zorginzage.ServiceName
+		return server.verifyAccess(ctx, request, &nutsAuthClient.TokenIntrospectionResponse{
+			Active:  true,
+			Iss:     &iss,
+			Sub:     &sub,
+			Service: &service,
+			Vcs:     &[]string{custodianDID + "#1", custodianDID + "#2"},
+		})
+	}
+
+	t.Run("ok - any granted episode", func(t *testing.T) {
+		assert.NoError(t, verify("/fhir/Observation?context=EpisodeOfCare/first"))
+		assert.NoError(t, verify("/fhir/Observation?context=EpisodeOfCare/second"))
+	})
+	t.Run("error - foreign episode", func(t *testing.T) {
+		assert.Error(t, verify("/fhir/Observation?context=EpisodeOfCare/foreign"))
+	})
+	t.Run("error - multiple episodes", func(t *testing.T) {
+		assert.Error(t, verify("/fhir/Observation?context=EpisodeOfCare/first&context=EpisodeOfCare/foreign"))
+	})
+	t.Run("error - no episode", func(t *testing.T) {
+		assert.Error(t, verify("/fhir/Observation"))
+	})
+}
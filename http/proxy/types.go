@@ -1,8 +1,9 @@
 package proxy
 
 const (
-	CodeSecurity  = "security"
-	SeverityError = "error"
+	CodeSecurity   = "security"
+	CodeProcessing = "processing"
+	SeverityError  = "error"
 )
 
 type IssueDetails struct {
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	openapi_types "github.com/deepmap/oapi-codegen/pkg/types"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/fhirtest"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/nodetest"
+)
+
+const integrationPassword = "integration"
+
+// TestTransfer runs a transfer between two customers of the same EHR, which are onboarded on a stub Nuts node and
+// store their data on an in-memory FHIR server.
+func TestTransfer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	ehr := startEHR(t)
+
+	vendor := ehr.login(t, 1, "vendor")
+	sender := ehr.login(t, 1, "admin")
+	receiver := ehr.login(t, 2, "admin")
+	senderDID := vendor.onboard(t, 1)
+	receiverDID := vendor.onboard(t, 2)
+
+	t.Run("vendor is trusted", func(t *testing.T) {
+		var issuers types.CredentialIssuers
+		vendor.call(t, http.MethodGet, "/web/private/admin/trust/"+credential.NutsOrganizationCredentialType, nil, &issuers)
+
+		assert.Len(t, issuers.Trusted, 1)
+		assert.Empty(t, issuers.Untrusted)
+	})
+
+	t.Run("sender finds the receiver", func(t *testing.T) {
+		var organizations []types.Organization
+		sender.call(t, http.MethodGet, "/web/private/network/organizations?query=Amersfoort&didServiceType="+transfer.ReceiverServiceName, nil, &organizations)
+
+		require.Len(t, organizations, 1)
+		assert.Equal(t, receiverDID, organizations[0].Did)
+	})
+
+	// the sender creates a transfer and requests the receiver to take over the care
+	var patient types.Patient
+	sender.call(t, http.MethodPost, "/web/private/patients", types.PatientProperties{
+		FirstName: "Henk",
+		Surname:   "de Vries",
+		Gender:    types.PatientPropertiesGenderMale,
+		Zipcode:   "3817AB",
+		Dob:       &openapi_types.Date{Time: time.Date(1940, 5, 12, 0, 0, 0, 0, time.UTC)},
+	}, &patient)
+	var dossier types.Dossier
+	sender.call(t, http.MethodPost, "/web/private/dossier", types.CreateDossierRequest{Name: "Transfer", PatientID: patient.ObjectID}, &dossier)
+	transferDate := openapi_types.Date{Time: time.Now().AddDate(0, 0, 7)}
+	var senderTransfer types.Transfer
+	sender.call(t, http.MethodPost, "/web/private/transfer", types.CreateTransferRequest{
+		DossierID: dossier.Id,
+		TransferProperties: types.TransferProperties{
+			CarePlan: types.CarePlan{PatientProblems: []types.PatientProblem{{
+				Problem:       types.Problem{Name: "Decubitus", Status: types.ProblemStatusActive},
+				Interventions: []types.Intervention{{Comment: "Wisselligging"}},
+			}}},
+			Patient:      patient,
+			TransferDate: transferDate,
+		},
+	}, &senderTransfer)
+	var negotiation types.TransferNegotiation
+	sender.call(t, http.MethodPost, fmt.Sprintf("/web/private/transfer/%s/negotiation", senderTransfer.Id), types.CreateTransferNegotiationRequest{
+		OrganizationDID: receiverDID,
+		TransferDate:    transferDate,
+	}, &negotiation)
+	requestPath := fmt.Sprintf("/web/private/transfer-request/%s/%s", senderDID, negotiation.TaskID)
+
+	t.Run("receiver gets the request", func(t *testing.T) {
+		entry := receiver.inboxEntry(t, negotiation.TaskID)
+
+		assert.Equal(t, senderDID, entry.Sender.Did)
+		assert.Equal(t, transfer.RequestedState, string(entry.Status.Status))
+	})
+
+	t.Run("sender issued a credential for the request", func(t *testing.T) {
+		credentials := sender.issuedCredentials(t)
+
+		require.Len(t, credentials, 1)
+		assert.Equal(t, receiverDID, credentials[0].OrganizationDID)
+		assert.Equal(t, senderTransfer.Id, *credentials[0].TransferID)
+		assert.False(t, credentials[0].Orphaned)
+		require.NotNil(t, credentials[0].ExpirationDate)
+		assert.WithinDuration(t, transferDate.Truncate(24*time.Hour).AddDate(0, 0, 7), *credentials[0].ExpirationDate, time.Second)
+		require.NotNil(t, negotiation.CredentialID)
+		assert.Equal(t, credentials[0].Id, *negotiation.CredentialID)
+	})
+
+	t.Run("sender extends the transfer", func(t *testing.T) {
+		properties := senderTransfer.TransferProperties
+		properties.TransferDate = openapi_types.Date{Time: transferDate.AddDate(0, 0, 3)}
+		sender.call(t, http.MethodPut, fmt.Sprintf("/web/private/transfer/%s", senderTransfer.Id), properties, nil)
+
+		credentials := sender.issuedCredentials(t)
+		require.Len(t, credentials, 1, "the credential must be re-issued")
+		require.NotNil(t, credentials[0].ExpirationDate)
+		assert.WithinDuration(t, properties.TransferDate.Truncate(24*time.Hour).AddDate(0, 0, 7), *credentials[0].ExpirationDate, time.Second)
+		assert.Equal(t, credentials[0].Id, *sender.negotiation(t, senderTransfer.Id).CredentialID)
+	})
+
+	t.Run("receiver accepts", func(t *testing.T) {
+		receiver.call(t, http.MethodPost, requestPath, types.TransferNegotiationStatus{Status: transfer.AcceptedState}, nil)
+
+		assert.Equal(t, transfer.AcceptedState, string(sender.negotiation(t, senderTransfer.Id).Status))
+		assert.Equal(t, transfer.AcceptedState, string(receiver.inboxEntry(t, negotiation.TaskID).Status.Status))
+	})
+
+	t.Run("sender confirms", func(t *testing.T) {
+		sender.call(t, http.MethodPut, fmt.Sprintf("/web/private/transfer/%s/negotiation/%s", senderTransfer.Id, negotiation.Id),
+			types.TransferNegotiationStatus{Status: transfer.InProgressState}, nil)
+
+		assert.Equal(t, types.TransferStatusAssigned, sender.transfer(t, senderTransfer.Id).Status)
+		assert.Equal(t, transfer.InProgressState, string(receiver.inboxEntry(t, negotiation.TaskID).Status.Status))
+		credentials := sender.issuedCredentials(t)
+		require.Len(t, credentials, 1, "the credential must be replaced")
+		assert.Equal(t, credentials[0].Id, *sender.negotiation(t, senderTransfer.Id).CredentialID)
+	})
+
+	t.Run("receiver completes", func(t *testing.T) {
+		receiver.call(t, http.MethodPost, requestPath, types.TransferNegotiationStatus{Status: transfer.CompletedState}, nil)
+
+		assert.Equal(t, types.TransferStatusCompleted, sender.transfer(t, senderTransfer.Id).Status)
+		assert.Equal(t, transfer.CompletedState, string(sender.negotiation(t, senderTransfer.Id).Status))
+		assert.Equal(t, transfer.CompletedState, string(receiver.inboxEntry(t, negotiation.TaskID).Status.Status))
+		assert.Empty(t, sender.issuedCredentials(t), "the credential must be revoked")
+	})
+}
+
+type testEHR struct {
+	url string
+}
+
+// startEHR starts an EHR with two customers, configured to onboard them on a stub Nuts node.
+func startEHR(t *testing.T) testEHR {
+	node := nodetest.NewNode()
+	nodeServer := httptest.NewServer(node)
+	t.Cleanup(nodeServer.Close)
+	fhirServer := httptest.NewServer(fhirtest.NewServer())
+	t.Cleanup(fhirServer.Close)
+
+	// the public URL of the EHR must be known before it's set up
+	var handler http.Handler
+	ehrServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		handler.ServeHTTP(writer, request)
+	}))
+	t.Cleanup(ehrServer.Close)
+
+	vendorDID := node.CreateDID()
+	node.AddService(vendorDID, "oauth", nodeServer.URL+"/n2n/auth/v1/accesstoken")
+
+	dir := t.TempDir()
+	customersFile := filepath.Join(dir, "customers.json")
+	data, _ := json.Marshal(map[string]types.Customer{
+		"1": {Id: 1, Name: "Verpleeghuis De Nootjes", City: stringPtr("Utrecht")},
+		"2": {Id: 2, Name: "Ziekenhuis Amersfoort", City: stringPtr("Amersfoort")},
+	})
+	require.NoError(t, os.WriteFile(customersFile, data, 0600))
+
+	config := defaultConfig()
+	config.NutsNodeAddress = nodeServer.URL
+	config.FHIR.Server = FHIRServer{Type: "other", Address: fhirServer.URL, Tenancy: FHIRTenancy{Strategy: "path"}}
+	config.CustomersFile = customersFile
+	config.DBConnectionString = filepath.Join(dir, "ehr.db")
+	config.SessionKeys.File = filepath.Join(dir, "session-keys.json")
+	config.Credentials.Password = integrationPassword
+	config.Credentials.Vendor = VendorAccount{Customer: 1, Username: "vendor", Password: integrationPassword}
+	config.Onboarding = Onboarding{VendorDID: vendorDID, PublicURL: ehrServer.URL}
+	handler = setupServer(config)
+
+	return testEHR{url: ehrServer.URL}
+}
+
+// login returns a session of the user of the customer: the admin of the customer or the vendor.
+func (e testEHR) login(t *testing.T, customerID int, username string) testSession {
+	session := testSession{url: e.url}
+	var token types.SessionToken
+	session.call(t, http.MethodPost, "/web/auth/passwd", types.PasswordAuthenticateRequest{
+		CustomerID: customerID,
+		Username:   username,
+		Password:   integrationPassword,
+	}, &token)
+	session.token = token.Token
+	return session
+}
+
+type testSession struct {
+	url   string
+	token string
+}
+
+// call performs the request with the body as JSON and unmarshals the response into result, if not nil.
+// It fails the test if the response isn't successful.
+func (s testSession) call(t *testing.T, method, path string, body, result interface{}) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+	request, _ := http.NewRequest(method, s.url+path, reader)
+	request.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	data, _ := io.ReadAll(response.Body)
+	require.Truef(t, response.StatusCode >= 200 && response.StatusCode < 300, "%s %s: %d %s", method, path, response.StatusCode, data)
+	if result != nil {
+		require.NoError(t, json.Unmarshal(data, result))
+	}
+}
+
+// onboard onboards the customer on the Nuts network and returns its DID.
+func (s testSession) onboard(t *testing.T, customerID int) string {
+	var customer types.Customer
+	s.call(t, http.MethodPost, fmt.Sprintf("/web/private/admin/customer/%d/onboard", customerID), nil, &customer)
+	require.NotNil(t, customer.Did)
+	return *customer.Did
+}
+
+func (s testSession) inboxEntry(t *testing.T, taskID string) types.InboxEntry {
+	t.Helper()
+	var entries []types.InboxEntry
+	s.call(t, http.MethodGet, "/web/private/network/inbox", nil, &entries)
+	for _, entry := range entries {
+		if entry.ResourceID == taskID {
+			return entry
+		}
+	}
+	require.Failf(t, "inbox entry not found", "task=%s", taskID)
+	return types.InboxEntry{}
+}
+
+func (s testSession) negotiation(t *testing.T, transferID types.ObjectID) types.TransferNegotiation {
+	t.Helper()
+	var negotiations []types.TransferNegotiation
+	s.call(t, http.MethodGet, fmt.Sprintf("/web/private/transfer/%s/negotiation", transferID), nil, &negotiations)
+	require.Len(t, negotiations, 1)
+	return negotiations[0]
+}
+
+func (s testSession) transfer(t *testing.T, transferID types.ObjectID) types.Transfer {
+	t.Helper()
+	var result types.Transfer
+	s.call(t, http.MethodGet, fmt.Sprintf("/web/private/transfer/%s", transferID), nil, &result)
+	return result
+}
+
+func (s testSession) issuedCredentials(t *testing.T) []types.IssuedCredential {
+	t.Helper()
+	var credentials []types.IssuedCredential
+	s.call(t, http.MethodGet, "/web/private/customer/credentials", nil, &credentials)
+	return credentials
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
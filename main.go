@@ -2,8 +2,7 @@ package main
 
 import (
 	"context"
-	"crypto/elliptic"
-	"crypto/sha1"
+	"crypto/rand"
 	"embed"
 	"encoding/hex"
 	"errors"
@@ -12,24 +11,33 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/episode"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/geo"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/notification"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/onboarding"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/organizations"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer/receiver"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer/sender"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/trust"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
 
 	"github.com/nuts-foundation/nuts-demo-ehr/api"
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/credentials"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/diagnostics"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/dossier"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/fhir/zorginzage"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/patients"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/transfer"
 	httpAuth "github.com/nuts-foundation/nuts-demo-ehr/http/auth"
+	"github.com/nuts-foundation/nuts-demo-ehr/http/listener"
 	"github.com/nuts-foundation/nuts-demo-ehr/http/proxy"
 	nutsClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
 	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
@@ -38,6 +46,7 @@ import (
 
 	openapi_types "github.com/deepmap/oapi-codegen/pkg/types"
 	"github.com/jmoiron/sqlx"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/sirupsen/logrus"
@@ -54,6 +63,9 @@ var embeddedFiles embed.FS
 
 const apiTimeout = 10 * time.Second
 
+// maxSignSessionsPerCustomer bounds the signing sessions started on the (public) login page of a customer
+const maxSignSessionsPerCustomer = 100
+
 func getFileSystem(useFS bool) http.FileSystem {
 	if useFS {
 		logrus.Info("using live mode")
@@ -83,21 +95,154 @@ func main() {
 		logrus.Fatal("Invalid FHIR server type, valid options are: 'hapi-multi-tenant', 'hapi' or 'other'")
 	}
 
+	server := setupServer(config)
+
+	// Start server
+	if config.Public.Enabled() {
+		if config.Public.Port == config.HTTPPort {
+			logrus.Fatal("public.port must differ from port")
+		}
+		public := publicListener(config)
+		if config.TLS.Enabled() {
+			if public.TLSConfig, err = config.TLS.ServerConfig(); err != nil {
+				logrus.Fatal(err)
+			}
+			// health checks can't present a client certificate
+			public.CertificateRequired = func(path string) bool {
+				return path != "/status"
+			}
+		}
+		server.Logger.Fatal(listener.Start(server, internalListener(config), public))
+	}
+	server.Logger.Fatal(server.Start(fmt.Sprintf(":%d", config.HTTPPort)))
+}
+
+// setupServer sets up the EHR, the FHIR proxy and their dependencies as configured. It exits when that fails.
+func setupServer(config Config) *echo.Echo {
+	tenancy, err := config.FHIR.Server.TenantStrategy()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
 	// init node API nutsClient
 	nodeClient := nutsClient.HTTPClient{NutsNodeAddress: config.NutsNodeAddress}
-	vcRegistry := registry.NewVerifiableCredentialRegistry(&nodeClient)
-	customerRepository := customers.NewJsonFileRepository(config.CustomersFile)
+	organizationCache := cache.New(config.Cache.TTL, config.Cache.MaxEntries)
+	orgRegistry := registry.NewOrganizationRegistry(nodeClient, nodeClient, organizationCache, config.Cache.NotFoundTTL)
+
+	// authorization credentials are only accepted from trusted issuers
+	trustPolicy, err := registry.ParseTrustPolicy(config.Trust.AuthorizationPolicy)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	// credentials revoked by their issuer are accepted until they expire from the cache, so they are cached shorter than tokens
+	if config.Cache.CredentialTTL <= 0 || config.Cache.CredentialTTL > config.Cache.TTL {
+		logrus.Fatal("cache.credentialttl must be positive and can't exceed cache.ttl")
+	}
+	credentialCache := cache.New(config.Cache.CredentialTTL, config.Cache.MaxEntries)
+	vcRegistry := registry.NewVerifiableCredentialRegistry(&nodeClient, credentialCache, trustPolicy, orgRegistry)
+	trustService := trust.NewService(nodeClient, organizationCache, credentialCache)
+	trustOrganizationIssuers(trustService, config.TrustedOrganizationIssuers())
+
+	sqlDB := sqlx.MustConnect("sqlite3", config.DBConnectionString)
+	sqlDB.SetMaxOpenConns(1)
+
+	// customers are stored in the database, the customers file is only imported when there are no customers yet
+	customerRepository := customers.NewSQLiteCustomerRepository(sqlDB)
+	importCustomers(customerRepository, sqlDB, config.CustomersFile)
+
+	// the introspected access tokens are shared by the security filters of the EHR and the FHIR proxy
+	nodeAuthService, err := httpAuth.NewService(config.NutsNodeAddress)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tokenCache := cache.New(config.Cache.TTL, config.Cache.MaxEntries)
+	authService := httpAuth.NewCachingService(nodeAuthService, tokenCache)
+
+	// the keys that sign the session tokens are rotated, unless a single key is configured
+	var sessionKeys *api.KeyRing
+	if config.sessionKey != nil {
+		sessionKeys, err = api.NewStaticKeyRing(config.sessionKey)
+	} else {
+		sessionKeys, err = api.NewKeyRing(config.SessionKeys.File)
+	}
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if config.sessionKey == nil {
+		api.StartKeyRotation(sessionKeys, config.SessionKeys.RotationInterval)
+	}
+
+	// every customer gets an admin user, which can be used to create the other users, and the operator a vendor account
+	userRepository := users.NewSQLiteUserRepository(sqlDB)
+	registerAdmins(userRepository, sqlDB, customerRepository, config.Credentials)
+
+	// sessions are persisted, so users stay logged in after a restart when the sessionPemKey is configured
+	sessionStore := api.NewSQLiteSessionStore(sqlDB)
+	api.StartCleanup(sessionStore, time.Minute)
+
+	// the sessions of the EHR are also used to issue launch tokens for the FHIR proxy
+	auth := api.NewAuth(sessionKeys, customerRepository, userRepository, sessionStore)
+	if config.OIDC.Enabled() {
+		auth.EnableOIDC(config.OIDC.AuthConfig())
+		logrus.Infof("OpenID Connect login enabled (issuer=%s)", config.OIDC.Issuer)
+	}
 
 	server := createServer()
 
-	registerEHR(server, config, customerRepository, vcRegistry)
+	// other components can verify the tokens issued by the EHR
+	server.GET("/.well-known/jwks.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, sessionKeys.PublicKeys())
+	})
+
+	// the client certificates of other care organizations must belong to the requester of their access token, if configured
+	var certificateBinding httpAuth.AccessFunc
+	if config.TLS.BindRequester {
+		if !config.Public.Enabled() || !config.TLS.Enabled() {
+			logrus.Fatal("tls.bindrequester requires public.port and tls.certfile to be set")
+		}
+		certificateBinding = httpAuth.RequesterCertificateBinding(config.TLS.SerialNumbers())
+	}
+
+	// admins can see the usage statistics of the caches
+	caches := map[string]*cache.Cache{
+		"tokens":        tokenCache,
+		"credentials":   credentialCache,
+		"organizations": organizationCache,
+	}
+
+	registerEHR(server, config, sqlDB, tenancy, auth, authService, customerRepository, userRepository, vcRegistry, orgRegistry, trustService, certificateBinding, caches)
 
 	if config.FHIR.Proxy.Enable {
-		registerFHIRProxy(server, config, customerRepository, vcRegistry)
+		registerFHIRProxy(server, config, tenancy, auth, authService, customerRepository, vcRegistry, certificateBinding)
 	}
+	return server
+}
 
-	// Start server
-	server.Logger.Fatal(server.Start(fmt.Sprintf(":%d", config.HTTPPort)))
+// internalListener serves the UI, the private API and the FHIR proxy (for local apps) when a public listener is configured.
+func internalListener(config Config) listener.Listener {
+	return listener.Listener{
+		Name:    "internal",
+		Address: fmt.Sprintf(":%d", config.HTTPPort),
+		Allowed: listener.WithoutPrefix("/web/external/"),
+	}
+}
+
+// publicListener only serves the endpoints called by other care organizations, so it can be exposed in a DMZ.
+// The internal endpoints are reached by requests the FHIR proxy dispatches, not through the listener.
+func publicListener(config Config) listener.Listener {
+	prefixes := []string{"/web/external/"}
+	if config.FHIR.Proxy.Enable {
+		prefixes = append(prefixes, config.FHIR.Proxy.Path+"/")
+	}
+	allowed := listener.WithPrefix(prefixes...)
+	return listener.Listener{
+		Name:    "public",
+		Address: fmt.Sprintf(":%d", config.Public.Port),
+		// the status endpoint is used for health checks
+		Allowed: func(path string) bool {
+			return path == "/status" || allowed(path)
+		},
+	}
 }
 
 func createServer() *echo.Echo {
@@ -125,17 +270,8 @@ func createServer() *echo.Echo {
 	return server
 }
 
-func registerFHIRProxy(server *echo.Echo, config Config, customerRepository customers.Repository, vcRegistry registry.VerifiableCredentialRegistry) {
-	authService, err := httpAuth.NewService(config.NutsNodeAddress)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	fhirURL, err := url.Parse(config.FHIR.Server.Address)
-	if err != nil {
-		log.Fatal(err)
-	}
-	proxyServer := proxy.NewServer(authService, customerRepository, vcRegistry, *fhirURL, config.FHIR.Proxy.Path, config.FHIR.Server.SupportsMultiTenancy())
+func registerFHIRProxy(server *echo.Echo, config Config, tenancy fhir.TenantStrategy, auth *api.Auth, authService httpAuth.Service, customerRepository customers.Repository, vcRegistry registry.VerifiableCredentialRegistry, certificateBinding httpAuth.AccessFunc) {
+	proxyServer := proxy.NewServer(authService, customerRepository, vcRegistry, auth, tenancy, config.FHIR.Proxy.Path, certificateBinding)
 
 	// set security filter
 	server.Use(proxyServer.AuthMiddleware())
@@ -144,52 +280,40 @@ func registerFHIRProxy(server *echo.Echo, config Config, customerRepository cust
 		// For all non-Task updates, logic is performed by middleware
 
 		// For the Task update it does not proxy the call but forwards it to here.
-		// The request is altered to /web/internal/{customerID}/Task/{ID}
+		// The request is altered to /web/internal/customer/{customerID}/task/{ID} and marked as internal dispatch,
+		// which is the only way to reach the internal endpoints.
 		server.ServeHTTP(c.Response(), c.Request())
 		return nil
 	}, proxyServer.Handler)
 }
 
-func registerEHR(server *echo.Echo, config Config, customerRepository customers.Repository, vcRegistry registry.VerifiableCredentialRegistry) {
+func registerEHR(server *echo.Echo, config Config, sqlDB *sqlx.DB, tenancy fhir.TenantStrategy, auth *api.Auth, authService httpAuth.Service, customerRepository customers.Repository, userRepository users.Repository, vcRegistry registry.VerifiableCredentialRegistry, orgRegistry registry.OrganizationRegistry, trustService trust.Service, certificateBinding httpAuth.AccessFunc, caches map[string]*cache.Cache) {
 	// init node API nutsClient
 	nodeClient := nutsClient.HTTPClient{NutsNodeAddress: config.NutsNodeAddress}
 
-	var passwd string
-	if config.Credentials.Empty() {
-		passwd = generateAuthenticationPassword(config)
-		logrus.Infof("Authentication credentials not configured, so they were generated (password=%s)", passwd)
-	} else {
-		passwd = config.Credentials.Password
-	}
-
-	// Initialize services
-	sqlDB := sqlx.MustConnect("sqlite3", config.DBConnectionString)
-	sqlDB.SetMaxOpenConns(1)
-
-	authService, err := httpAuth.NewService(config.NutsNodeAddress)
+	// other care organizations are called with mutual TLS, if configured
+	remoteTLS, err := config.TLS.ClientConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
+	remoteFHIRClientFactory := fhir.NewFactory(fhir.WithTLS(remoteTLS))
+	notifier := transfer.FireAndForgetNotifier{TLSConfig: remoteTLS}
 
-	fhirClientFactory := fhir.NewFactory(fhir.WithURL(config.FHIR.Server.Address), fhir.WithMultiTenancyEnabled(config.FHIR.Server.SupportsMultiTenancy()))
+	// Initialize services
+	fhirClientFactory := fhir.NewFactory(fhir.WithURL(config.FHIR.Server.Address), fhir.WithTenantStrategy(tenancy))
 	patientRepository := patients.NewFHIRPatientRepository(patients.Factory{}, fhirClientFactory)
 	reportRepository := reports.NewFHIRRepository(fhirClientFactory)
-	orgRegistry := registry.NewOrganizationRegistry(&nodeClient)
 	dossierRepository := dossier.NewSQLiteDossierRepository(dossier.Factory{}, sqlDB)
 	transferSenderRepo := sender.NewTransferRepository(sqlDB)
 	transferReceiverRepo := receiver.NewTransferRepository(sqlDB)
-	transferSenderService := sender.NewTransferService(authService, fhirClientFactory, transferSenderRepo, customerRepository, dossierRepository, patientRepository, orgRegistry, vcRegistry)
-	transferReceiverService := receiver.NewTransferService(authService, fhirClientFactory, transferReceiverRepo, customerRepository, orgRegistry, vcRegistry)
-	tenantInitializer := func(tenant int) error {
-		if !config.FHIR.Server.SupportsMultiTenancy() {
-			return nil
-		}
-
-		return fhir.InitializeTenant(config.FHIR.Server.Address, strconv.Itoa(tenant))
-	}
+	favouriteRepository := organizations.NewSQLiteFavouriteRepository(sqlDB)
+	collaborationRepository := episode.NewSQLiteCollaborationRepository(sqlDB)
+	transferSenderService := sender.NewTransferService(authService, fhirClientFactory, transferSenderRepo, customerRepository, dossierRepository, patientRepository, orgRegistry, vcRegistry, notifier, config.Expiration.EOverdracht.Policy())
+	transferReceiverService := receiver.NewTransferService(authService, fhirClientFactory, transferReceiverRepo, customerRepository, orgRegistry, vcRegistry, remoteFHIRClientFactory, notifier)
+	tenantInitializer := tenancy.Initialize
 
 	if config.LoadTestPatients {
-		allCustomers, err := customerRepository.All()
+		allCustomers, err := listCustomers(customerRepository, sqlDB)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -200,8 +324,6 @@ func registerEHR(server *echo.Echo, config Config, customerRepository customers.
 			registerPatients(patientRepository, sqlDB, customer.Id)
 		}
 	}
-	auth := api.NewAuth(config.sessionKey, customerRepository, passwd)
-
 	// Initialize wrapper
 	apiWrapper := api.Wrapper{
 		APIAuth:                 auth,
@@ -215,17 +337,42 @@ func registerEHR(server *echo.Echo, config Config, customerRepository customers.
 		TransferSenderService:   transferSenderService,
 		TransferReceiverService: transferReceiverService,
 		TransferReceiverRepo:    transferReceiverRepo,
-		EpisodeService:          episode.NewService(fhirClientFactory, authService, orgRegistry, vcRegistry),
+		EpisodeService:          episode.NewService(fhirClientFactory, authService, orgRegistry, vcRegistry, collaborationRepository, cache.New(config.Cache.TTL, config.Cache.MaxEntries), remoteFHIRClientFactory, notifier, config.Expiration.Zorginzage.Policy()),
 		TenantInitializer:       tenantInitializer,
-		NotificationHandler:     notification.NewHandler(authService, fhirClientFactory, transferReceiverService, orgRegistry, vcRegistry),
+		UserRepository:          userRepository,
+		SigningMeans:            config.APISigningMeans(),
+		SignSessions:            api.NewSignSessionTracker(nodeClient, time.Second, 10*time.Minute, maxSignSessionsPerCustomer),
+		NotificationHandler:     notification.NewHandler(authService, fhirClientFactory, transferReceiverService, orgRegistry, vcRegistry, remoteFHIRClientFactory),
+		OrganizationSearch:      organizations.NewSearchService(orgRegistry, favouriteRepository, geo.NewPlaces()),
+		FavouriteRepository:     favouriteRepository,
+		Trust:                   trustService,
+		Credentials:             credentials.NewService(vcRegistry, orgRegistry, transferSenderRepo, collaborationRepository, fhirClientFactory),
+		Caches:                  caches,
+	}
+	// the services are only checked against the URLs of this EHR if its public URL is known
+	var endpoints onboarding.Endpoints
+	if config.Onboarding.PublicURL != "" {
+		endpoints = config.Onboarding.Endpoints(config.FHIR.Proxy.Path)
+	}
+	apiWrapper.Diagnostics = diagnostics.NewService(nodeClient, nodeClient, nodeClient, endpoints, remoteTLS)
+	if config.Onboarding.Enabled() {
+		// other care organizations access the customers' data through the FHIR proxy
+		if !config.FHIR.Proxy.Enable {
+			log.Fatal("onboarding requires the FHIR proxy to be enabled")
+		}
+		apiWrapper.Onboarding = onboarding.NewService(nodeClient, nodeClient, nodeClient, customerRepository, config.Onboarding.VendorDID, endpoints)
+		logrus.Infof("Onboarding of customers enabled (vendor=%s, url=%s)", config.Onboarding.VendorDID, config.Onboarding.PublicURL)
 	}
 
 	// JWT checking for correct claims
 	server.Use(auth.JWTHandler)
+	server.Use(api.RoleHandler)
+	// internal endpoints are only reachable through the FHIR proxy
+	server.Use(httpAuth.InternalOnly)
 	server.Use(sql.Transactional(sqlDB))
 
 	// for requests that require Nuts AccessToken
-	server.Use(authMiddleware(authService))
+	server.Use(authMiddleware(authService, certificateBinding))
 
 	api.RegisterHandlersWithBaseURL(server, apiWrapper, "/web")
 
@@ -291,9 +438,135 @@ func registerPatients(repository patients.Repository, db *sqlx.DB, customerID in
 	}
 }
 
-func generateAuthenticationPassword(config Config) string {
-	pkHashBytes := sha1.Sum(elliptic.Marshal(config.sessionKey.Curve, config.sessionKey.X, config.sessionKey.Y))
-	return hex.EncodeToString(pkHashBytes[:])
+// registerAdmins creates the admin user for all customers that don't have any users yet, with the configured password
+// or a generated one, which is printed in the log. It also creates the vendor account of the operator, if configured
+// and it doesn't exist yet. Existing users are never changed, later changes are made through the user admin API.
+func registerAdmins(repository users.Repository, db *sqlx.DB, customerRepository customers.Repository, credentials Credentials) {
+	// createUser creates the user, it returns the password that was generated if none was configured
+	createUser := func(ctx context.Context, customerID int, username, password string, roles []types.UserRole) (string, error) {
+		generated := ""
+		if password == "" {
+			generated = generateAuthenticationPassword()
+			password = generated
+		}
+		if _, err := repository.Create(ctx, customerID, username, password, roles); err != nil {
+			return "", fmt.Errorf("unable to register %s user: %w", username, err)
+		}
+		return generated, nil
+	}
+	logCreated := func(customerID int, username, generated string) {
+		if generated != "" {
+			logrus.Infof("Registered %s user with generated password (customer=%d, password=%s)", username, customerID, generated)
+		} else {
+			logrus.Infof("Registered %s user (customer=%d)", username, customerID)
+		}
+	}
+
+	if err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+		allCustomers, err := customerRepository.All(ctx)
+		if err != nil {
+			return err
+		}
+		for _, customer := range allCustomers {
+			existing, err := repository.All(ctx, customer.Id)
+			if err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				continue
+			}
+			// the admin only manages its own customer, the vendor role is kept for the operator
+			generated, err := createUser(ctx, customer.Id, "admin", credentials.Password, users.CustomerRoles)
+			if err != nil {
+				return err
+			}
+			logCreated(customer.Id, "admin", generated)
+		}
+
+		vendor := credentials.Vendor
+		if vendor.Customer == 0 {
+			logrus.Info("No vendor account configured (credentials.vendor.customer), customers and trust can't be managed")
+			return nil
+		}
+		if customer, err := customerRepository.FindByID(ctx, vendor.Customer); err != nil {
+			return err
+		} else if customer == nil {
+			return fmt.Errorf("customer of the vendor account doesn't exist (customer=%d)", vendor.Customer)
+		}
+		existing, err := repository.All(ctx, vendor.Customer)
+		if err != nil {
+			return err
+		}
+		for _, user := range existing {
+			if user.Username == vendor.Username {
+				return nil
+			}
+		}
+		generated, err := createUser(ctx, vendor.Customer, vendor.Username, vendor.Password, users.AllRoles)
+		if err != nil {
+			return err
+		}
+		logCreated(vendor.Customer, vendor.Username, generated)
+		return nil
+	}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// trustOrganizationIssuers makes the Nuts node trust the issuers of NutsOrganizationCredentials. The EHR starts when
+// that fails, e.g. because the Nuts node isn't available yet, since the issuers can also be trusted by a vendor.
+func trustOrganizationIssuers(service trust.Service, issuers []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, issuer := range issuers {
+		if err := service.Trust(ctx, credential.NutsOrganizationCredentialType, issuer); err != nil {
+			logrus.Errorf("Unable to trust issuer of organization credentials: %v", err)
+			continue
+		}
+		logrus.Infof("Trusted issuer of organization credentials (did=%s)", issuer)
+	}
+}
+
+// importCustomers imports the customers from the customers file, if it exists and there are no customers yet.
+func importCustomers(repository *customers.SQLiteCustomerRepository, db *sqlx.DB, file string) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return
+	}
+	records, err := customers.ReadJSONFile(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := sql.ExecuteTransactional(db, func(ctx context.Context) error {
+		count, err := repository.Import(ctx, records)
+		if err != nil {
+			return fmt.Errorf("unable to import customers: %w", err)
+		}
+		if count > 0 {
+			logrus.Infof("Imported customers from file (file=%s, count=%d)", file, count)
+		}
+		return nil
+	}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func listCustomers(repository customers.Repository, db *sqlx.DB) ([]types.Customer, error) {
+	var result []types.Customer
+	err := sql.ExecuteTransactional(db, func(ctx context.Context) (err error) {
+		result, err = repository.All(ctx)
+		return
+	})
+	return result, err
+}
+
+// generateAuthenticationPassword returns a random password. It isn't derived from any key material, since the public
+// keys of the EHR are published.
+func generateAuthenticationPassword() string {
+	passwordBytes := make([]byte, 16)
+	if _, err := rand.Read(passwordBytes); err != nil {
+		logrus.Fatal(err)
+	}
+	return hex.EncodeToString(passwordBytes)
 }
 
 // httpErrorHandler includes the err.Err() string in a { "error": "msg" } json hash
@@ -331,7 +604,7 @@ func httpErrorHandler(err error, c echo.Context) {
 	}
 }
 
-func authMiddleware(authService httpAuth.Service) echo.MiddlewareFunc {
+func authMiddleware(authService httpAuth.Service, certificateBinding httpAuth.AccessFunc) echo.MiddlewareFunc {
 	config := httpAuth.Config{
 		Skipper: func(e echo.Context) bool {
 			return !strings.HasPrefix(e.Request().RequestURI, "/web/external/")
@@ -341,12 +614,17 @@ func authMiddleware(authService httpAuth.Service) echo.MiddlewareFunc {
 			if service == nil {
 				return errors.New("access-token doesn't contain 'service' claim")
 			}
-			if *service != "eOverdracht-receiver" {
-				return fmt.Errorf("access-token contains incorrect 'service' claim: %s, must be eOverdracht-receiver", *service)
+			// episode notifications are sent by organizations holding an episode our customers collaborate in
+			expected := transfer.ReceiverServiceName
+			if strings.HasPrefix(request.RequestURI, "/web/external/episode/") {
+				expected = zorginzage.ServiceName
+			}
+			if *service != expected {
+				return fmt.Errorf("access-token contains incorrect 'service' claim: %s, must be %s", *service, expected)
 			}
 
 			return nil
 		},
 	}
-	return httpAuth.SecurityFilter{Auth: authService}.AuthWithConfig(config)
+	return httpAuth.SecurityFilter{Auth: authService, CertificateBinding: certificateBinding}.AuthWithConfig(config)
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/customers"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/users"
+	"github.com/nuts-foundation/nuts-demo-ehr/sql"
+)
+
+func TestRegisterAdmins(t *testing.T) {
+	setup := func(t *testing.T) (*sqlx.DB, *users.SQLiteUserRepository, *customers.SQLiteCustomerRepository) {
+		db := sqlx.MustConnect("sqlite3", ":memory:")
+		// every connection to :memory: has its own database
+		db.SetMaxOpenConns(1)
+		userRepository := users.NewSQLiteUserRepository(db)
+		customerRepository := customers.NewSQLiteCustomerRepository(db)
+		require.NoError(t, sql.ExecuteTransactional(db, func(ctx context.Context) error {
+			_, err := customerRepository.Import(ctx, []types.Customer{{Id: 1, Name: "Vendor"}, {Id: 2, Name: "Customer"}})
+			return err
+		}))
+		return db, userRepository, customerRepository
+	}
+	credentials := Credentials{Password: "secret", Vendor: VendorAccount{Customer: 1, Username: "vendor", Password: "operator"}}
+	authenticate := func(t *testing.T, db *sqlx.DB, repository users.Repository, customerID int, username, password string) *types.User {
+		var user *types.User
+		require.NoError(t, sql.ExecuteTransactional(db, func(ctx context.Context) (err error) {
+			user, err = repository.Authenticate(ctx, customerID, username, password)
+			return err
+		}))
+		return user
+	}
+
+	t.Run("admins only get the customer roles", func(t *testing.T) {
+		db, userRepository, customerRepository := setup(t)
+
+		registerAdmins(userRepository, db, customerRepository, credentials)
+
+		for _, customerID := range []int{1, 2} {
+			admin := authenticate(t, db, userRepository, customerID, "admin", "secret")
+			require.NotNil(t, admin)
+			assert.Equal(t, users.CustomerRoles, admin.Roles)
+		}
+	})
+	t.Run("only the configured vendor account gets the vendor role", func(t *testing.T) {
+		db, userRepository, customerRepository := setup(t)
+
+		registerAdmins(userRepository, db, customerRepository, credentials)
+
+		vendor := authenticate(t, db, userRepository, 1, "vendor", "operator")
+		require.NotNil(t, vendor)
+		assert.Equal(t, users.AllRoles, vendor.Roles)
+		assert.Nil(t, authenticate(t, db, userRepository, 2, "vendor", "operator"))
+	})
+	t.Run("existing users aren't changed", func(t *testing.T) {
+		db, userRepository, customerRepository := setup(t)
+		registerAdmins(userRepository, db, customerRepository, credentials)
+
+		changed := credentials
+		changed.Password = "changed"
+		changed.Vendor.Password = "changed"
+		registerAdmins(userRepository, db, customerRepository, changed)
+
+		assert.NotNil(t, authenticate(t, db, userRepository, 2, "admin", "secret"))
+		assert.NotNil(t, authenticate(t, db, userRepository, 1, "vendor", "operator"))
+	})
+	t.Run("passwords are generated if not configured", func(t *testing.T) {
+		db, userRepository, customerRepository := setup(t)
+
+		registerAdmins(userRepository, db, customerRepository, Credentials{Vendor: VendorAccount{Customer: 1, Username: "vendor"}})
+
+		assert.Nil(t, authenticate(t, db, userRepository, 2, "admin", ""))
+		assert.Nil(t, authenticate(t, db, userRepository, 1, "vendor", ""))
+	})
+	t.Run("no vendor account", func(t *testing.T) {
+		db, userRepository, customerRepository := setup(t)
+
+		registerAdmins(userRepository, db, customerRepository, Credentials{Password: "secret", Vendor: VendorAccount{Username: "vendor"}})
+
+		var all []types.User
+		require.NoError(t, sql.ExecuteTransactional(db, func(ctx context.Context) (err error) {
+			all, err = userRepository.All(ctx, 1)
+			return err
+		}))
+		require.Len(t, all, 1)
+		assert.Equal(t, "admin", all[0].Username)
+	})
+}
@@ -11,15 +11,15 @@ import (
 )
 
 type Auth interface {
-	CreateIrmaSession(customer types.Customer) ([]byte, error)
-	GetIrmaSessionResult(sessionToken string) (*nutsAuthClient.SignSessionStatusResponse, error)
-
-	CreateDummySession(customer types.Customer) ([]byte, error)
-	GetDummySessionResult(sessionToken string) (*nutsAuthClient.SignSessionStatusResponse, error)
+	// CreateSignSession starts a session at the Nuts node in which the user signs the login contract of the customer,
+	// using the given means (e.g. irma, dummy, uzi or employeeid). The params are passed to the means.
+	CreateSignSession(ctx context.Context, customer types.Customer, means string, params map[string]interface{}) ([]byte, error)
+	// GetSignSessionResult returns the status of the signing session, which contains the signed contract when completed.
+	GetSignSessionResult(ctx context.Context, sessionToken string) (*nutsAuthClient.SignSessionStatusResponse, error)
 }
 
-func (c HTTPClient) getSessionResult(sessionToken string) (*nutsAuthClient.SignSessionStatusResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (c HTTPClient) GetSignSessionResult(ctx context.Context, sessionToken string) (*nutsAuthClient.SignSessionStatusResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	resp, err := c.auth().GetSignSessionStatus(ctx, sessionToken)
@@ -41,24 +41,8 @@ func (c HTTPClient) getSessionResult(sessionToken string) (*nutsAuthClient.SignS
 	return sessionResponse, nil
 }
 
-func (c HTTPClient) CreateIrmaSession(customer types.Customer) ([]byte, error) {
-	return c.createSession(customer, nutsAuthClient.SignSessionRequestMeansIrma)
-}
-
-func (c HTTPClient) GetIrmaSessionResult(sessionToken string) (*nutsAuthClient.SignSessionStatusResponse, error) {
-	return c.getSessionResult(sessionToken)
-}
-
-func (c HTTPClient) CreateDummySession(customer types.Customer) ([]byte, error) {
-	return c.createSession(customer, nutsAuthClient.SignSessionRequestMeansDummy)
-}
-
-func (c HTTPClient) GetDummySessionResult(sessionToken string) (*nutsAuthClient.SignSessionStatusResponse, error) {
-	return c.getSessionResult(sessionToken)
-}
-
-func (c HTTPClient) createSession(customer types.Customer, means nutsAuthClient.SignSessionRequestMeans) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (c HTTPClient) CreateSignSession(ctx context.Context, customer types.Customer, means string, params map[string]interface{}) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	le := nutsAuthClient.LegalEntity(*customer.Did)
@@ -85,7 +69,8 @@ func (c HTTPClient) createSession(customer types.Customer, means nutsAuthClient.
 	}
 
 	body := nutsAuthClient.CreateSignSessionJSONRequestBody{
-		Means:   means,
+		Means:   nutsAuthClient.SignSessionRequestMeans(means),
+		Params:  params,
 		Payload: contract.Message,
 	}
 
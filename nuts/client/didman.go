@@ -13,6 +13,9 @@ const authorizationConcept = "authorization"
 type DIDManClient interface {
 	SearchOrganizations(ctx context.Context, query string, didServiceType *string) ([]nutsDIDManClient.OrganizationSearchResult, error)
 	GetCompoundServiceEndpoint(ctx context.Context, organizationDID, serviceType string, field string) (string, error)
+	GetCompoundServices(ctx context.Context, did string) ([]nutsDIDManClient.CompoundService, error)
+	// AddCompoundService adds a compound service to the DID document, its endpoints are URLs or references to other services.
+	AddCompoundService(ctx context.Context, did, serviceType string, endpoints map[string]interface{}) error
 }
 
 func (c HTTPClient) SearchOrganizations(ctx context.Context, query string, didServiceType *string) ([]nutsDIDManClient.OrganizationSearchResult, error) {
@@ -51,6 +54,33 @@ func (c HTTPClient) GetCompoundServiceEndpoint(ctx context.Context, organization
 	return parsedResponse.JSON200.Endpoint, nil
 }
 
+func (c HTTPClient) GetCompoundServices(ctx context.Context, did string) ([]nutsDIDManClient.CompoundService, error) {
+	response, err := c.didman().GetCompoundServices(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	err = testResponseCode(http.StatusOK, response)
+	if err != nil {
+		return nil, err
+	}
+	parsedResponse, err := nutsDIDManClient.ParseGetCompoundServicesResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	return *parsedResponse.JSON200, nil
+}
+
+func (c HTTPClient) AddCompoundService(ctx context.Context, did, serviceType string, endpoints map[string]interface{}) error {
+	response, err := c.didman().AddCompoundService(ctx, did, nutsDIDManClient.AddCompoundServiceJSONRequestBody{
+		Type:            serviceType,
+		ServiceEndpoint: endpoints,
+	})
+	if err != nil {
+		return err
+	}
+	return testResponseCode(http.StatusOK, response)
+}
+
 func (c HTTPClient) didman() nutsDIDManClient.ClientInterface {
 	response, err := nutsDIDManClient.NewClientWithResponses(c.getNodeURL())
 	if err != nil {
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -10,22 +11,48 @@ import (
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vcr"
 )
 
+// ErrCredentialRevoked is returned when revoking or resolving a credential that is already revoked.
+var ErrCredentialRevoked = errors.New("credential is already revoked")
+
+// ErrCredentialNotFound is returned when resolving a credential the Nuts node doesn't know.
+var ErrCredentialNotFound = errors.New("credential not found")
+
 type VCRClient interface {
 	GetOrganization(ctx context.Context, organizationDID string) ([]map[string]interface{}, error)
-	CreateVC(ctx context.Context, typeName, issuer string, credentialSubject map[string]interface{}, expirationDate *time.Time) error
-	FindAuthorizationCredentials(ctx context.Context, params map[string]string) ([]vcr.VerifiableCredential, error)
+	// GetUntrustedOrganization is like GetOrganization, but also returns organizations of credentials of untrusted issuers.
+	GetUntrustedOrganization(ctx context.Context, organizationDID string) ([]map[string]interface{}, error)
+	// CreateVC issues a credential and returns its ID.
+	CreateVC(ctx context.Context, typeName, issuer string, credentialSubject map[string]interface{}, expirationDate *time.Time) (string, error)
+	// FindAuthorizationCredentials searches NutsAuthorizationCredentials. If untrusted is false, only credentials of
+	// trusted issuers are returned.
+	FindAuthorizationCredentials(ctx context.Context, params map[string]string, untrusted bool) ([]vcr.VerifiableCredential, error)
 	FindAuthorizationCredentialIDs(ctx context.Context, params map[string]string) ([]string, error)
+	// RevokeCredential revokes the credential. It returns ErrCredentialRevoked if it's already revoked.
 	RevokeCredential(ctx context.Context, credentialID string) error
+	// ResolveVerifiableCredential returns the credential, it returns ErrCredentialNotFound if the Nuts node doesn't know it
+	// and ErrCredentialRevoked if it's revoked.
 	ResolveVerifiableCredential(ctx context.Context, credentialID string, untrusted bool) (*vcr.VerifiableCredential, error)
+	// ListTrustedIssuers returns the DIDs of the issuers of the credential type the Nuts node trusts.
+	ListTrustedIssuers(ctx context.Context, credentialType string) ([]string, error)
+	// ListUntrustedIssuers returns the DIDs of the issuers of received credentials of the type the Nuts node doesn't trust.
+	ListUntrustedIssuers(ctx context.Context, credentialType string) ([]string, error)
+	TrustIssuer(ctx context.Context, credentialType, issuer string) error
+	UntrustIssuer(ctx context.Context, credentialType, issuer string) error
 }
 
 func (c HTTPClient) GetOrganization(ctx context.Context, organizationDID string) ([]map[string]interface{}, error) {
-	return c.searchVCR(ctx, organizationConcept, []vcr.KeyValuePair{
+	return c.searchVCR(ctx, organizationConcept, false, []vcr.KeyValuePair{
 		{Key: "subject", Value: organizationDID},
 	})
 }
 
-func (c HTTPClient) CreateVC(ctx context.Context, typeName, issuer string, credentialSubject map[string]interface{}, expirationDate *time.Time) error {
+func (c HTTPClient) GetUntrustedOrganization(ctx context.Context, organizationDID string) ([]map[string]interface{}, error) {
+	return c.searchVCR(ctx, organizationConcept, true, []vcr.KeyValuePair{
+		{Key: "subject", Value: organizationDID},
+	})
+}
+
+func (c HTTPClient) CreateVC(ctx context.Context, typeName, issuer string, credentialSubject map[string]interface{}, expirationDate *time.Time) (string, error) {
 	var exp *string
 
 	if expirationDate != nil {
@@ -40,20 +67,25 @@ func (c HTTPClient) CreateVC(ctx context.Context, typeName, issuer string, crede
 		ExpirationDate:    exp,
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	_, err = testAndReadResponse(http.StatusOK, response)
+	data, err := testAndReadResponse(http.StatusOK, response)
 	if err != nil {
-		return err
+		return "", err
+	}
+	result := vcr.VerifiableCredential{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", err
+	}
+	if result.Id == nil {
+		return "", errors.New("issued credential has no ID")
 	}
 
-	return nil
+	return *result.Id, nil
 }
 
-var trueVal = true
-
-func (c HTTPClient) FindAuthorizationCredentials(ctx context.Context, params map[string]string) ([]vcr.VerifiableCredential, error) {
+func (c HTTPClient) FindAuthorizationCredentials(ctx context.Context, params map[string]string, untrusted bool) ([]vcr.VerifiableCredential, error) {
 	var vcParams = make([]vcr.KeyValuePair, 0)
 	for k, v := range params {
 		vcParams = append(vcParams, vcr.KeyValuePair{
@@ -62,7 +94,7 @@ func (c HTTPClient) FindAuthorizationCredentials(ctx context.Context, params map
 		})
 	}
 
-	response, err := c.vcr().Search(ctx, authorizationConcept, &vcr.SearchParams{Untrusted: &trueVal}, vcr.SearchJSONRequestBody{Params: vcParams})
+	response, err := c.vcr().Search(ctx, authorizationConcept, &vcr.SearchParams{Untrusted: &untrusted}, vcr.SearchJSONRequestBody{Params: vcParams})
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +112,7 @@ func (c HTTPClient) FindAuthorizationCredentials(ctx context.Context, params map
 }
 
 func (c HTTPClient) FindAuthorizationCredentialIDs(ctx context.Context, params map[string]string) ([]string, error) {
-	credentials, err := c.FindAuthorizationCredentials(ctx, params)
+	credentials, err := c.FindAuthorizationCredentials(ctx, params, true)
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +133,9 @@ func (c HTTPClient) RevokeCredential(ctx context.Context, credentialID string) e
 	if err != nil {
 		return err
 	}
+	if response.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: %s", ErrCredentialRevoked, credentialID)
+	}
 	_, err = testAndReadResponse(http.StatusOK, response)
 	return err
 }
@@ -110,19 +145,72 @@ func (c HTTPClient) ResolveVerifiableCredential(ctx context.Context, credentialI
 	if err != nil {
 		return nil, err
 	}
+	if response.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrCredentialNotFound, credentialID)
+	}
 	data, err := testAndReadResponse(http.StatusOK, response)
+	if err != nil {
+		return nil, err
+	}
 	result := vcr.ResolutionResult{}
 	if err := json.Unmarshal(data, &result); err != nil {
 		return nil, err
 	}
+	if result.CurrentStatus == vcr.ResolutionResultCurrentStatusRevoked {
+		return nil, fmt.Errorf("%w: %s", ErrCredentialRevoked, credentialID)
+	}
 	if !untrusted && result.CurrentStatus != "trusted" {
 		return nil, fmt.Errorf("credential with ID %s is not trusted (but %s)", credentialID, result.CurrentStatus)
 	}
 	return &result.VerifiableCredential, nil
 }
 
-func (c HTTPClient) searchVCR(ctx context.Context, concept string, params []vcr.KeyValuePair) ([]map[string]interface{}, error) {
-	response, err := c.vcr().Search(ctx, concept, &vcr.SearchParams{}, vcr.SearchJSONRequestBody{Params: params})
+func (c HTTPClient) ListTrustedIssuers(ctx context.Context, credentialType string) ([]string, error) {
+	response, err := c.vcr().ListTrusted(ctx, credentialType)
+	if err != nil {
+		return nil, err
+	}
+	return readIssuers(response)
+}
+
+func (c HTTPClient) ListUntrustedIssuers(ctx context.Context, credentialType string) ([]string, error) {
+	response, err := c.vcr().ListUntrusted(ctx, credentialType)
+	if err != nil {
+		return nil, err
+	}
+	return readIssuers(response)
+}
+
+func (c HTTPClient) TrustIssuer(ctx context.Context, credentialType, issuer string) error {
+	response, err := c.vcr().TrustIssuer(ctx, vcr.TrustIssuerJSONRequestBody{CredentialType: credentialType, Issuer: issuer})
+	if err != nil {
+		return err
+	}
+	return testResponseCode(http.StatusNoContent, response)
+}
+
+func (c HTTPClient) UntrustIssuer(ctx context.Context, credentialType, issuer string) error {
+	response, err := c.vcr().UntrustIssuer(ctx, vcr.UntrustIssuerJSONRequestBody{CredentialType: credentialType, Issuer: issuer})
+	if err != nil {
+		return err
+	}
+	return testResponseCode(http.StatusNoContent, response)
+}
+
+func readIssuers(response *http.Response) ([]string, error) {
+	data, err := testAndReadResponse(http.StatusOK, response)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c HTTPClient) searchVCR(ctx context.Context, concept string, untrusted bool, params []vcr.KeyValuePair) ([]map[string]interface{}, error) {
+	response, err := c.vcr().Search(ctx, concept, &vcr.SearchParams{Untrusted: &untrusted}, vcr.SearchJSONRequestBody{Params: params})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vdr"
+)
+
+type VDRClient interface {
+	// CreateDID creates a new DID document on the Nuts node and returns it.
+	CreateDID(ctx context.Context, request vdr.DIDCreateRequest) (*vdr.DIDDocument, error)
+	// ResolveDID returns the current version of the DID document and its metadata.
+	ResolveDID(ctx context.Context, did string) (*vdr.DIDResolutionResult, error)
+}
+
+func (c HTTPClient) CreateDID(ctx context.Context, request vdr.DIDCreateRequest) (*vdr.DIDDocument, error) {
+	response, err := c.vdr().CreateDID(ctx, vdr.CreateDIDJSONRequestBody(request))
+	if err != nil {
+		return nil, err
+	}
+	data, err := testAndReadResponse(http.StatusOK, response)
+	if err != nil {
+		return nil, err
+	}
+	document := vdr.DIDDocument{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (c HTTPClient) ResolveDID(ctx context.Context, did string) (*vdr.DIDResolutionResult, error) {
+	response, err := c.vdr().GetDID(ctx, did, &vdr.GetDIDParams{})
+	if err != nil {
+		return nil, err
+	}
+	data, err := testAndReadResponse(http.StatusOK, response)
+	if err != nil {
+		return nil, err
+	}
+	result := vdr.DIDResolutionResult{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c HTTPClient) vdr() vdr.ClientInterface {
+	response, err := vdr.NewClientWithResponses(c.getNodeURL())
+	if err != nil {
+		panic(err)
+	}
+	return response
+}
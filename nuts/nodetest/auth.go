@@ -0,0 +1,113 @@
+package nodetest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	nutsAuthClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client/auth"
+)
+
+// AccessTokenValidity is the time access tokens are valid.
+const AccessTokenValidity = time.Minute
+
+// IdentityEmail is the e-mail address of the user in tokens requested with a user identity, since the identity isn't verified.
+const IdentityEmail = "user@example.com"
+
+type accessToken struct {
+	requester     string
+	authorizer    string
+	service       string
+	credentialIDs []string
+	hasIdentity   bool
+	issuedAt      time.Time
+}
+
+func (n *Node) registerAuth() {
+	n.router.POST("/internal/auth/v1/request-access-token", n.requestAccessTokenHandler)
+	n.router.POST("/internal/auth/v1/accesstoken/introspect", n.introspectAccessTokenHandler)
+}
+
+// requestAccessTokenHandler issues an access token if the authorizer has a service of the requested type, and the
+// credentials are valid and issued by the authorizer to the requester.
+func (n *Node) requestAccessTokenHandler(ctx echo.Context) error {
+	request := nutsAuthClient.RequestAccessTokenRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	if _, ok := n.documents[request.Requester]; !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "unknown requester: "+request.Requester)
+	}
+	authorizer, ok := n.documents[request.Authorizer]
+	if !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "unknown authorizer: "+request.Authorizer)
+	}
+	if _, ok := authorizer.service(request.Service); !ok {
+		return errorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("authorizer doesn't have a %s service", request.Service))
+	}
+
+	token := accessToken{
+		requester:   request.Requester,
+		authorizer:  request.Authorizer,
+		service:     request.Service,
+		hasIdentity: request.Identity != nil,
+		issuedAt:    time.Now(),
+	}
+	for _, presented := range request.Credentials {
+		if presented.ID == nil {
+			return errorResponse(ctx, http.StatusBadRequest, "credential without ID")
+		}
+		stored := n.credential(presented.ID.String())
+		if stored == nil || !stored.valid(token.issuedAt) {
+			return errorResponse(ctx, http.StatusBadRequest, "invalid credential: "+presented.ID.String())
+		}
+		if string(stored.credential.Issuer) != request.Authorizer || stored.subject() != request.Requester {
+			return errorResponse(ctx, http.StatusBadRequest, "credential isn't issued by the authorizer to the requester: "+stored.id())
+		}
+		token.credentialIDs = append(token.credentialIDs, stored.id())
+	}
+
+	value := uuid.NewString()
+	n.tokens[value] = token
+	return ctx.JSON(http.StatusOK, nutsAuthClient.AccessTokenResponse{
+		AccessToken: value,
+		ExpiresIn:   int(AccessTokenValidity.Seconds()),
+		TokenType:   "bearer",
+	})
+}
+
+func (n *Node) introspectAccessTokenHandler(ctx echo.Context) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	token, ok := n.tokens[ctx.FormValue("token")]
+	expiry := token.issuedAt.Add(AccessTokenValidity)
+	if !ok || !time.Now().Before(expiry) {
+		return ctx.JSON(http.StatusOK, nutsAuthClient.TokenIntrospectionResponse{Active: false})
+	}
+
+	exp, iat := int(expiry.Unix()), int(token.issuedAt.Unix())
+	response := nutsAuthClient.TokenIntrospectionResponse{
+		Active:  true,
+		Iss:     &token.authorizer,
+		Sub:     &token.requester,
+		Service: &token.service,
+		Exp:     &exp,
+		Iat:     &iat,
+	}
+	if len(token.credentialIDs) > 0 {
+		response.Vcs = &token.credentialIDs
+	}
+	if token.hasIdentity {
+		email := IdentityEmail
+		response.Email = &email
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,131 @@
+package nodetest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/didman"
+)
+
+// referenceSeparator separates the DID from the query of a reference to a service of another DID document,
+// e.g. did:nuts:123/serviceEndpoint?type=oauth
+const referenceSeparator = "/serviceEndpoint?type="
+
+func (n *Node) registerDIDMan() {
+	n.router.GET("/internal/didman/v1/search/organizations", n.searchOrganizationsHandler)
+	n.router.GET("/internal/didman/v1/did/:did/compoundservice", n.getCompoundServicesHandler)
+	n.router.POST("/internal/didman/v1/did/:did/compoundservice", n.addCompoundServiceHandler)
+	n.router.GET("/internal/didman/v1/did/:did/compoundservice/:type/endpoint/:field", n.getCompoundServiceEndpointHandler)
+}
+
+// searchOrganizationsHandler returns the organizations with a trusted organization credential of which the name contains the query.
+// If a service type is given, only the organizations with a service of that type are returned.
+func (n *Node) searchOrganizationsHandler(ctx echo.Context) error {
+	query := strings.ToLower(ctx.QueryParam("query"))
+	serviceType := ctx.QueryParam("didServiceType")
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	results := []map[string]interface{}{}
+	now := time.Now()
+	for _, stored := range n.credentials {
+		if stored.typ() != concepts["organization"] || !stored.valid(now) || !n.isTrusted(*stored) {
+			continue
+		}
+		organization, _ := stored.credential.CredentialSubject["organization"].(map[string]interface{})
+		name, _ := organization["name"].(string)
+		if !strings.Contains(strings.ToLower(name), query) {
+			continue
+		}
+		doc, ok := n.documents[stored.subject()]
+		if !ok {
+			continue
+		}
+		if _, ok := doc.service(serviceType); serviceType != "" && !ok {
+			continue
+		}
+		results = append(results, map[string]interface{}{
+			"didDocument":  map[string]interface{}{"id": doc.id},
+			"organization": organization,
+		})
+	}
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (n *Node) getCompoundServicesHandler(ctx echo.Context) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	doc, ok := n.documents[pathParam(ctx, "did")]
+	if !ok {
+		return errorResponse(ctx, http.StatusNotFound, "unable to find the DID document")
+	}
+	results := []didman.CompoundService{}
+	for _, s := range doc.services {
+		if endpoints, ok := s.endpoint.(map[string]interface{}); ok {
+			results = append(results, didman.CompoundService{Id: s.id, Type: s.typ, ServiceEndpoint: endpoints})
+		}
+	}
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (n *Node) addCompoundServiceHandler(ctx echo.Context) error {
+	request := didman.CompoundServiceProperties{}
+	if err := ctx.Bind(&request); err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	doc, ok := n.documents[pathParam(ctx, "did")]
+	if !ok {
+		return errorResponse(ctx, http.StatusNotFound, "unable to find the DID document")
+	}
+	if _, exists := doc.service(request.Type); exists {
+		return errorResponse(ctx, http.StatusConflict, "a service with this type already exists")
+	}
+	s := doc.addService(request.Type, request.ServiceEndpoint)
+	return ctx.JSON(http.StatusOK, didman.CompoundService{Id: s.id, Type: s.typ, ServiceEndpoint: request.ServiceEndpoint})
+}
+
+// getCompoundServiceEndpointHandler returns the endpoint of the compound service. If resolve is true, a reference to
+// a service of another DID document is resolved to the URL of that service.
+func (n *Node) getCompoundServiceEndpointHandler(ctx echo.Context) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	doc, ok := n.documents[pathParam(ctx, "did")]
+	if !ok {
+		return errorResponse(ctx, http.StatusNotFound, "unable to find the DID document")
+	}
+	s, ok := doc.service(pathParam(ctx, "type"))
+	if !ok {
+		return errorResponse(ctx, http.StatusNotFound, "service not found in DID Document")
+	}
+	endpoints, _ := s.endpoint.(map[string]interface{})
+	endpoint, ok := endpoints[pathParam(ctx, "field")].(string)
+	if !ok {
+		return errorResponse(ctx, http.StatusNotFound, "endpoint not found in compound service")
+	}
+
+	if ctx.QueryParam("resolve") == "true" && strings.Contains(endpoint, referenceSeparator) {
+		parts := strings.SplitN(endpoint, referenceSeparator, 2)
+		referenced, ok := n.documents[parts[0]]
+		if !ok {
+			return errorResponse(ctx, http.StatusNotFound, "unable to find the referenced DID document")
+		}
+		s, ok := referenced.service(parts[1])
+		if !ok {
+			return errorResponse(ctx, http.StatusNotFound, "referenced service not found in DID Document")
+		}
+		if endpoint, ok = s.endpoint.(string); !ok {
+			return errorResponse(ctx, http.StatusBadRequest, "referenced service isn't a URL")
+		}
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"endpoint": endpoint})
+}
@@ -0,0 +1,135 @@
+// Package nodetest provides a fake Nuts node for tests, so the EHR can be tested end-to-end without a running nuts-node.
+package nodetest
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Node is a fake Nuts node. It implements the subset of the auth, vcr, didman and vdr APIs the EHR calls, keeping the
+// DIDs, credentials and access tokens in memory. Nothing is signed nor verified cryptographically: access tokens are
+// random strings, credentials have an empty proof and signing sessions (e.g. IRMA) aren't supported.
+// The Node is an http.Handler, so it's usually served with httptest.NewServer.
+type Node struct {
+	mux    sync.Mutex
+	router *echo.Echo
+	// documents are the DID documents by DID
+	documents map[string]*document
+	// credentials are all issued credentials, in order of issuance
+	credentials []*storedCredential
+	// trusted contains the trusted issuers by credential type
+	trusted map[string]map[string]bool
+	tokens  map[string]accessToken
+}
+
+type document struct {
+	id          string
+	controllers []string
+	services    []service
+}
+
+// service is a service of a DID document. The endpoint of a compound service is a map of endpoints by name,
+// otherwise it's a URL.
+type service struct {
+	id       string
+	typ      string
+	endpoint interface{}
+}
+
+// NewNode returns a Node without DIDs, credentials and trusted issuers.
+func NewNode() *Node {
+	node := &Node{
+		router:    echo.New(),
+		documents: map[string]*document{},
+		trusted:   map[string]map[string]bool{},
+		tokens:    map[string]accessToken{},
+	}
+	node.router.HideBanner = true
+	node.registerAuth()
+	node.registerVCR()
+	node.registerDIDMan()
+	node.registerVDR()
+	return node
+}
+
+func (n *Node) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	n.router.ServeHTTP(writer, request)
+}
+
+// CreateDID registers a new DID document controlled by the given DIDs and returns its DID.
+func (n *Node) CreateDID(controllers ...string) string {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	return n.createDID(controllers)
+}
+
+// AddService adds a service to the DID document. The endpoint is a URL, or a map of endpoints by name for a compound service.
+func (n *Node) AddService(did, serviceType string, endpoint interface{}) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	doc, ok := n.documents[did]
+	if !ok {
+		panic("unknown DID: " + did)
+	}
+	doc.addService(serviceType, endpoint)
+}
+
+// Trust trusts the issuer for credentials of the given type, e.g. NutsOrganizationCredential.
+func (n *Node) Trust(credentialType, issuer string) {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	n.setTrust(credentialType, issuer, true)
+}
+
+func (n *Node) setTrust(credentialType, issuer string, trusted bool) {
+	if n.trusted[credentialType] == nil {
+		n.trusted[credentialType] = map[string]bool{}
+	}
+	if trusted {
+		n.trusted[credentialType][issuer] = true
+	} else {
+		delete(n.trusted[credentialType], issuer)
+	}
+}
+
+func (n *Node) createDID(controllers []string) string {
+	did := "did:nuts:" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	n.documents[did] = &document{id: did, controllers: controllers}
+	return did
+}
+
+func (d *document) addService(serviceType string, endpoint interface{}) service {
+	s := service{id: d.id + "#" + uuid.NewString(), typ: serviceType, endpoint: endpoint}
+	d.services = append(d.services, s)
+	return s
+}
+
+func (d *document) service(serviceType string) (service, bool) {
+	for _, s := range d.services {
+		if s.typ == serviceType {
+			return s, true
+		}
+	}
+	return service{}, false
+}
+
+// pathParam returns the unescaped path parameter, since DIDs and credential IDs are escaped by the clients.
+func pathParam(ctx echo.Context, name string) string {
+	value, err := url.PathUnescape(ctx.Param(name))
+	if err != nil {
+		return ctx.Param(name)
+	}
+	return value
+}
+
+func errorResponse(ctx echo.Context, status int, message string) error {
+	return ctx.JSON(status, map[string]interface{}{"title": message, "status": status})
+}
@@ -0,0 +1,273 @@
+package nodetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/tidwall/gjson"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vcr"
+)
+
+// concepts maps the concepts that can be searched to the type of their credentials
+var concepts = map[string]string{
+	"organization":  credential.NutsOrganizationCredentialType,
+	"authorization": credential.NutsAuthorizationCredentialType,
+}
+
+type storedCredential struct {
+	credential vcr.VerifiableCredential
+	revoked    bool
+}
+
+func (c storedCredential) id() string {
+	return *c.credential.Id
+}
+
+func (c storedCredential) typ() string {
+	return c.credential.Type[len(c.credential.Type)-1]
+}
+
+func (c storedCredential) subject() string {
+	subject, _ := c.credential.CredentialSubject["id"].(string)
+	return subject
+}
+
+// valid returns false if the credential is revoked or expired.
+func (c storedCredential) valid(now time.Time) bool {
+	if c.revoked {
+		return false
+	}
+	if c.credential.ExpirationDate != nil {
+		expiration, err := time.Parse(time.RFC3339, *c.credential.ExpirationDate)
+		if err != nil || !now.Before(expiration) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches checks the search params against the credential. Concept specific keys (e.g. organization.name) refer to
+// the credential subject and subject refers to its ID, other keys are paths in the credential (e.g. credentialSubject.resources.#.path).
+// Values must match exactly. If a path refers to multiple values, one of them must match.
+func (c storedCredential) matches(concept string, params []vcr.KeyValuePair) bool {
+	data, _ := json.Marshal(c.credential)
+	for _, param := range params {
+		path := param.Key
+		if path == "subject" {
+			path = "credentialSubject.id"
+		} else if strings.HasPrefix(path, concept+".") {
+			path = "credentialSubject." + strings.TrimPrefix(path, concept+".")
+		}
+
+		value := gjson.GetBytes(data, path)
+		found := false
+		if value.IsArray() {
+			for _, element := range value.Array() {
+				found = found || element.String() == param.Value
+			}
+		} else {
+			found = value.Exists() && value.String() == param.Value
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *Node) registerVCR() {
+	n.router.POST("/internal/vcr/v1/vc", n.createCredentialHandler)
+	n.router.GET("/internal/vcr/v1/vc/:id", n.resolveCredentialHandler)
+	n.router.DELETE("/internal/vcr/v1/vc/:id", n.revokeCredentialHandler)
+	n.router.POST("/internal/vcr/v1/:concept", n.searchHandler)
+	n.router.POST("/internal/vcr/v1/trust", n.trustHandler(true))
+	n.router.DELETE("/internal/vcr/v1/trust", n.trustHandler(false))
+	n.router.GET("/internal/vcr/v1/:type/trusted", n.listTrustedHandler)
+	n.router.GET("/internal/vcr/v1/:type/untrusted", n.listUntrustedHandler)
+}
+
+func (n *Node) createCredentialHandler(ctx echo.Context) error {
+	request := vcr.IssueVCRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	if _, ok := n.documents[request.Issuer]; !ok {
+		return errorResponse(ctx, http.StatusBadRequest, "unknown issuer: "+request.Issuer)
+	}
+	if request.Type == "" {
+		return errorResponse(ctx, http.StatusBadRequest, "missing credential type")
+	}
+	id := request.Issuer + "#" + uuid.NewString()
+	stored := &storedCredential{credential: vcr.VerifiableCredential{
+		Context:           []string{"https://www.w3.org/2018/credentials/v1", "https://nuts.nl/credentials/v1"},
+		Id:                &id,
+		Type:              []string{"VerifiableCredential", request.Type},
+		Issuer:            vcr.DID(request.Issuer),
+		IssuanceDate:      time.Now().UTC().Format(time.RFC3339Nano),
+		ExpirationDate:    request.ExpirationDate,
+		CredentialSubject: request.CredentialSubject,
+		Proof:             map[string]interface{}{},
+	}}
+	n.credentials = append(n.credentials, stored)
+
+	return ctx.JSON(http.StatusOK, stored.credential)
+}
+
+func (n *Node) resolveCredentialHandler(ctx echo.Context) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	stored := n.credential(pathParam(ctx, "id"))
+	if stored == nil {
+		return errorResponse(ctx, http.StatusNotFound, "credential not found")
+	}
+	status := vcr.ResolutionResultCurrentStatusUntrusted
+	if stored.revoked {
+		status = vcr.ResolutionResultCurrentStatusRevoked
+	} else if n.isTrusted(*stored) {
+		status = vcr.ResolutionResultCurrentStatusTrusted
+	}
+	return ctx.JSON(http.StatusOK, vcr.ResolutionResult{
+		CurrentStatus:        status,
+		VerifiableCredential: stored.credential,
+	})
+}
+
+func (n *Node) revokeCredentialHandler(ctx echo.Context) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	stored := n.credential(pathParam(ctx, "id"))
+	if stored == nil {
+		return errorResponse(ctx, http.StatusNotFound, "credential not found")
+	}
+	if stored.revoked {
+		return errorResponse(ctx, http.StatusConflict, "credential is already revoked")
+	}
+	stored.revoked = true
+	return ctx.JSON(http.StatusOK, vcr.Revocation{
+		Issuer:  stored.credential.Issuer,
+		Subject: stored.id(),
+		Date:    time.Now().Format(time.RFC3339),
+	})
+}
+
+// searchHandler returns the valid credentials of the concept that match the params. Organizations are returned in the
+// concept template, authorizations as credentials.
+func (n *Node) searchHandler(ctx echo.Context) error {
+	concept := ctx.Param("concept")
+	credentialType, ok := concepts[concept]
+	if !ok {
+		return errorResponse(ctx, http.StatusNotFound, "unknown concept: "+concept)
+	}
+	untrusted := ctx.QueryParam("untrusted") == "true"
+	request := vcr.SearchRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	results := []interface{}{}
+	now := time.Now()
+	for _, stored := range n.credentials {
+		if stored.typ() != credentialType || !stored.valid(now) || (!untrusted && !n.isTrusted(*stored)) {
+			continue
+		}
+		if !stored.matches(concept, request.Params) {
+			continue
+		}
+		if concept == "organization" {
+			results = append(results, organizationTemplate(*stored))
+		} else {
+			results = append(results, stored.credential)
+		}
+	}
+	return ctx.JSON(http.StatusOK, results)
+}
+
+func (n *Node) trustHandler(trusted bool) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		request := vcr.CredentialIssuer{}
+		if err := ctx.Bind(&request); err != nil {
+			return errorResponse(ctx, http.StatusBadRequest, err.Error())
+		}
+		if request.CredentialType == "" || request.Issuer == "" {
+			return errorResponse(ctx, http.StatusBadRequest, "missing credentialType or issuer")
+		}
+
+		n.mux.Lock()
+		defer n.mux.Unlock()
+
+		n.setTrust(request.CredentialType, request.Issuer, trusted)
+		return ctx.NoContent(http.StatusNoContent)
+	}
+}
+
+func (n *Node) listTrustedHandler(ctx echo.Context) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	issuers := []string{}
+	for issuer := range n.trusted[ctx.Param("type")] {
+		issuers = append(issuers, issuer)
+	}
+	sort.Strings(issuers)
+	return ctx.JSON(http.StatusOK, issuers)
+}
+
+// listUntrustedHandler returns the issuers of the credentials of the type that aren't trusted.
+func (n *Node) listUntrustedHandler(ctx echo.Context) error {
+	credentialType := ctx.Param("type")
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	issuers := []string{}
+	found := map[string]bool{}
+	for _, stored := range n.credentials {
+		issuer := string(stored.credential.Issuer)
+		if stored.typ() != credentialType || n.isTrusted(*stored) || found[issuer] {
+			continue
+		}
+		found[issuer] = true
+		issuers = append(issuers, issuer)
+	}
+	sort.Strings(issuers)
+	return ctx.JSON(http.StatusOK, issuers)
+}
+
+func organizationTemplate(stored storedCredential) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           stored.id(),
+		"issuer":       stored.credential.Issuer,
+		"type":         stored.typ(),
+		"subject":      stored.subject(),
+		"organization": stored.credential.CredentialSubject["organization"],
+	}
+}
+
+func (n *Node) credential(id string) *storedCredential {
+	for _, stored := range n.credentials {
+		if stored.id() == id {
+			return stored
+		}
+	}
+	return nil
+}
+
+func (n *Node) isTrusted(stored storedCredential) bool {
+	return n.trusted[stored.typ()][string(stored.credential.Issuer)]
+}
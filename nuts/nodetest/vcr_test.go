@@ -0,0 +1,70 @@
+package nodetest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nuts-foundation/nuts-node/vcr/credential"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nutsClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+)
+
+func TestNode_AuthorizationCredentials(t *testing.T) {
+	node := NewNode()
+	server := httptest.NewServer(node)
+	defer server.Close()
+	client := nutsClient.HTTPClient{NutsNodeAddress: server.URL}
+	ctx := context.Background()
+	issuer, subject := node.CreateDID(), node.CreateDID()
+
+	issue := func(path string, expiration *time.Time) string {
+		id, err := client.CreateVC(ctx, credential.NutsAuthorizationCredentialType, issuer, map[string]interface{}{
+			"id":           subject,
+			"purposeOfUse": "eOverdracht-sender",
+			"resources":    []map[string]interface{}{{"path": path, "operations": []string{"read"}}},
+		}, expiration)
+		require.NoError(t, err)
+		return id
+	}
+	find := func(path string) []string {
+		ids, err := client.FindAuthorizationCredentialIDs(ctx, map[string]string{
+			"issuer":                             issuer,
+			"credentialSubject.id":               subject,
+			"credentialSubject.resources.#.path": path,
+		})
+		require.NoError(t, err)
+		return ids
+	}
+
+	t.Run("search matches resources", func(t *testing.T) {
+		id := issue("/Task/1", nil)
+		issue("/Task/2", nil)
+
+		assert.Equal(t, []string{id}, find("/Task/1"))
+		assert.Empty(t, find("/Task/3"))
+	})
+	t.Run("revoked credentials aren't found", func(t *testing.T) {
+		ids := find("/Task/2")
+		require.Len(t, ids, 1)
+
+		require.NoError(t, client.RevokeCredential(ctx, ids[0]))
+
+		assert.Empty(t, find("/Task/2"))
+		assert.ErrorIs(t, client.RevokeCredential(ctx, ids[0]), nutsClient.ErrCredentialRevoked)
+	})
+	t.Run("expired credentials aren't found", func(t *testing.T) {
+		expiration := time.Now().Add(-time.Minute)
+		issue("/Task/4", &expiration)
+
+		assert.Empty(t, find("/Task/4"))
+	})
+	t.Run("issuer must be known", func(t *testing.T) {
+		_, err := client.CreateVC(ctx, credential.NutsAuthorizationCredentialType, "did:nuts:unknown", map[string]interface{}{"id": subject}, nil)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,65 @@
+package nodetest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vdr"
+)
+
+func (n *Node) registerVDR() {
+	n.router.POST("/internal/vdr/v1/did", n.createDIDHandler)
+	n.router.GET("/internal/vdr/v1/did/:did", n.resolveDIDHandler)
+}
+
+func (n *Node) createDIDHandler(ctx echo.Context) error {
+	request := vdr.DIDCreateRequest{}
+	if err := ctx.Bind(&request); err != nil {
+		return errorResponse(ctx, http.StatusBadRequest, err.Error())
+	}
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	var controllers []string
+	if request.Controllers != nil {
+		controllers = append(controllers, *request.Controllers...)
+	}
+	for _, controller := range controllers {
+		if _, ok := n.documents[controller]; !ok {
+			return errorResponse(ctx, http.StatusBadRequest, "unknown controller: "+controller)
+		}
+	}
+	did := n.createDID(controllers)
+
+	return ctx.JSON(http.StatusOK, n.documents[did].toVDR())
+}
+
+func (n *Node) resolveDIDHandler(ctx echo.Context) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	doc, ok := n.documents[pathParam(ctx, "did")]
+	if !ok {
+		return errorResponse(ctx, http.StatusNotFound, "unable to find the DID document")
+	}
+	return ctx.JSON(http.StatusOK, vdr.DIDResolutionResult{
+		Document: doc.toVDR(),
+		DocumentMetadata: vdr.DIDDocumentMetadata{
+			Created: time.Now().Format(time.RFC3339),
+			Txs:     []string{},
+		},
+	})
+}
+
+// toVDR returns the DID document without its services, which the EHR manages through didman.
+func (d *document) toVDR() vdr.DIDDocument {
+	result := vdr.DIDDocument{Id: d.id}
+	if len(d.controllers) > 0 {
+		var controller interface{} = d.controllers
+		result.Controller = &controller
+	}
+	return result
+}
@@ -0,0 +1,29 @@
+package registry
+
+import "time"
+
+// ExpirationPolicy determines when the NutsAuthorizationCredentials issued for a Bolt expire.
+type ExpirationPolicy struct {
+	// GracePeriod is the time after the date the access is needed until (e.g. the transfer date) the credential expires.
+	GracePeriod time.Duration
+	// Validity is the time after issuance the credential expires when that date is unknown. If 0, it doesn't expire.
+	Validity time.Duration
+}
+
+// ExpirationDate returns the expiration date of a credential issued now that gives access until the given date, which
+// may be nil if it's unknown. It returns nil if the credential shouldn't expire.
+// Dates in the past are treated as now, so the credential is always valid for at least the grace period.
+func (p ExpirationPolicy) ExpirationDate(until *time.Time, now time.Time) *time.Time {
+	var result time.Time
+	switch {
+	case until == nil && p.Validity == 0:
+		return nil
+	case until == nil:
+		result = now.Add(p.Validity)
+	case until.Before(now):
+		result = now.Add(p.GracePeriod)
+	default:
+		result = until.Add(p.GracePeriod)
+	}
+	return &result
+}
@@ -0,0 +1,30 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpirationPolicy_ExpirationDate(t *testing.T) {
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+	policy := ExpirationPolicy{GracePeriod: 24 * time.Hour, Validity: 30 * 24 * time.Hour}
+
+	t.Run("grace period after the date", func(t *testing.T) {
+		until := now.AddDate(0, 0, 7)
+
+		assert.Equal(t, until.Add(24*time.Hour), *policy.ExpirationDate(&until, now))
+	})
+	t.Run("date in the past", func(t *testing.T) {
+		until := now.AddDate(0, 0, -7)
+
+		assert.Equal(t, now.Add(24*time.Hour), *policy.ExpirationDate(&until, now))
+	})
+	t.Run("unknown date", func(t *testing.T) {
+		assert.Equal(t, now.AddDate(0, 0, 30), *policy.ExpirationDate(nil, now))
+	})
+	t.Run("unknown date without validity", func(t *testing.T) {
+		assert.Nil(t, ExpirationPolicy{GracePeriod: time.Hour}.ExpirationDate(nil, now))
+	})
+}
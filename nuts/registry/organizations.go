@@ -3,43 +3,54 @@ package registry
 import (
 	"context"
 	"errors"
-	"sync"
+	"fmt"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
 	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/didman"
 )
 
-const cacheMaxAge = 10 * time.Second
+// ErrOrganizationNotFound is returned when no trusted organization credential was issued to the DID.
+var ErrOrganizationNotFound = errors.New("organization not found")
 
 type OrganizationRegistry interface {
 	Search(ctx context.Context, query string, didServiceType *string) ([]types.Organization, error)
+	// Get returns the organization of the DID. When multiple trusted organization credentials were issued to the DID,
+	// the most recently issued one is used. It returns ErrOrganizationNotFound if there's no trusted credential.
 	Get(ctx context.Context, organizationDID string) (*types.Organization, error)
 	GetCompoundServiceEndpoint(ctx context.Context, organizationDID, serviceType string, field string) (string, error)
+	// Invalidate removes the cached organization and endpoints of the DID, e.g. after its registration changed.
+	Invalidate(organizationDID string)
 }
 
-func NewOrganizationRegistry(client *client.HTTPClient) OrganizationRegistry {
+// NewOrganizationRegistry creates an OrganizationRegistry that caches organizations and endpoints in the given cache.
+// DIDs without organization are cached too, for the notFoundTTL.
+func NewOrganizationRegistry(didman client.DIDManClient, vcr client.VCRClient, organizationCache *cache.Cache, notFoundTTL time.Duration) OrganizationRegistry {
 	return &remoteOrganizationRegistry{
-		client:   client,
-		cache:    map[string]entry{},
-		cacheMux: &sync.Mutex{},
+		didman:      didman,
+		vcr:         vcr,
+		cache:       organizationCache,
+		notFoundTTL: notFoundTTL,
 	}
 }
 
 type remoteOrganizationRegistry struct {
-	client   *client.HTTPClient
-	cache    map[string]entry
-	cacheMux *sync.Mutex
+	didman client.DIDManClient
+	vcr    client.VCRClient
+	// cache holds the organizations and endpoints, by keys prefixed with the DID
+	cache       *cache.Cache
+	notFoundTTL time.Duration
 }
 
-type entry struct {
-	organization types.Organization
-	writeTime    time.Time
-}
+// notFound is cached for DIDs without organization
+type notFound struct{}
 
 func (r remoteOrganizationRegistry) Search(ctx context.Context, query string, didServiceType *string) ([]types.Organization, error) {
-	organizations, err := r.client.SearchOrganizations(ctx, query, didServiceType)
+	organizations, err := r.didman.SearchOrganizations(ctx, query, didServiceType)
 	if err != nil {
 		return nil, err
 	}
@@ -47,34 +58,85 @@ func (r remoteOrganizationRegistry) Search(ctx context.Context, query string, di
 	for i, curr := range organizations {
 		results[i] = organizationSearchResultToDomain(curr)
 	}
-	r.toCache(results...)
 	return results, nil
 }
 
 func (r remoteOrganizationRegistry) Get(ctx context.Context, organizationDID string) (*types.Organization, error) {
-	cached := r.fromCache(organizationDID)
-	if cached != nil {
-		return cached, nil
+	key := organizationKey(organizationDID)
+	if cached, ok := r.cache.Get(key); ok {
+		if organization, ok := cached.(types.Organization); ok {
+			return &organization, nil
+		}
+		return nil, ErrOrganizationNotFound
 	}
 
-	raw, err := r.client.GetOrganization(ctx, organizationDID)
+	raw, err := r.vcr.GetOrganization(ctx, organizationDID)
 	if err != nil {
 		return nil, err
 	}
 	if len(raw) == 0 {
-		return nil, errors.New("organization not found")
+		r.cache.SetWithExpiry(key, notFound{}, time.Now().Add(r.notFoundTTL))
+		return nil, ErrOrganizationNotFound
 	}
+	concept := raw[0]
 	if len(raw) > 1 {
-		// TODO: Get latest issued VC, or maybe all of them?
-		return nil, errors.New("multiple organizations found (not supported yet)")
+		if concept, err = r.latestIssued(ctx, raw); err != nil {
+			return nil, err
+		}
 	}
-	result := organizationConceptToDomain(raw[0])
-	r.toCache(result)
+	result := organizationConceptToDomain(concept)
+	r.cache.Set(key, result)
 	return &result, nil
 }
 
 func (r remoteOrganizationRegistry) GetCompoundServiceEndpoint(ctx context.Context, organizationDID, serviceType string, field string) (string, error) {
-	return r.client.GetCompoundServiceEndpoint(ctx, organizationDID, serviceType, field)
+	key := fmt.Sprintf("%s|endpoint|%s|%s", organizationDID, serviceType, field)
+	if cached, ok := r.cache.Get(key); ok {
+		return cached.(string), nil
+	}
+
+	endpoint, err := r.didman.GetCompoundServiceEndpoint(ctx, organizationDID, serviceType, field)
+	if err != nil {
+		return "", err
+	}
+	r.cache.Set(key, endpoint)
+	return endpoint, nil
+}
+
+func (r remoteOrganizationRegistry) Invalidate(organizationDID string) {
+	r.cache.DeletePrefix(organizationDID + "|")
+}
+
+// latestIssued returns the concept of the most recently issued credential. The concepts don't contain the issuance
+// date, so the credentials are resolved. Credentials that can't be resolved, e.g. because they were revoked, are skipped.
+func (r remoteOrganizationRegistry) latestIssued(ctx context.Context, concepts []map[string]interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	var latest time.Time
+	for _, concept := range concepts {
+		id, _ := concept["id"].(string)
+		credential, err := r.vcr.ResolveVerifiableCredential(ctx, id, false)
+		if err != nil {
+			logrus.Warnf("Unable to resolve organization credential (id=%s): %v", id, err)
+			continue
+		}
+		issuanceDate, err := time.Parse(time.RFC3339, credential.IssuanceDate)
+		if err != nil {
+			logrus.Warnf("Invalid issuance date of organization credential (id=%s): %v", id, err)
+			continue
+		}
+		if result == nil || issuanceDate.After(latest) {
+			result = concept
+			latest = issuanceDate
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("none of the %d organization credentials could be resolved", len(concepts))
+	}
+	return result, nil
+}
+
+func organizationKey(organizationDID string) string {
+	return organizationDID + "|organization"
 }
 
 func organizationConceptToDomain(concept map[string]interface{}) types.Organization {
@@ -94,23 +156,3 @@ func organizationSearchResultToDomain(result didman.OrganizationSearchResult) ty
 		Name: org["name"].(string),
 	}
 }
-
-func (r remoteOrganizationRegistry) toCache(organizations ...types.Organization) {
-	r.cacheMux.Lock()
-	defer r.cacheMux.Unlock()
-	for _, organization := range organizations {
-		r.cache[organization.Did] = entry{
-			organization: organization,
-			writeTime:    time.Now(),
-		}
-	}
-}
-
-func (r remoteOrganizationRegistry) fromCache(organizationDID string) *types.Organization {
-	r.cacheMux.Lock()
-	defer r.cacheMux.Unlock()
-	if cached, ok := r.cache[organizationDID]; ok && time.Now().Before(cached.writeTime.Add(cacheMaxAge)) {
-		return &cached.organization
-	}
-	return nil
-}
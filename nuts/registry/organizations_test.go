@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vcr"
+)
+
+// stubNode counts the calls to the Nuts node
+type stubNode struct {
+	client.DIDManClient
+	client.VCRClient
+	organizations []map[string]interface{}
+	// issuanceDates of the organization credentials by ID
+	issuanceDates map[string]string
+	calls         int
+}
+
+func (s *stubNode) GetOrganization(_ context.Context, _ string) ([]map[string]interface{}, error) {
+	s.calls++
+	return s.organizations, nil
+}
+
+func (s *stubNode) ResolveVerifiableCredential(_ context.Context, credentialID string, _ bool) (*vcr.VerifiableCredential, error) {
+	if issuanceDate, ok := s.issuanceDates[credentialID]; ok {
+		return &vcr.VerifiableCredential{IssuanceDate: issuanceDate}, nil
+	}
+	return nil, errors.New("credential is revoked")
+}
+
+func (s *stubNode) GetCompoundServiceEndpoint(_ context.Context, _, _ string, _ string) (string, error) {
+	s.calls++
+	return "https://care-home.example.com/fhir", nil
+}
+
+func organizationConcept(id, name string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           id,
+		"subject":      "did:nuts:care-home",
+		"organization": map[string]interface{}{"name": name, "city": "Utrecht"},
+	}
+}
+
+func TestRemoteOrganizationRegistry_Get(t *testing.T) {
+	newRegistry := func(node *stubNode) OrganizationRegistry {
+		return NewOrganizationRegistry(node, node, cache.New(time.Minute, 10), time.Minute)
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		node := &stubNode{organizations: []map[string]interface{}{organizationConcept("1", "Care Home")}}
+		registry := newRegistry(node)
+
+		_, _ = registry.Get(context.Background(), "did:nuts:care-home")
+		organization, err := registry.Get(context.Background(), "did:nuts:care-home")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Care Home", organization.Name)
+		assert.Equal(t, 1, node.calls)
+	})
+	t.Run("not found is cached", func(t *testing.T) {
+		node := &stubNode{}
+		registry := newRegistry(node)
+
+		_, _ = registry.Get(context.Background(), "did:nuts:care-home")
+		_, err := registry.Get(context.Background(), "did:nuts:care-home")
+
+		assert.ErrorIs(t, err, ErrOrganizationNotFound)
+		assert.Equal(t, 1, node.calls)
+	})
+	t.Run("invalidate", func(t *testing.T) {
+		node := &stubNode{}
+		registry := newRegistry(node)
+		_, _ = registry.Get(context.Background(), "did:nuts:care-home")
+		node.organizations = []map[string]interface{}{organizationConcept("1", "Care Home")}
+
+		registry.Invalidate("did:nuts:care-home")
+		organization, err := registry.Get(context.Background(), "did:nuts:care-home")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Care Home", organization.Name)
+	})
+	t.Run("multiple credentials: most recently issued", func(t *testing.T) {
+		node := &stubNode{
+			organizations: []map[string]interface{}{
+				organizationConcept("1", "Care Home"),
+				organizationConcept("2", "Care Home Utrecht"),
+				organizationConcept("3", "Revoked Care Home"),
+			},
+			issuanceDates: map[string]string{
+				"1": "2021-01-01T12:00:00Z",
+				"2": "2021-06-01T12:00:00Z",
+			},
+		}
+
+		organization, err := newRegistry(node).Get(context.Background(), "did:nuts:care-home")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Care Home Utrecht", organization.Name)
+	})
+}
+
+func TestRemoteOrganizationRegistry_GetCompoundServiceEndpoint(t *testing.T) {
+	node := &stubNode{}
+	registry := NewOrganizationRegistry(node, node, cache.New(time.Minute, 10), time.Minute)
+
+	_, _ = registry.GetCompoundServiceEndpoint(context.Background(), "did:nuts:care-home", "zorginzage-demo", "fhir")
+	endpoint, err := registry.GetCompoundServiceEndpoint(context.Background(), "did:nuts:care-home", "zorginzage-demo", "fhir")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://care-home.example.com/fhir", endpoint)
+	assert.Equal(t, 1, node.calls)
+}
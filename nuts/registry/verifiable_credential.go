@@ -3,14 +3,46 @@ package registry
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/nuts-foundation/go-did/vc"
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	"github.com/sirupsen/logrus"
 
 	nutsClient "github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
 	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vcr"
 	"github.com/nuts-foundation/nuts-node/vcr/credential"
 )
 
+// TrustPolicy determines which issuers of NutsAuthorizationCredentials are trusted.
+type TrustPolicy string
+
+const (
+	// TrustOrganizations trusts issuers with a trusted NutsOrganizationCredential, i.e. known care organizations.
+	TrustOrganizations TrustPolicy = "organizations"
+	// TrustVCR trusts the issuers the Nuts node trusts for NutsAuthorizationCredentials.
+	TrustVCR TrustPolicy = "vcr"
+	// TrustAll trusts all issuers, which was the behaviour before trust was enforced.
+	TrustAll TrustPolicy = "all"
+)
+
+// ParseTrustPolicy returns the policy of the name, which defaults to TrustOrganizations.
+func ParseTrustPolicy(name string) (TrustPolicy, error) {
+	switch policy := TrustPolicy(name); policy {
+	case "":
+		return TrustOrganizations, nil
+	case TrustOrganizations, TrustVCR, TrustAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid trust policy: %s, valid options are: '%s', '%s' or '%s'", name, TrustOrganizations, TrustVCR, TrustAll)
+	}
+}
+
+// ErrUntrustedIssuer is returned when a credential was issued by an issuer that isn't trusted according to the TrustPolicy.
+var ErrUntrustedIssuer = errors.New("issuer of credential is not trusted")
+
 type VCRSearchParams struct {
 	PurposeOfUse string
 	SubjectID    string
@@ -35,39 +67,61 @@ func convertCredential(input *vcr.VerifiableCredential) (*vc.VerifiableCredentia
 }
 
 type VerifiableCredentialRegistry interface {
-	// CreateAuthorizationCredential creates a NutsAuthorizationCredential on the nuts node
-	CreateAuthorizationCredential(ctx context.Context, issuer string, subject *credential.NutsAuthorizationCredentialSubject) error
-	// RevokeAuthorizationCredential revokes a credential based on the resourcePath contained in the credential
+	// CreateAuthorizationCredential creates a NutsAuthorizationCredential on the nuts node and returns its ID. It
+	// expires at the expirationDate, or never if it's nil.
+	CreateAuthorizationCredential(ctx context.Context, issuer string, subject *credential.NutsAuthorizationCredentialSubject, expirationDate *time.Time) (string, error)
+	// RevokeAuthorizationCredential revokes the credentials of the subject for the purpose that contain the resourcePath.
+	// It's meant for records that don't have the ID of their credential, otherwise RevokeCredential is used.
 	RevokeAuthorizationCredential(ctx context.Context, purposeOfUse, subjectID, resourcePath string) error
-	// ResolveVerifiableCredential from the nuts node. It also returns untrusted credentials
+	// ResolveVerifiableCredential from the nuts node. It never returns revoked credentials and returns ErrUntrustedIssuer
+	// when the issuer isn't trusted according to the TrustPolicy.
+	// Resolved credentials are cached, so revocations by other parties are noticed once the cache entry expires.
 	ResolveVerifiableCredential(ctx context.Context, credentialID string) (*vc.VerifiableCredential, error)
-	// FindAuthorizationCredentials returns the NutsAuthorizationCredential for the given params
+	// ResolveIssuedCredential resolves a credential issued by one of the customers, regardless of whether the issuer
+	// is trusted. It isn't cached, so a revoked credential is never returned.
+	ResolveIssuedCredential(ctx context.Context, credentialID string) (*vc.VerifiableCredential, error)
+	// FindAuthorizationCredentials returns the NutsAuthorizationCredential for the given params, of trusted issuers only.
 	FindAuthorizationCredentials(ctx context.Context, params *VCRSearchParams) ([]vc.VerifiableCredential, error)
+	// FindIssuedAuthorizationCredentials returns the NutsAuthorizationCredentials issued by params.Issuer that aren't
+	// revoked or expired, regardless of whether the issuer is trusted. The other params are only matched if set.
+	FindIssuedAuthorizationCredentials(ctx context.Context, params *VCRSearchParams) ([]vc.VerifiableCredential, error)
+	// RevokeCredential revokes the credential with the given ID. Revoking a revoked credential has no effect.
+	RevokeCredential(ctx context.Context, credentialID string) error
 }
 
 type httpVerifiableCredentialRegistry struct {
 	nutsClient nutsClient.VCRClient
+	// credentials caches resolved credentials by their ID
+	credentials *cache.Cache
+	policy      TrustPolicy
+	// organizations is used to check the issuers when the policy is TrustOrganizations
+	organizations OrganizationRegistry
 }
 
-func NewVerifiableCredentialRegistry(client nutsClient.VCRClient) VerifiableCredentialRegistry {
+// NewVerifiableCredentialRegistry creates a VerifiableCredentialRegistry that only returns NutsAuthorizationCredentials
+// of issuers trusted according to the policy.
+func NewVerifiableCredentialRegistry(client nutsClient.VCRClient, credentialCache *cache.Cache, policy TrustPolicy, organizations OrganizationRegistry) VerifiableCredentialRegistry {
 	return &httpVerifiableCredentialRegistry{
-		nutsClient: client,
+		nutsClient:    client,
+		credentials:   credentialCache,
+		policy:        policy,
+		organizations: organizations,
 	}
 }
 
-func (registry *httpVerifiableCredentialRegistry) CreateAuthorizationCredential(ctx context.Context, issuer string, subject *credential.NutsAuthorizationCredentialSubject) error {
+func (registry *httpVerifiableCredentialRegistry) CreateAuthorizationCredential(ctx context.Context, issuer string, subject *credential.NutsAuthorizationCredentialSubject, expirationDate *time.Time) (string, error) {
 	subjectMap := map[string]interface{}{}
 
 	data, err := json.Marshal(subject)
 	if err != nil {
-		return fmt.Errorf("invalid subject: %w", err)
+		return "", fmt.Errorf("invalid subject: %w", err)
 	}
 
 	if err := json.Unmarshal(data, &subjectMap); err != nil {
-		return fmt.Errorf("invalid subject: %w", err)
+		return "", fmt.Errorf("invalid subject: %w", err)
 	}
 
-	return registry.nutsClient.CreateVC(ctx, credential.NutsAuthorizationCredentialType, issuer, subjectMap, nil)
+	return registry.nutsClient.CreateVC(ctx, credential.NutsAuthorizationCredentialType, issuer, subjectMap, expirationDate)
 }
 
 func (registry *httpVerifiableCredentialRegistry) FindAuthorizationCredentials(ctx context.Context, params *VCRSearchParams) ([]vc.VerifiableCredential, error) {
@@ -89,25 +143,75 @@ func (registry *httpVerifiableCredentialRegistry) FindAuthorizationCredentials(c
 		searchParams["issuer"] = params.Issuer
 	}
 
-	credentials, err := registry.nutsClient.FindAuthorizationCredentials(ctx, searchParams)
+	credentials, err := registry.nutsClient.FindAuthorizationCredentials(ctx, searchParams, registry.policy != TrustVCR)
 	if err != nil {
 		return nil, err
 	}
 
-	results := make([]vc.VerifiableCredential, len(credentials))
+	results := make([]vc.VerifiableCredential, 0, len(credentials))
 
-	for i, authCredential := range credentials {
+	for _, authCredential := range credentials {
 		result, err := convertCredential(&authCredential) //nolint:gosec
 		if err != nil {
 			return nil, err
 		}
 
-		results[i] = *result
+		if err := registry.verifyIssuer(ctx, *result); errors.Is(err, ErrUntrustedIssuer) {
+			logrus.Debugf("Skipping NutsAuthorizationCredential of untrusted issuer (id=%s, issuer=%s)", result.ID, result.Issuer.String())
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		results = append(results, *result)
+	}
+
+	return results, nil
+}
+
+func (registry *httpVerifiableCredentialRegistry) FindIssuedAuthorizationCredentials(ctx context.Context, params *VCRSearchParams) ([]vc.VerifiableCredential, error) {
+	searchParams := map[string]string{"issuer": params.Issuer}
+	if params.PurposeOfUse != "" {
+		searchParams["credentialSubject.purposeOfUse"] = params.PurposeOfUse
+	}
+	if params.ResourcePath != "" {
+		searchParams["credentialSubject.resources.#.path"] = params.ResourcePath
+	}
+	if params.SubjectID != "" {
+		searchParams["credentialSubject.id"] = params.SubjectID
+	}
+	if params.Subject != "" {
+		searchParams["credentialSubject.subject"] = params.Subject
+	}
+
+	credentials, err := registry.nutsClient.FindAuthorizationCredentials(ctx, searchParams, true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]vc.VerifiableCredential, 0, len(credentials))
+	for _, authCredential := range credentials {
+		result, err := convertCredential(&authCredential) //nolint:gosec
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
 	}
 
 	return results, nil
 }
 
+func (registry *httpVerifiableCredentialRegistry) RevokeCredential(ctx context.Context, credentialID string) error {
+	err := registry.nutsClient.RevokeCredential(ctx, credentialID)
+	if errors.Is(err, nutsClient.ErrCredentialRevoked) {
+		logrus.Debugf("NutsAuthorizationCredential is already revoked (id=%s)", credentialID)
+	} else if err != nil {
+		return err
+	}
+	registry.credentials.Delete(credentialID)
+	return nil
+}
+
 func (registry *httpVerifiableCredentialRegistry) RevokeAuthorizationCredential(ctx context.Context, purposeOfUse, subjectID, resourcePath string) error {
 	// may be extended by issuanceDate for even faster results.
 	params := map[string]string{
@@ -115,12 +219,16 @@ func (registry *httpVerifiableCredentialRegistry) RevokeAuthorizationCredential(
 		"credentialSubject.purposeOfUse":     purposeOfUse,
 		"credentialSubject.resources.#.path": resourcePath,
 	}
-	credentialIDs, err := registry.nutsClient.FindAuthorizationCredentialIDs(ctx, params)
+	credentials, err := registry.nutsClient.FindAuthorizationCredentials(ctx, params, true)
 	if err != nil {
 		return err
 	}
-	for _, ID := range credentialIDs {
-		if err = registry.nutsClient.RevokeCredential(ctx, ID); err != nil {
+	for _, authCredential := range credentials {
+		// the Nuts node matches the path as prefix, so /Task/1 would also revoke the credential of /Task/12
+		if authCredential.Id == nil || !containsResource(authCredential, resourcePath) {
+			continue
+		}
+		if err = registry.RevokeCredential(ctx, *authCredential.Id); err != nil {
 			return err
 		}
 	}
@@ -128,11 +236,71 @@ func (registry *httpVerifiableCredentialRegistry) RevokeAuthorizationCredential(
 	return nil
 }
 
+// containsResource returns true if the credential subject contains a resource with exactly the path.
+func containsResource(authCredential vcr.VerifiableCredential, resourcePath string) bool {
+	data, _ := json.Marshal(authCredential.CredentialSubject)
+	subject := credential.NutsAuthorizationCredentialSubject{}
+	if err := json.Unmarshal(data, &subject); err != nil {
+		return false
+	}
+	for _, resource := range subject.Resources {
+		if resource.Path == resourcePath {
+			return true
+		}
+	}
+	return false
+}
+
 func (registry *httpVerifiableCredentialRegistry) ResolveVerifiableCredential(ctx context.Context, credentialID string) (*vc.VerifiableCredential, error) {
-	authCredential, err := registry.nutsClient.ResolveVerifiableCredential(ctx, credentialID, true)
+	if cached, ok := registry.credentials.Get(credentialID); ok {
+		result := cached.(vc.VerifiableCredential)
+		// the trust in the issuer might have changed since the credential was cached
+		if err := registry.verifyIssuer(ctx, result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	}
+
+	authCredential, err := registry.nutsClient.ResolveVerifiableCredential(ctx, credentialID, registry.policy != TrustVCR)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := convertCredential(authCredential)
 	if err != nil {
 		return nil, err
 	}
+	if err := registry.verifyIssuer(ctx, *result); err != nil {
+		return nil, err
+	}
+
+	var expiry time.Time
+	if result.ExpirationDate != nil {
+		expiry = *result.ExpirationDate
+	}
+	registry.credentials.SetWithExpiry(credentialID, *result, expiry)
+
+	return result, nil
+}
 
+func (registry *httpVerifiableCredentialRegistry) ResolveIssuedCredential(ctx context.Context, credentialID string) (*vc.VerifiableCredential, error) {
+	authCredential, err := registry.nutsClient.ResolveVerifiableCredential(ctx, credentialID, true)
+	if err != nil {
+		return nil, err
+	}
 	return convertCredential(authCredential)
 }
+
+// verifyIssuer returns ErrUntrustedIssuer if the issuer of the credential isn't trusted according to the policy.
+// Issuers trusted by the Nuts node (TrustVCR) are already checked when resolving or searching the credential.
+func (registry *httpVerifiableCredentialRegistry) verifyIssuer(ctx context.Context, credential vc.VerifiableCredential) error {
+	if registry.policy != TrustOrganizations {
+		return nil
+	}
+	issuer := credential.Issuer.String()
+	_, err := registry.organizations.Get(ctx, issuer)
+	if errors.Is(err, ErrOrganizationNotFound) {
+		return fmt.Errorf("%w: %s doesn't have a trusted NutsOrganizationCredential", ErrUntrustedIssuer, issuer)
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/nuts-foundation/nuts-demo-ehr/cache"
+	"github.com/nuts-foundation/nuts-demo-ehr/domain/types"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client"
+	"github.com/nuts-foundation/nuts-demo-ehr/nuts/client/vcr"
+)
+
+// stubVCR returns the credentials, regardless of the search params
+type stubVCR struct {
+	client.VCRClient
+	credentials []vcr.VerifiableCredential
+	untrusted   bool
+	revoked     map[string]bool
+	// resolved counts how often a credential was resolved by the Nuts node
+	resolved int
+}
+
+func (s *stubVCR) RevokeCredential(_ context.Context, credentialID string) error {
+	if s.revoked[credentialID] {
+		return client.ErrCredentialRevoked
+	}
+	s.revoked[credentialID] = true
+	return nil
+}
+
+func (s *stubVCR) FindAuthorizationCredentials(_ context.Context, _ map[string]string, untrusted bool) ([]vcr.VerifiableCredential, error) {
+	s.untrusted = untrusted
+	return s.credentials, nil
+}
+
+func (s *stubVCR) ResolveVerifiableCredential(_ context.Context, credentialID string, untrusted bool) (*vcr.VerifiableCredential, error) {
+	s.untrusted = untrusted
+	s.resolved++
+	for _, curr := range s.credentials {
+		if *curr.Id == credentialID {
+			return &curr, nil
+		}
+	}
+	return nil, ErrOrganizationNotFound
+}
+
+// stubOrganizations contains the DIDs of the organizations with a trusted organization credential
+type stubOrganizations struct {
+	OrganizationRegistry
+	trusted map[string]bool
+}
+
+func (s stubOrganizations) Get(_ context.Context, organizationDID string) (*types.Organization, error) {
+	if !s.trusted[organizationDID] {
+		return nil, ErrOrganizationNotFound
+	}
+	return &types.Organization{Did: organizationDID}, nil
+}
+
+func authorizationCredential(id, issuer string) vcr.VerifiableCredential {
+	return vcr.VerifiableCredential{
+		Context:           []string{"https://www.w3.org/2018/credentials/v1"},
+		Id:                &id,
+		Type:              []string{"VerifiableCredential", "NutsAuthorizationCredential"},
+		Issuer:            vcr.DID(issuer),
+		IssuanceDate:      time.Now().Format(time.RFC3339),
+		CredentialSubject: map[string]interface{}{"id": "did:nuts:subject"},
+	}
+}
+
+func TestHttpVerifiableCredentialRegistry_TrustPolicy(t *testing.T) {
+	node := &stubVCR{credentials: []vcr.VerifiableCredential{
+		authorizationCredential("did:nuts:trusted#1", "did:nuts:trusted"),
+		authorizationCredential("did:nuts:unknown#1", "did:nuts:unknown"),
+	}}
+	organizations := stubOrganizations{trusted: map[string]bool{"did:nuts:trusted": true}}
+	newRegistry := func(policy TrustPolicy) VerifiableCredentialRegistry {
+		return NewVerifiableCredentialRegistry(node, cache.New(time.Minute, 10), policy, organizations)
+	}
+	ctx := context.Background()
+
+	t.Run("organizations - only issuers with a trusted organization credential", func(t *testing.T) {
+		registry := newRegistry(TrustOrganizations)
+
+		credentials, err := registry.FindAuthorizationCredentials(ctx, &VCRSearchParams{})
+		require.NoError(t, err)
+		require.Len(t, credentials, 1)
+		assert.Equal(t, "did:nuts:trusted", credentials[0].Issuer.String())
+
+		_, err = registry.ResolveVerifiableCredential(ctx, "did:nuts:trusted#1")
+		assert.NoError(t, err)
+		_, err = registry.ResolveVerifiableCredential(ctx, "did:nuts:unknown#1")
+		assert.ErrorIs(t, err, ErrUntrustedIssuer)
+	})
+	t.Run("organizations - trust is checked for cached credentials", func(t *testing.T) {
+		organizations := stubOrganizations{trusted: map[string]bool{"did:nuts:trusted": true}}
+		registry := NewVerifiableCredentialRegistry(node, cache.New(time.Minute, 10), TrustOrganizations, organizations)
+		_, _ = registry.ResolveVerifiableCredential(ctx, "did:nuts:trusted#1")
+
+		organizations.trusted["did:nuts:trusted"] = false
+		_, err := registry.ResolveVerifiableCredential(ctx, "did:nuts:trusted#1")
+
+		assert.ErrorIs(t, err, ErrUntrustedIssuer)
+	})
+	t.Run("vcr - trust is checked by the Nuts node", func(t *testing.T) {
+		registry := newRegistry(TrustVCR)
+
+		credentials, err := registry.FindAuthorizationCredentials(ctx, &VCRSearchParams{})
+
+		require.NoError(t, err)
+		assert.Len(t, credentials, 2)
+		assert.False(t, node.untrusted)
+	})
+	t.Run("all", func(t *testing.T) {
+		registry := newRegistry(TrustAll)
+
+		credentials, err := registry.FindAuthorizationCredentials(ctx, &VCRSearchParams{})
+
+		require.NoError(t, err)
+		assert.Len(t, credentials, 2)
+		assert.True(t, node.untrusted)
+	})
+}
+
+func TestHttpVerifiableCredentialRegistry_RevokeAuthorizationCredential(t *testing.T) {
+	withPath := func(id, path string) vcr.VerifiableCredential {
+		result := authorizationCredential(id, "did:nuts:issuer")
+		result.CredentialSubject["resources"] = []interface{}{map[string]interface{}{"path": path}}
+		return result
+	}
+	// the stub returns all credentials, like the Nuts node returns credentials of which the path starts with /Task/1
+	node := &stubVCR{credentials: []vcr.VerifiableCredential{
+		withPath("did:nuts:issuer#1", "/Task/1"),
+		withPath("did:nuts:issuer#12", "/Task/12"),
+	}, revoked: map[string]bool{}}
+	registry := NewVerifiableCredentialRegistry(node, cache.New(time.Minute, 10), TrustAll, stubOrganizations{})
+	ctx := context.Background()
+
+	t.Run("only revokes credentials with the exact path", func(t *testing.T) {
+		err := registry.RevokeAuthorizationCredential(ctx, "eOverdracht-sender", "did:nuts:subject", "/Task/1")
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"did:nuts:issuer#1": true}, node.revoked)
+	})
+	t.Run("revoking a revoked credential has no effect", func(t *testing.T) {
+		assert.NoError(t, registry.RevokeCredential(ctx, "did:nuts:issuer#1"))
+	})
+}
+
+func TestHttpVerifiableCredentialRegistry_ResolveVerifiableCredential(t *testing.T) {
+	expired := authorizationCredential("did:nuts:issuer#expired", "did:nuts:issuer")
+	expirationDate := time.Now().Add(-time.Second).Format(time.RFC3339)
+	expired.ExpirationDate = &expirationDate
+	ctx := context.Background()
+	newRegistry := func() (*stubVCR, VerifiableCredentialRegistry) {
+		node := &stubVCR{credentials: []vcr.VerifiableCredential{
+			authorizationCredential("did:nuts:issuer#1", "did:nuts:issuer"),
+			expired,
+		}, revoked: map[string]bool{}}
+		return node, NewVerifiableCredentialRegistry(node, cache.New(time.Minute, 10), TrustAll, stubOrganizations{})
+	}
+
+	t.Run("resolved credential is cached", func(t *testing.T) {
+		node, registry := newRegistry()
+
+		for i := 0; i < 2; i++ {
+			result, err := registry.ResolveVerifiableCredential(ctx, "did:nuts:issuer#1")
+			require.NoError(t, err)
+			assert.Equal(t, "did:nuts:issuer#1", result.ID.String())
+		}
+
+		assert.Equal(t, 1, node.resolved)
+	})
+	t.Run("cached credential expires with the credential", func(t *testing.T) {
+		node, registry := newRegistry()
+
+		_, _ = registry.ResolveVerifiableCredential(ctx, "did:nuts:issuer#expired")
+		_, _ = registry.ResolveVerifiableCredential(ctx, "did:nuts:issuer#expired")
+
+		assert.Equal(t, 2, node.resolved)
+	})
+	t.Run("revoking invalidates the cached credential", func(t *testing.T) {
+		node, registry := newRegistry()
+		_, _ = registry.ResolveVerifiableCredential(ctx, "did:nuts:issuer#1")
+
+		require.NoError(t, registry.RevokeCredential(ctx, "did:nuts:issuer#1"))
+		_, _ = registry.ResolveVerifiableCredential(ctx, "did:nuts:issuer#1")
+
+		assert.Equal(t, 2, node.resolved)
+	})
+	t.Run("errors are not cached", func(t *testing.T) {
+		node, registry := newRegistry()
+
+		_, err := registry.ResolveVerifiableCredential(ctx, "did:nuts:issuer#unknown")
+		assert.Error(t, err)
+		_, err = registry.ResolveVerifiableCredential(ctx, "did:nuts:issuer#unknown")
+		assert.Error(t, err)
+
+		assert.Equal(t, 2, node.resolved)
+	})
+}
+
+func TestParseTrustPolicy(t *testing.T) {
+	policy, err := ParseTrustPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, TrustOrganizations, policy)
+
+	policy, err = ParseTrustPolicy("vcr")
+	assert.NoError(t, err)
+	assert.Equal(t, TrustVCR, policy)
+
+	_, err = ParseTrustPolicy("nobody")
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+package sql
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AddColumn adds the column to a table that was created by an earlier version, which didn't have it yet.
+// The definition contains the type and constraints of the column, e.g. "varchar(200) NULL".
+func AddColumn(tx *sqlx.Tx, table, column, definition string) error {
+	var count int
+	if err := tx.Get(&count, `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column); err != nil {
+		return fmt.Errorf("unable to read columns of %s: %w", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("unable to add column %s to %s: %w", column, table, err)
+	}
+	return nil
+}
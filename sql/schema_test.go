@@ -0,0 +1,25 @@
+package sql
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddColumn(t *testing.T) {
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	db.MustExec(`CREATE TABLE item (id integer NOT NULL PRIMARY KEY)`)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+
+	require.NoError(t, AddColumn(tx, "item", "name", "varchar(200) NULL"))
+	require.NoError(t, AddColumn(tx, "item", "name", "varchar(200) NULL"), "adding it again has no effect")
+
+	tx.MustExec(`INSERT INTO item (id, name) VALUES (1, 'test')`)
+	var name string
+	require.NoError(t, tx.Get(&name, `SELECT name FROM item WHERE id = 1`))
+	assert.Equal(t, "test", name)
+}
@@ -13,11 +13,16 @@ var transactionManagerContextKey = "!!TXProvider"
 func Transactional(db *sqlx.DB) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
-			transactionManager := &TransactionManager{db: db}
-			tmContext := context.WithValue(ctx.Request().Context(), transactionManagerContextKey, transactionManager)
-			ctx.SetRequest(ctx.Request().WithContext(tmContext))
+			// requests dispatched by the EHR itself (e.g. by the FHIR proxy) reuse the transaction manager of the
+			// outer request, since a new transaction would wait for the single connection held by the outer request.
+			transactionManager, err := GetTransactionManager(ctx.Request().Context())
+			if err != nil {
+				transactionManager = &TransactionManager{db: db}
+				tmContext := context.WithValue(ctx.Request().Context(), transactionManagerContextKey, transactionManager)
+				ctx.SetRequest(ctx.Request().WithContext(tmContext))
+			}
 
-			err := next(ctx)
+			err = next(ctx)
 			if err != nil {
 				transactionManager.Rollback()
 			} else {
@@ -8,6 +8,7 @@ import EHRApp from './ehr/EHRApp.vue'
 import Login from './Login.vue'
 import PasswordAuthentication from './components/auth/PasswordAuthentication.vue'
 import IRMALogin from './components/auth/IRMALogin.vue'
+import OIDCLogin from './components/auth/OIDCLogin.vue'
 import Logout from './Logout.vue'
 import NotFound from './NotFound.vue'
 import Api from './plugins/api'
@@ -54,6 +55,12 @@ const routes = [
     component: IRMALogin,
     props: route => ({redirectPath: route.query.redirect})
   },
+  {
+    name: 'auth.oidc',
+    path: '/auth/oidc',
+    component: OIDCLogin,
+    props: route => ({error: route.query.error, redirectPath: route.query.redirect})
+  },
   {
     path: '/ehr',
     components: {
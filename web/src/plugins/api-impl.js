@@ -72,56 +72,59 @@ function createApi(options) {
 
         });
     },
-    authenticateWithDummy(parameters) {
+    listSigningMeans(parameters) {
       const params = typeof parameters === 'undefined' ? {} : parameters;
       let headers = {
 
       };
       handleSecurity([{"bearerAuth":[]}]
-          , headers, params, 'authenticateWithDummy');
-      return fetch(endpoint + basePath + '/auth/dummy'
+          , headers, params, 'listSigningMeans');
+      return fetch(endpoint + basePath + '/auth/means'
         , {
-          method: 'POST',
+          method: 'GET',
           headers,
           mode,
         });
     },
-    getDummyAuthenticationResult(parameters) {
+    createSignSession(parameters) {
       const params = typeof parameters === 'undefined' ? {} : parameters;
       let headers = {
+        'content-type': 'application/json',
 
       };
       handleSecurity([{"bearerAuth":[]}]
-          , headers, params, 'getDummyAuthenticationResult');
-      return fetch(endpoint + basePath + '/auth/dummy/session/' + params['sessionToken'] + '/result'
+          , headers, params, 'createSignSession');
+      return fetch(endpoint + basePath + '/auth/' + params['means'] + '/session'
         , {
-          method: 'GET',
+          method: 'POST',
           headers,
           mode,
+          body: JSON.stringify(params['body']),
+
         });
     },
-    authenticateWithIRMA(parameters) {
+    getSignSessionResult(parameters) {
       const params = typeof parameters === 'undefined' ? {} : parameters;
       let headers = {
 
       };
       handleSecurity([{"bearerAuth":[]}]
-          , headers, params, 'authenticateWithIRMA');
-      return fetch(endpoint + basePath + '/auth/irma/session'
+          , headers, params, 'getSignSessionResult');
+      return fetch(endpoint + basePath + '/auth/' + params['means'] + '/session/' + params['sessionToken'] + '/result'
         , {
-          method: 'POST',
+          method: 'GET',
           headers,
           mode,
         });
     },
-    getIRMAAuthenticationResult(parameters) {
+    getSignSessionEvents(parameters) {
       const params = typeof parameters === 'undefined' ? {} : parameters;
       let headers = {
 
       };
       handleSecurity([{"bearerAuth":[]}]
-          , headers, params, 'getIRMAAuthenticationResult');
-      return fetch(endpoint + basePath + '/auth/irma/session/' + params['sessionToken'] + '/result'
+          , headers, params, 'getSignSessionEvents');
+      return fetch(endpoint + basePath + '/auth/' + params['means'] + '/session/' + params['sessionToken'] + '/events'
         , {
           method: 'GET',
           headers,
@@ -145,6 +148,34 @@ function createApi(options) {
 
         });
     },
+    getOIDCStatus(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'getOIDCStatus');
+      return fetch(endpoint + basePath + '/auth/oidc'
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    exchangeOIDCSession(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'exchangeOIDCSession');
+      return fetch(endpoint + basePath + '/auth/oidc/session'
+        , {
+          method: 'POST',
+          headers,
+          mode,
+        });
+    },
     listCustomers(parameters) {
       const params = typeof parameters === 'undefined' ? {} : parameters;
       let headers = {
@@ -201,6 +232,256 @@ function createApi(options) {
           mode,
         });
     },
+    listUsers(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'listUsers');
+      return fetch(endpoint + basePath + '/private/admin/user'
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    createUser(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+        'content-type': 'application/json',
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'createUser');
+      return fetch(endpoint + basePath + '/private/admin/user'
+        , {
+          method: 'POST',
+          headers,
+          mode,
+          body: JSON.stringify(params['body']),
+
+        });
+    },
+    updateUser(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+        'content-type': 'application/json',
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'updateUser');
+      return fetch(endpoint + basePath + '/private/admin/user/' + params['userID'] + ''
+        , {
+          method: 'PUT',
+          headers,
+          mode,
+          body: JSON.stringify(params['body']),
+
+        });
+    },
+    deleteUser(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'deleteUser');
+      return fetch(endpoint + basePath + '/private/admin/user/' + params['userID'] + ''
+        , {
+          method: 'DELETE',
+          headers,
+          mode,
+        });
+    },
+    listAllCustomers(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'listAllCustomers');
+      return fetch(endpoint + basePath + '/private/admin/customer'
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    createCustomer(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+        'content-type': 'application/json',
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'createCustomer');
+      return fetch(endpoint + basePath + '/private/admin/customer'
+        , {
+          method: 'POST',
+          headers,
+          mode,
+          body: JSON.stringify(params['body']),
+
+        });
+    },
+    updateCustomer(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+        'content-type': 'application/json',
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'updateCustomer');
+      return fetch(endpoint + basePath + '/private/admin/customer/' + params['customerID'] + ''
+        , {
+          method: 'PUT',
+          headers,
+          mode,
+          body: JSON.stringify(params['body']),
+
+        });
+    },
+    deactivateCustomer(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'deactivateCustomer');
+      return fetch(endpoint + basePath + '/private/admin/customer/' + params['customerID'] + ''
+        , {
+          method: 'DELETE',
+          headers,
+          mode,
+        });
+    },
+    onboardCustomer(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'onboardCustomer');
+      return fetch(endpoint + basePath + '/private/admin/customer/' + params['customerID'] + '/onboard'
+        , {
+          method: 'POST',
+          headers,
+          mode,
+        });
+    },
+    getCredentialIssuers(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'getCredentialIssuers');
+      return fetch(endpoint + basePath + '/private/admin/trust/' + params['credentialType'] + ''
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    trustIssuer(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'trustIssuer');
+      return fetch(endpoint + basePath + '/private/admin/trust/' + params['credentialType'] + '/' + encodeURIComponent(params['issuer']) + ''
+        , {
+          method: 'PUT',
+          headers,
+          mode,
+        });
+    },
+    untrustIssuer(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'untrustIssuer');
+      return fetch(endpoint + basePath + '/private/admin/trust/' + params['credentialType'] + '/' + encodeURIComponent(params['issuer']) + ''
+        , {
+          method: 'DELETE',
+          headers,
+          mode,
+        });
+    },
+    logout(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'logout');
+      return fetch(endpoint + basePath + '/private/logout'
+        , {
+          method: 'POST',
+          headers,
+          mode,
+        });
+    },
+    getCustomerDiagnostics(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'getCustomerDiagnostics');
+      return fetch(endpoint + basePath + '/private/customer/diagnostics'
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    getCacheStats(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'getCacheStats');
+      return fetch(endpoint + basePath + '/private/admin/cache'
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    listIssuedCredentials(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'listIssuedCredentials');
+      return fetch(endpoint + basePath + '/private/customer/credentials'
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    revokeIssuedCredential(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'revokeIssuedCredential');
+      return fetch(endpoint + basePath + '/private/customer/credentials/' + encodeURIComponent(params['credentialID']) + ''
+        , {
+          method: 'DELETE',
+          headers,
+          mode,
+        });
+    },
     getCustomer(parameters) {
       const params = typeof parameters === 'undefined' ? {} : parameters;
       let headers = {
@@ -346,6 +627,10 @@ function createApi(options) {
       return fetch(endpoint + basePath + '/private/network/organizations' + '?' + buildQuery({
           'query': params['query'],
           'didServiceType': params['didServiceType'],
+          'bolt': params['bolt'],
+          'zipcode': params['zipcode'],
+          'maxDistance': params['maxDistance'],
+          'favouritesOnly': params['favouritesOnly'],
         })
 
         , {
@@ -354,6 +639,48 @@ function createApi(options) {
           mode,
         });
     },
+    getFavourites(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'getFavourites');
+      return fetch(endpoint + basePath + '/private/network/favourites'
+        , {
+          method: 'GET',
+          headers,
+          mode,
+        });
+    },
+    addFavourite(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'addFavourite');
+      return fetch(endpoint + basePath + '/private/network/favourites/' + encodeURIComponent(params['did']) + ''
+        , {
+          method: 'PUT',
+          headers,
+          mode,
+        });
+    },
+    removeFavourite(parameters) {
+      const params = typeof parameters === 'undefined' ? {} : parameters;
+      let headers = {
+
+      };
+      handleSecurity([{"bearerAuth":[]}]
+          , headers, params, 'removeFavourite');
+      return fetch(endpoint + basePath + '/private/network/favourites/' + encodeURIComponent(params['did']) + ''
+        , {
+          method: 'DELETE',
+          headers,
+          mode,
+        });
+    },
     getPatient(parameters) {
       const params = typeof parameters === 'undefined' ? {} : parameters;
       let headers = {
@@ -33,6 +33,9 @@ function wrapClientCall(client, functionName, apiOptions, router) {
     }
 }
 
+// streamingCalls return a stream of Server-Sent Events instead of JSON, their response is returned as is
+const streamingCalls = ['getSignSessionEvents']
+
 export default {
     install: (app, apiOptions = {}) => {
         const client = new Implementation({
@@ -50,7 +53,9 @@ export default {
         // Wrap all generated OpenAPI client functions with code for handling error and JSON responses
         let proxy = {}
         for (const member in client) {
-            if (typeof (client[member]) === 'function') {
+            if (streamingCalls.includes(member)) {
+                proxy[member] = client[member]
+            } else if (typeof (client[member]) === 'function') {
                 proxy[member] = wrapClientCall(client, member, apiOptions, app.config.globalProperties.$router)
             }
         }